@@ -0,0 +1,56 @@
+package main
+
+import (
+	"slices"
+	"strings"
+
+	"googlemaps.github.io/maps"
+)
+
+// component returns the long name of the first address component of any of the given types,
+// tried in order
+func component(components []maps.AddressComponent, types ...string) string {
+	for _, t := range types {
+		for _, c := range components {
+			if slices.Contains(c.Types, t) {
+				return c.LongName
+			}
+		}
+	}
+	return ""
+}
+
+// setAddressComponents splits a listing's address into its street, city, postcode and country
+// from the address components of its place details. UK addresses name the town as the postal
+// town, which is more useful to group by than the often tiny locality.
+func (b *Business) setAddressComponents(components []maps.AddressComponent) {
+	if len(components) == 0 {
+		return
+	}
+	b.Street = strings.TrimSpace(component(components, "street_number") + " " + component(components, "route"))
+	if b.Street == "" {
+		b.Street = component(components, "premise")
+	}
+	b.City = component(components, "postal_town", "locality", "administrative_area_level_2")
+	b.Postcode = component(components, "postal_code")
+	b.Country = component(components, "country")
+}
+
+// fillAddressParts sets the postcode of listings whose source didn't split their address, from
+// the UK postcode in it
+func (b *Business) fillAddressParts() {
+	if b.Postcode == "" {
+		b.Postcode = extractPostcode(b.Address)
+	}
+}
+
+// postcodeDistrict is the district of a UK postcode, its outward code before the space, e.g.
+// "SW1A" for "SW1A 1AA"; other countries' postcodes are returned whole
+func postcodeDistrict(postcode string) string {
+	postcode = strings.ToUpper(strings.TrimSpace(postcode))
+	if normalized := extractPostcode(postcode); normalized != "" {
+		district, _, _ := strings.Cut(normalized, " ")
+		return district
+	}
+	return postcode
+}
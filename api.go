@@ -0,0 +1,312 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"mime"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// maxRunHistory is how many past runs the API keeps the status of
+	maxRunHistory = 50
+	// defaultBusinessLimit is how many leads GET /businesses returns unless ?limit= says otherwise
+	defaultBusinessLimit = 100
+)
+
+var (
+	// errRunInProgress refuses a run while another is going; runs share the progress counters,
+	// run state and sinks, so they never overlap
+	errRunInProgress = errors.New("a search run is already in progress")
+	// errEnoughLeads stops listing leads once the limit is reached
+	errEnoughLeads = errors.New("enough leads")
+)
+
+// runStatus is one search run started by the schedule or through the API
+type runStatus struct {
+	ID       int        `json:"id"`
+	Trigger  string     `json:"trigger"`
+	Areas    []string   `json:"areas"`
+	State    string     `json:"state"`
+	Started  time.Time  `json:"started"`
+	Finished *time.Time `json:"finished,omitempty"`
+	Summary  string     `json:"summary,omitempty"`
+	Error    string     `json:"error,omitempty"`
+	// Progress is set while the run is going
+	Progress *progressSnapshot `json:"progress,omitempty"`
+
+	areas []*SearchArea
+}
+
+// runner runs searches one at a time and remembers how recent runs went
+type runner struct {
+	run *searchRun
+
+	mu      sync.Mutex
+	runs    []*runStatus
+	current *runStatus
+	lastID  int
+}
+
+func newRunner(run *searchRun) *runner {
+	return &runner{run: run}
+}
+
+// begin reserves a run of the given areas, all of them when nil, unless one is in progress
+func (r *runner) begin(areas []*SearchArea, trigger string) (*runStatus, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.current != nil {
+		return nil, errRunInProgress
+	}
+	if areas == nil {
+		areas = r.run.areas
+	}
+	r.lastID++
+	status := &runStatus{ID: r.lastID, Trigger: trigger, State: "running", Started: time.Now(), areas: areas}
+	for _, area := range areas {
+		status.Areas = append(status.Areas, area.Name)
+	}
+	r.current = status
+	r.runs = append(r.runs, status)
+	if len(r.runs) > maxRunHistory {
+		r.runs = r.runs[len(r.runs)-maxRunHistory:]
+	}
+	return status, nil
+}
+
+// execute runs a reserved run to the end, calling onLead, if set, with each new lead, and returns
+// what it wrote
+func (r *runner) execute(ctx context.Context, status *runStatus, onLead func(*Business)) *RunDigest {
+	fmt.Printf("Starting search run %d (%s) at %s\n", status.ID, status.Trigger, status.Started.Format(time.DateTime))
+	run := *r.run
+	run.areas, run.onLead = status.areas, onLead
+	summary, digest, err := run.Run(ctx)
+	if report := run.notionClient.options.Report(); report != "" {
+		fmt.Print(report)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	finished := time.Now()
+	status.Finished, status.Summary, status.State = &finished, strings.TrimSpace(summary), "finished"
+	if err != nil {
+		status.State, status.Error = "failed", err.Error()
+	}
+	r.current = nil
+	return digest
+}
+
+// status returns a copy of a run's status, with live progress if it is going; nil if unknown
+func (r *runner) status(id int) *runStatus {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, status := range r.runs {
+		if status.ID == id {
+			return r.snapshotLocked(status)
+		}
+	}
+	return nil
+}
+
+// statuses returns copies of the recent runs, newest first
+func (r *runner) statuses() []*runStatus {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	statuses := make([]*runStatus, 0, len(r.runs))
+	for i := len(r.runs) - 1; i >= 0; i-- {
+		statuses = append(statuses, r.snapshotLocked(r.runs[i]))
+	}
+	return statuses
+}
+
+func (r *runner) snapshotLocked(status *runStatus) *runStatus {
+	snapshot := *status
+	if status == r.current {
+		p := progress.Snapshot()
+		snapshot.Progress = &p
+	}
+	return &snapshot
+}
+
+// APIServer is the REST API of serve --listen: it starts search runs, reports on them, lists the
+// leads found and serves the dashboard for reviewing queued leads. When BUSINESS_FINDER_API_TOKEN
+// is set, requests must carry it as a bearer token; without it, only loopback addresses are served.
+type APIServer struct {
+	runner *runner
+	token  string
+	ctx    context.Context
+}
+
+// NewAPIServer initializes an APIServer; runs it starts stop when ctx is done
+func NewAPIServer(ctx context.Context, runner *runner) *APIServer {
+	return &APIServer{runner: runner, token: os.Getenv("BUSINESS_FINDER_API_TOKEN"), ctx: ctx}
+}
+
+// loopbackAddress reports whether a listen address only accepts connections from this machine;
+// an address without a host listens on every interface
+func loopbackAddress(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil || host == "" {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// Handler routes the API's endpoints
+func (api *APIServer) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /searches", api.startSearch)
+	mux.HandleFunc("GET /searches", api.listSearches)
+	mux.HandleFunc("GET /searches/{id}", api.getSearch)
+	mux.HandleFunc("GET /businesses", api.listBusinesses)
+	mux.HandleFunc("GET /review", api.listReview)
+	mux.HandleFunc("POST /review/push", api.pushReview)
+	mux.HandleFunc("POST /review/discard", api.discardReview)
+	// The page itself is public; the calls it makes carry the token the user enters
+	mux.HandleFunc("GET /{$}", api.dashboard)
+	return api.authorize(mux)
+}
+
+// authorize checks the bearer token, and that POSTs are JSON: browsers can't send that content
+// type across origins without a preflight, so other sites' pages can't start runs through a
+// browser on the same machine
+func (api *APIServer) authorize(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			if mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type")); err != nil || mediaType != "application/json" {
+				writeError(w, http.StatusUnsupportedMediaType, errors.New("POST bodies must be application/json"))
+				return
+			}
+		}
+		if api.token != "" && r.URL.Path != "/" {
+			token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(api.token)) != 1 {
+				writeError(w, http.StatusUnauthorized, errors.New("missing or wrong bearer token"))
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// startSearch starts a run of every configured area, or of the ones named in the body, e.g.
+// {"areas": ["Falmouth"]}, and returns its status before it finishes
+func (api *APIServer) startSearch(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Areas []string `json:"areas"`
+	}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid body: %w", err))
+			return
+		}
+	}
+	var areas []*SearchArea
+	for _, name := range body.Areas {
+		area := api.runner.area(name)
+		if area == nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("unknown area %q", name))
+			return
+		}
+		areas = append(areas, area)
+	}
+
+	status, err := api.runner.begin(areas, "api")
+	if errors.Is(err, errRunInProgress) {
+		writeError(w, http.StatusConflict, err)
+		return
+	}
+	go api.runner.execute(api.ctx, status, nil)
+	w.Header().Set("Location", fmt.Sprintf("/searches/%d", status.ID))
+	writeJSON(w, http.StatusAccepted, api.runner.status(status.ID))
+}
+
+// area finds a configured area by name
+func (r *runner) area(name string) *SearchArea {
+	for _, area := range r.run.areas {
+		if strings.EqualFold(area.Name, name) {
+			return area
+		}
+	}
+	return nil
+}
+
+func (api *APIServer) listSearches(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, api.runner.statuses())
+}
+
+func (api *APIServer) getSearch(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid run id %q", r.PathValue("id")))
+		return
+	}
+	status := api.runner.status(id)
+	if status == nil {
+		writeError(w, http.StatusNotFound, fmt.Errorf("no run %d", id))
+		return
+	}
+	writeJSON(w, http.StatusOK, status)
+}
+
+// listBusinesses returns the leads in Notion as merge variables, narrowed by ?where= (a filter
+// expression as bulk-set takes) and capped by ?limit=
+func (api *APIServer) listBusinesses(w http.ResponseWriter, r *http.Request) {
+	filter, err := ParseFilter(r.URL.Query().Get("where"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	limit := defaultBusinessLimit
+	if s := r.URL.Query().Get("limit"); s != "" {
+		if limit, err = strconv.Atoi(s); err != nil || limit <= 0 {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid limit %q", s))
+			return
+		}
+	}
+
+	businesses := []map[string]string{}
+	err = api.runner.run.notionClient.EachBusiness(r.Context(), nil, func(b Business) error {
+		if !filter.Matches(b) {
+			return nil
+		}
+		businesses = append(businesses, templateData(b))
+		if len(businesses) >= limit {
+			return errEnoughLeads
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errEnoughLeads) {
+		slog.Error("Failed to list leads", "operation", "api", "err", err)
+		writeError(w, http.StatusBadGateway, fmt.Errorf("listing leads: %w", err))
+		return
+	}
+	writeJSON(w, http.StatusOK, businesses)
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		slog.Warn("Failed to write response", "operation", "api", "err", err)
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
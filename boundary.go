@@ -0,0 +1,112 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"googlemaps.github.io/maps"
+)
+
+// Boundary is a polygonal area, such as a council boundary, that results must fall inside
+type Boundary struct {
+	// polygons hold rings of [lng, lat] positions; the first ring is the outline, the rest holes
+	polygons [][][][2]float64
+}
+
+// geoJSON covers the parts of a GeoJSON document needed to find its polygons
+type geoJSON struct {
+	Type        string          `json:"type"`
+	Coordinates json.RawMessage `json:"coordinates"`
+	Geometry    *geoJSON        `json:"geometry"`
+	Features    []geoJSON       `json:"features"`
+	Geometries  []geoJSON       `json:"geometries"`
+}
+
+// LoadBoundary reads the Polygon and MultiPolygon geometries of a GeoJSON file
+func LoadBoundary(path string) (*Boundary, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var doc geoJSON
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	b := &Boundary{}
+	if err := b.add(doc); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if len(b.polygons) == 0 {
+		return nil, fmt.Errorf("%s: no polygons found", path)
+	}
+	return b, nil
+}
+
+// add collects the polygons of a GeoJSON object and any features or geometries it contains
+func (b *Boundary) add(g geoJSON) error {
+	switch g.Type {
+	case "FeatureCollection":
+		for _, f := range g.Features {
+			if err := b.add(f); err != nil {
+				return err
+			}
+		}
+	case "Feature":
+		if g.Geometry != nil {
+			return b.add(*g.Geometry)
+		}
+	case "GeometryCollection":
+		for _, geom := range g.Geometries {
+			if err := b.add(geom); err != nil {
+				return err
+			}
+		}
+	case "Polygon":
+		var polygon [][][2]float64
+		if err := json.Unmarshal(g.Coordinates, &polygon); err != nil {
+			return fmt.Errorf("polygon coordinates: %w", err)
+		}
+		b.polygons = append(b.polygons, polygon)
+	case "MultiPolygon":
+		var polygons [][][][2]float64
+		if err := json.Unmarshal(g.Coordinates, &polygons); err != nil {
+			return fmt.Errorf("multipolygon coordinates: %w", err)
+		}
+		b.polygons = append(b.polygons, polygons...)
+	}
+	return nil
+}
+
+// Contains reports whether a point lies inside any of the boundary's polygons
+func (b *Boundary) Contains(p maps.LatLng) bool {
+	for _, polygon := range b.polygons {
+		if len(polygon) == 0 || !ringContains(polygon[0], p) {
+			continue
+		}
+		inHole := false
+		for _, hole := range polygon[1:] {
+			if ringContains(hole, p) {
+				inHole = true
+				break
+			}
+		}
+		if !inHole {
+			return true
+		}
+	}
+	return false
+}
+
+// ringContains is the even-odd ray casting test; areas as small as a county are treated as flat
+func ringContains(ring [][2]float64, p maps.LatLng) bool {
+	inside := false
+	for i, j := 0, len(ring)-1; i < len(ring); j, i = i, i+1 {
+		xi, yi := ring[i][0], ring[i][1]
+		xj, yj := ring[j][0], ring[j][1]
+		if (yi > p.Lat) != (yj > p.Lat) && p.Lng < (xj-xi)*(p.Lat-yi)/(yj-yi)+xi {
+			inside = !inside
+		}
+	}
+	return inside
+}
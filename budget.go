@@ -0,0 +1,223 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"googlemaps.github.io/maps"
+)
+
+// Google Places request kinds, as priced in googlePrices
+const (
+	requestNearbySearch = "nearby search"
+	requestTextSearch   = "text search"
+	requestPlaceDetails = "place details"
+	requestFindPlace    = "find place"
+	requestPlacePhoto   = "place photo"
+)
+
+// googlePrices are the list prices, in US dollars, of one request of each kind; details cost more
+// with contact and atmosphere fields, see detailsPrice. Monthly free credit and volume discounts
+// aren't taken into account, so this errs on the side of overestimating.
+var googlePrices = map[string]float64{
+	requestNearbySearch: 0.032,
+	requestTextSearch:   0.032,
+	requestPlaceDetails: 0.017,
+	requestFindPlace:    0.017,
+	requestPlacePhoto:   0.007,
+}
+
+const (
+	// contactDataPrice is added to details requests asking for any contactFields
+	contactDataPrice = 0.003
+	// atmosphereDataPrice is added to details requests asking for any atmosphereFields
+	atmosphereDataPrice = 0.005
+)
+
+var (
+	contactFields = []maps.PlaceDetailsFieldMask{maps.PlaceDetailsFieldMaskWebsite,
+		maps.PlaceDetailsFieldMaskInternationalPhoneNumber, maps.PlaceDetailsFieldMaskFormattedPhoneNumber,
+		maps.PlaceDetailsFieldMaskOpeningHours}
+	atmosphereFields = []maps.PlaceDetailsFieldMask{maps.PlaceDetailsFieldMaskRatings,
+		maps.PlaceDetailsFieldMaskUserRatingsTotal, maps.PlaceDetailsFieldMaskReviews}
+)
+
+// detailsPrice is the price of a details request for the given fields; no fields means all of them
+func detailsPrice(fields []maps.PlaceDetailsFieldMask) float64 {
+	price := googlePrices[requestPlaceDetails]
+	requested := func(group []maps.PlaceDetailsFieldMask) bool {
+		return len(fields) == 0 || slices.ContainsFunc(fields, func(f maps.PlaceDetailsFieldMask) bool { return slices.Contains(group, f) })
+	}
+	if requested(contactFields) {
+		price += contactDataPrice
+	}
+	if requested(atmosphereFields) {
+		price += atmosphereDataPrice
+	}
+	return price
+}
+
+const (
+	// maxSearchPages is how many pages of 20 results a Places search returns at most
+	maxSearchPages = 3
+	// maxSearchResults is how many results a Places search returns at most
+	maxSearchResults = 60
+)
+
+// errBudgetExhausted is returned instead of making a request that would take the spend past --max-budget
+var errBudgetExhausted = errors.New("budget for Google requests reached")
+
+// Budget tracks the estimated spend on Google requests during a run, records it in the usage
+// ledger under the run's profile, and refuses requests once the run's limit or the profile's
+// monthly budget would be exceeded
+type Budget struct {
+	// limit is the most to spend per run, in US dollars; 0 means no limit
+	limit   float64
+	ledger  *UsageLedger
+	profile string
+	// monthly is the profile's monthly budget in US dollars; 0 means no cap
+	monthly float64
+
+	mu     sync.Mutex
+	spent  float64
+	counts map[string]int
+	warned bool
+}
+
+// NewBudget initializes a Budget; limit and monthly are in US dollars, 0 for no limit
+func NewBudget(limit float64, ledger *UsageLedger, profile string, monthly float64) *Budget {
+	return &Budget{limit: limit, ledger: ledger, profile: profile, monthly: monthly, counts: make(map[string]int)}
+}
+
+// Reset starts counting a new run
+func (bu *Budget) Reset() {
+	bu.mu.Lock()
+	defer bu.mu.Unlock()
+	bu.spent, bu.warned = 0, false
+	clear(bu.counts)
+}
+
+// Charge records a request of a kind about to be made, at the kind's list price
+func (bu *Budget) Charge(kind string) error {
+	return bu.ChargePrice(kind, googlePrices[kind])
+}
+
+// ChargePrice records a request about to be made, or returns errBudgetExhausted if it would exceed the
+// run's limit or the profile's monthly budget. A warning is logged once a run takes the profile
+// past usageWarning of its monthly budget.
+func (bu *Budget) ChargePrice(kind string, price float64) error {
+	bu.mu.Lock()
+	defer bu.mu.Unlock()
+	if bu.limit > 0 && bu.spent+price > bu.limit {
+		return fmt.Errorf("%w: $%.2f of $%.2f spent", errBudgetExhausted, bu.spent, bu.limit)
+	}
+	month := usageMonth(time.Now())
+	if bu.monthly > 0 {
+		used := bu.ledger.Spent(month, bu.profile) + price
+		if used > bu.monthly {
+			return fmt.Errorf("%w: %s has spent $%.2f of its $%.2f monthly budget", errBudgetExhausted, bu.profile, used-price, bu.monthly)
+		}
+		if !bu.warned && used >= usageWarning*bu.monthly {
+			bu.warned = true
+			slog.Warn("Monthly Google budget nearly used", "operation", "budget", "profile", bu.profile,
+				"spent", fmt.Sprintf("$%.2f", used), "budget", fmt.Sprintf("$%.2f", bu.monthly))
+		}
+	}
+	bu.ledger.Add(month, bu.profile, kind, price)
+	bu.spent += price
+	bu.counts[kind]++
+	progress.SetSpend(bu.spent)
+	return nil
+}
+
+// String summarizes the run's spend by request kind
+func (bu *Budget) String() string {
+	bu.mu.Lock()
+	defer bu.mu.Unlock()
+	kinds := make([]string, 0, len(bu.counts))
+	for kind := range bu.counts {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	parts := make([]string, len(kinds))
+	for i, kind := range kinds {
+		parts[i] = fmt.Sprintf("%d %s", bu.counts[kind], kind)
+	}
+	line := fmt.Sprintf("Estimated Google API spend: $%.2f", bu.spent)
+	if bu.limit > 0 {
+		line += fmt.Sprintf(" of a $%.2f budget", bu.limit)
+	}
+	if len(parts) > 0 {
+		line += " (" + strings.Join(parts, ", ") + ")"
+	}
+	return line + "\n"
+}
+
+// meteredPlaces charges every request to a budget before passing it on
+type meteredPlaces struct {
+	PlacesProvider
+	budget *Budget
+}
+
+func (mp meteredPlaces) NearbySearch(ctx context.Context, r *maps.NearbySearchRequest) (maps.PlacesSearchResponse, error) {
+	if err := mp.budget.Charge(requestNearbySearch); err != nil {
+		return maps.PlacesSearchResponse{}, err
+	}
+	return mp.PlacesProvider.NearbySearch(ctx, r)
+}
+
+func (mp meteredPlaces) TextSearch(ctx context.Context, r *maps.TextSearchRequest) (maps.PlacesSearchResponse, error) {
+	if err := mp.budget.Charge(requestTextSearch); err != nil {
+		return maps.PlacesSearchResponse{}, err
+	}
+	return mp.PlacesProvider.TextSearch(ctx, r)
+}
+
+func (mp meteredPlaces) PlaceDetails(ctx context.Context, r *maps.PlaceDetailsRequest) (maps.PlaceDetailsResult, error) {
+	if err := mp.budget.ChargePrice(requestPlaceDetails, detailsPrice(r.Fields)); err != nil {
+		return maps.PlaceDetailsResult{}, err
+	}
+	return mp.PlacesProvider.PlaceDetails(ctx, r)
+}
+
+func (mp meteredPlaces) FindPlaceFromText(ctx context.Context, r *maps.FindPlaceFromTextRequest) (maps.FindPlaceFromTextResponse, error) {
+	if err := mp.budget.Charge(requestFindPlace); err != nil {
+		return maps.FindPlaceFromTextResponse{}, err
+	}
+	return mp.PlacesProvider.FindPlaceFromText(ctx, r)
+}
+
+func (mp meteredPlaces) PhotoURL(ctx context.Context, reference string) (string, error) {
+	if err := mp.budget.Charge(requestPlacePhoto); err != nil {
+		return "", err
+	}
+	return mp.PlacesProvider.PhotoURL(ctx, reference)
+}
+
+// estimateCost prices the Google searches of the areas before a run. The low end assumes every
+// search fits on one page and finds nothing new; the high end that every search returns the
+// maximum results and each needs details and photos. Overlapping grid cells and known leads put
+// real runs well below the high end.
+func estimateCost(areas []*SearchArea, detailsFields []maps.PlaceDetailsFieldMask) string {
+	searches := 0
+	for _, area := range areas {
+		for _, st := range area.Types {
+			searches += len(area.forType(st).Cells())
+		}
+		searches += len(area.Queries)
+	}
+	low := float64(searches) * googlePrices[requestNearbySearch]
+	perResult := detailsPrice(detailsFields)
+	if len(detailsFields) == 0 || slices.Contains(detailsFields, maps.PlaceDetailsFieldMaskPhotos) {
+		perResult += maxPhotos * googlePrices[requestPlacePhoto]
+	}
+	high := float64(searches) * (maxSearchPages*googlePrices[requestNearbySearch] + maxSearchResults*perResult)
+	return fmt.Sprintf("Estimated Google API cost: %d searches, $%.2f to $%.2f\n", searches, low, high)
+}
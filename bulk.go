@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"golang.org/x/time/rate"
+)
+
+// notionRequestsPerSecond is Notion's average rate limit per integration
+const notionRequestsPerSecond = 3
+
+// settableFields are the select fields bulk-set may change
+var settableFields = []string{"Urgency", "Contacted", "WebsiteStatus", "AssignedTo", "SearchArea"}
+
+// runBulkSet applies field changes to every lead matching a filter, in Notion and in the sinks
+func runBulkSet(notionClient *NotionClient, sinks []Sink, args []string) {
+	fs := flag.NewFlagSet("bulk-set", flag.ExitOnError)
+	where := fs.String("where", "", `filter expression, e.g. "type=cafe and town=Falmouth"`)
+	all := fs.Bool("all", false, "apply to every lead when --where is not given")
+	dryRun := fs.Bool("dry-run", false, "list the leads that would change without changing them")
+	changes := make(map[string]string)
+	fs.Func("set", "field=value to set on matching leads, e.g. urgency=Low (repeatable)", func(value string) error {
+		name, v, ok := strings.Cut(value, "=")
+		if !ok {
+			return fmt.Errorf("expected field=value, got %q", value)
+		}
+		field, err := resolveField(name)
+		if err != nil {
+			return err
+		}
+		if !slices.Contains(settableFields, field) {
+			return fmt.Errorf("%s can't be set; settable fields are %s", field, strings.Join(settableFields, ", "))
+		}
+		changes[field] = strings.TrimSpace(v)
+		return nil
+	})
+	fs.Parse(args)
+
+	if len(changes) == 0 {
+		log.Fatal("bulk-set: at least one --set is required")
+	}
+	if *where == "" && !*all {
+		log.Fatal("bulk-set: pass --where, or --all to change every lead")
+	}
+	filter, err := ParseFilter(*where)
+	if err != nil {
+		log.Fatalf("bulk-set: %v", err)
+	}
+
+	ctx := context.Background()
+	var matched []Business
+	err = notionClient.EachBusiness(ctx, nil, func(b Business) error {
+		if filter.Matches(b) {
+			matched = append(matched, b)
+		}
+		return nil
+	})
+	if err != nil {
+		log.Fatalf("Failed to list leads: %v", err)
+	}
+	if stableOrder {
+		sortBusinesses(matched)
+	}
+
+	pb := NewProperties(notionClient.options)
+	for field, value := range changes {
+		pb.Select(field, value)
+	}
+	props, err := pb.Build()
+	if err != nil {
+		log.Fatalf("bulk-set: %v", err)
+	}
+
+	limiter := rate.NewLimiter(notionRequestsPerSecond, 1)
+	placeIDs := make(map[string]bool, len(matched))
+	updated := 0
+	for _, b := range matched {
+		placeIDs[b.PlaceID] = true
+		if *dryRun {
+			fmt.Printf("Would update %s (%s)\n", b.Name, b.LeadNumber)
+			continue
+		}
+		if err := limiter.Wait(ctx); err != nil {
+			log.Fatal(err)
+		}
+		if err := notionClient.UpdateBusiness(ctx, b.PageID, props); err != nil {
+			slog.Error("Failed to update lead", "operation", "bulk-set", "place_id", b.PlaceID, "name", b.Name, "err", err)
+			continue
+		}
+		old := templateData(b)
+		for field, value := range changes {
+			if old[field] != value {
+				diffLog.Add(b, field, old[field], value, "bulk-set")
+			}
+		}
+		updated++
+	}
+	fmt.Printf("Matched %d leads, updated %d in Notion\n", len(matched), updated)
+	if *dryRun || len(placeIDs) == 0 {
+		return
+	}
+
+	rewritten, err := rewriteSinks(sinks, func(record map[string]string) bool {
+		if !placeIDs[record["PlaceID"]] {
+			return false
+		}
+		changed := false
+		for field, value := range changes {
+			changed = setExisting(record, field, value) || changed
+		}
+		return changed
+	})
+	if err != nil {
+		slog.Error("Failed to update sinks", "operation", "bulk-set", "err", err)
+	}
+	fmt.Printf("Updated %d sink records\n", rewritten)
+}
@@ -0,0 +1,216 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"log/slog"
+	"strings"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+const (
+	// cadenceDone is the next action of leads that have completed every step
+	cadenceDone = "Done"
+	// cadenceStopped is the next action of leads that opted out of contact or replied
+	cadenceStopped = "Stopped"
+	// followedUp is the Contacted status of leads contacted more than once without a reply
+	followedUp = "Followed Up"
+	// replied is the Contacted status of leads that answered; the conversation goes on by hand
+	replied = "Replied"
+)
+
+// cadenceFields are the fields the cadence maintains
+var cadenceFields = []string{"Contacted", "CadenceStep", "LastActionDate", "NextAction", "NextActionDate", "LastContacted", "NextFollowUp"}
+
+// CadenceStep is one outreach action, due a number of days after the cadence starts
+type CadenceStep struct {
+	Day    int    `json:"day"`
+	Action string `json:"action"`
+}
+
+// Cadence is a sequence of outreach steps, e.g. an email on day 0, a call on day 3 and a follow-up
+// email on day 7. Each step falls due counting from when the previous one was actually done, so a
+// late call pushes the follow-up back rather than bunching them up.
+type Cadence []CadenceStep
+
+// Validate checks that steps have actions and are in day order
+func (c Cadence) Validate() error {
+	for i, step := range c {
+		switch {
+		case strings.TrimSpace(step.Action) == "":
+			return fmt.Errorf("step %d: action is required", i)
+		case step.Day < 0:
+			return fmt.Errorf("step %d: day must not be negative", i)
+		case i > 0 && step.Day < c[i-1].Day:
+			return fmt.Errorf("step %d: day %d comes before the previous step's day %d", i, step.Day, c[i-1].Day)
+		}
+	}
+	return nil
+}
+
+// startOfDayUTC is the date of t as a UTC midnight, the form dates are stored in Notion
+func startOfDayUTC(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// Next returns a lead's next action and the day it is due. The cadence starts when the lead was
+// assigned, or created if it never was.
+func (c Cadence) Next(b *Business, now time.Time) (string, time.Time) {
+	switch {
+	case len(c) == 0:
+		return "", time.Time{}
+	case b.Contacted == doNotContact || b.Contacted == replied:
+		return cadenceStopped, b.NextActionDate
+	case b.CadenceStep >= len(c):
+		return cadenceDone, b.LastActionDate
+	}
+
+	step := c[b.CadenceStep]
+	if b.CadenceStep > 0 && !b.LastActionDate.IsZero() {
+		previous := c[b.CadenceStep-1]
+		return step.Action, startOfDayUTC(b.LastActionDate).AddDate(0, 0, step.Day-previous.Day)
+	}
+	start := now
+	switch {
+	case !b.AssignedDate.IsZero():
+		start = b.AssignedDate
+	case !b.Created.IsZero():
+		start = b.Created
+	}
+	return step.Action, startOfDayUTC(start).AddDate(0, 0, step.Day)
+}
+
+// Schedule sets a lead's next action, and its next follow-up while a step is still due,
+// reporting whether either changed
+func (c Cadence) Schedule(b *Business, now time.Time) bool {
+	action, due := c.Next(b, now)
+	followUp := due
+	if action == cadenceDone || action == cadenceStopped {
+		followUp = time.Time{}
+	}
+	if action == b.NextAction && formatDate(due) == formatDate(b.NextActionDate) && formatDate(followUp) == formatDate(b.NextFollowUp) {
+		return false
+	}
+	b.NextAction, b.NextActionDate, b.NextFollowUp = action, due, followUp
+	return true
+}
+
+// Complete records that a lead's next step was done today and schedules the one after. The first
+// step done marks the lead Contacted, later ones Followed Up.
+func (c Cadence) Complete(b *Business, now time.Time) error {
+	switch {
+	case b.Contacted == doNotContact || b.Contacted == replied:
+		return fmt.Errorf("%s is %s", b.Name, b.Contacted)
+	case b.CadenceStep >= len(c):
+		return fmt.Errorf("%s has completed the cadence", b.Name)
+	}
+	b.CadenceStep++
+	b.LastActionDate, b.LastContacted = startOfDayUTC(now), startOfDayUTC(now)
+	b.Contacted = "Contacted"
+	if b.CadenceStep > 1 {
+		b.Contacted = followedUp
+	}
+	c.Schedule(b, now)
+	return nil
+}
+
+// Reply records that a lead answered today, which ends its cadence
+func (c Cadence) Reply(b *Business, now time.Time) error {
+	if b.Contacted == doNotContact {
+		return fmt.Errorf("%s is %s", b.Name, doNotContact)
+	}
+	b.Contacted = replied
+	b.LastActionDate = startOfDayUTC(now)
+	c.Schedule(b, now)
+	return nil
+}
+
+// runCadence brings every lead's next action up to date, after recording the steps done with
+// --done and the replies with --replied
+func runCadence(notionClient *NotionClient, cfg *Config, sinks []Sink, args []string) {
+	fs := flag.NewFlagSet("cadence", flag.ExitOnError)
+	var done, answered []string
+	fs.Func("done", "lead number whose next step was done today, e.g. LEAD-12 (repeatable, or comma-separated)", func(value string) error {
+		for _, lead := range splitList(value) {
+			done = append(done, strings.ToUpper(lead))
+		}
+		return nil
+	})
+	fs.Func("replied", "lead number that replied today, ending its cadence (repeatable, or comma-separated)", func(value string) error {
+		for _, lead := range splitList(value) {
+			answered = append(answered, strings.ToUpper(lead))
+		}
+		return nil
+	})
+	dryRun := fs.Bool("dry-run", false, "list the leads whose next action would change without changing them")
+	fs.Parse(args)
+
+	if len(cfg.Cadence) == 0 {
+		log.Fatal("cadence: no cadence steps are configured")
+	}
+
+	ctx := context.Background()
+	now := time.Now()
+	remaining := make(map[string]bool, len(done))
+	for _, lead := range done {
+		remaining[lead] = true
+	}
+	replies := make(map[string]bool, len(answered))
+	for _, lead := range answered {
+		replies[lead] = true
+	}
+	var changed []Business
+	// before are the changed leads as they were, for the diff log
+	before := make(map[string]Business)
+	err := notionClient.EachLead(ctx, nil, func(b Business) error {
+		original, update := b, false
+		if remaining[b.LeadNumber] {
+			delete(remaining, b.LeadNumber)
+			if err := cfg.Cadence.Complete(&b, now); err != nil {
+				slog.Warn("Failed to complete cadence step", "operation", "cadence", "lead", b.LeadNumber, "err", err)
+			} else {
+				update = true
+			}
+		}
+		if replies[b.LeadNumber] {
+			delete(replies, b.LeadNumber)
+			if err := cfg.Cadence.Reply(&b, now); err != nil {
+				slog.Warn("Failed to record reply", "operation", "cadence", "lead", b.LeadNumber, "err", err)
+			} else {
+				update = true
+			}
+		}
+		if cfg.Cadence.Schedule(&b, now) || update {
+			fmt.Printf("%s (%s): %s on %s\n", b.Name, b.LeadNumber, b.NextAction, formatDate(b.NextActionDate))
+			changed = append(changed, b)
+			before[b.PageID] = original
+		}
+		return nil
+	})
+	if err != nil {
+		log.Fatalf("Failed to list leads: %v", err)
+	}
+	for lead := range remaining {
+		slog.Warn("No such lead", "operation", "cadence", "lead", lead)
+	}
+	for lead := range replies {
+		slog.Warn("No such lead", "operation", "cadence", "lead", lead)
+	}
+	if *dryRun {
+		fmt.Printf("Would update %d leads\n", len(changed))
+		return
+	}
+
+	limiter := rate.NewLimiter(notionRequestsPerSecond, 1)
+	for i := range changed {
+		if err := limiter.Wait(ctx); err != nil {
+			log.Fatal(err)
+		}
+		updateSinks(ctx, sinks, before[changed[i].PageID], &changed[i], cadenceFields, "cadence")
+	}
+	fmt.Printf("Updated %d leads\n", len(changed))
+}
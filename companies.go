@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+const (
+	// companiesHouseSearchURL is the Companies House company search endpoint
+	companiesHouseSearchURL = "https://api.company-information.service.gov.uk/search/companies"
+	// companiesHouseRequestsPerSecond keeps under Companies House's limit of 600 requests per 5 minutes
+	companiesHouseRequestsPerSecond = 2
+	// companiesHouseCandidates is how many search results are compared to a business
+	companiesHouseCandidates = 5
+)
+
+// CompaniesHouse looks UK businesses up in the Companies House register
+type CompaniesHouse struct {
+	apiKey  string
+	client  *http.Client
+	limiter *rate.Limiter
+}
+
+// NewCompaniesHouse initializes a new CompaniesHouse client
+func NewCompaniesHouse(apiKey string) *CompaniesHouse {
+	return &CompaniesHouse{
+		apiKey:  apiKey,
+		client:  &http.Client{Timeout: requestTimeout},
+		limiter: rate.NewLimiter(companiesHouseRequestsPerSecond, 1),
+	}
+}
+
+// companySearchItem is a company as returned by the register search
+type companySearchItem struct {
+	Title          string `json:"title"`
+	CompanyNumber  string `json:"company_number"`
+	CompanyStatus  string `json:"company_status"`
+	DateOfCreation string `json:"date_of_creation"`
+	AddressSnippet string `json:"address_snippet"`
+}
+
+// isUKBusiness reports whether a business looks to be in the UK, from its phone number or address
+func isUKBusiness(b *Business) bool {
+	return strings.HasPrefix(b.Phone, "+44") || extractPostcode(b.Address) != ""
+}
+
+func (ch *CompaniesHouse) Name() string { return "companies-house" }
+
+// Enrich attaches the registration of the company a UK business trades as, if the register has one
+// with a matching name. Sole traders and partnerships aren't registered, so no match is common.
+func (ch *CompaniesHouse) Enrich(ctx context.Context, b *Business) error {
+	if !isUKBusiness(b) {
+		return nil
+	}
+	query := url.Values{}
+	query.Set("q", b.Name)
+	query.Set("items_per_page", fmt.Sprint(companiesHouseCandidates))
+	var res struct {
+		Items []companySearchItem `json:"items"`
+	}
+	if err := ch.get(ctx, companiesHouseSearchURL+"?"+query.Encode(), &res); err != nil {
+		return err
+	}
+
+	company := bestCompany(b, res.Items)
+	if company == nil {
+		return nil
+	}
+	b.CompanyNumber = company.CompanyNumber
+	b.CompanyStatus = company.CompanyStatus
+	b.RegisteredAddress = company.AddressSnippet
+	if created, err := time.Parse(time.DateOnly, company.DateOfCreation); err == nil {
+		b.IncorporationDate = created
+	}
+	return nil
+}
+
+// bestCompany picks the search result whose name matches the business. Several companies often
+// share a name, so one registered in the business's postcode district wins, then an active one.
+func bestCompany(b *Business, items []companySearchItem) *companySearchItem {
+	district, _, _ := strings.Cut(extractPostcode(b.Address), " ")
+	var best *companySearchItem
+	bestRank := 0
+	for i, item := range items {
+		if normalizeName(item.Title) != normalizeName(b.Name) && tokenSetSimilarity(item.Title, b.Name) < verifyMatchThreshold {
+			continue
+		}
+		rank := 1
+		if district != "" && strings.HasPrefix(extractPostcode(item.AddressSnippet), district+" ") {
+			rank += 2
+		}
+		if item.CompanyStatus == "active" {
+			rank++
+		}
+		if rank > bestRank {
+			best, bestRank = &items[i], rank
+		}
+	}
+	return best
+}
+
+func (ch *CompaniesHouse) get(ctx context.Context, link string, out any) error {
+	if err := ch.limiter.Wait(ctx); err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, link, nil)
+	if err != nil {
+		return err
+	}
+	// The API key is sent as the basic auth username, with no password
+	req.SetBasicAuth(ch.apiKey, "")
+	resp, err := ch.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("companies house: unexpected status %d", resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
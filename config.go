@@ -0,0 +1,263 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+
+	"googlemaps.github.io/maps"
+)
+
+// defaultConfigPath is read when --config is not given; a missing file means built-in defaults
+const defaultConfigPath = "config.json"
+
+// Config holds the settings read from the JSON config file
+type Config struct {
+	// Area names the bundled area preset to search when --area is not given
+	Area string `json:"area,omitempty"`
+	// Locations are searched one after another in a single run when --area is not given.
+	// Types and radius default to those of the built-in search area when omitted.
+	Locations []SearchArea `json:"locations,omitempty"`
+	// UrgencyRules are evaluated in order; the first matching rule sets a business's urgency
+	UrgencyRules []UrgencyRule `json:"urgency_rules"`
+	// ScoreWeights configure the lead score when no campaign is selected
+	ScoreWeights ScoreWeights `json:"score_weights"`
+	// Campaigns override settings per campaign, selected with --campaign
+	Campaigns map[string]Campaign `json:"campaigns,omitempty"`
+	// MonthlyBudget caps the estimated Google spend, in US dollars, of runs without a campaign
+	// each calendar month; 0 means no cap
+	MonthlyBudget float64 `json:"monthly_budget,omitempty"`
+	// Sinks are where each enriched business is written, each with its own field selection.
+	// Notion alone, with every field, when omitted.
+	Sinks []SinkConfig `json:"sinks,omitempty"`
+	// NotionRoutes send the leads matching them to other Notion databases, possibly in other
+	// workspaces; the first matching route wins, and leads matching none go to NOTION_DATABASE_ID.
+	// Purges, cadence, send, rescore and delete-run go over every database; other commands reading
+	// leads back, such as recheck and export, read NOTION_DATABASE_ID only.
+	NotionRoutes []NotionRoute `json:"notion_routes,omitempty"`
+	// Types replace the place types of every area searched when --types is not given; any Google
+	// place type works, e.g. "dentist" or {"type": "store", "keyword": "surf shop"}
+	Types []SearchType `json:"types,omitempty"`
+	// ExcludeTypes are place types never searched, unless --exclude-types names others
+	ExcludeTypes []string `json:"exclude_types,omitempty"`
+	// Filters drop listings that aren't worth writing as leads, such as chains
+	Filters Filters `json:"filters,omitempty"`
+	// Sources are the providers searched, in order, when --source is not given. Later sources fill
+	// in what earlier ones lack, as FieldSources allow.
+	Sources []string `json:"sources,omitempty"`
+	// FieldSources are per-field rules on which sources' values a lead takes when merging listings
+	FieldSources FieldSources `json:"field_sources,omitempty"`
+	// DetailsFields are the Place Details fields requested: address, website, phone, status,
+	// hours, rating, reviews and photos. Each call bills for the fields it asks for, so the default
+	// of website, phone, status and address skips the costlier ones.
+	DetailsFields []string `json:"details_fields,omitempty"`
+	// PlacesAPI selects the Places API searches and details use: "legacy" (the default) or "new"
+	PlacesAPI string `json:"places_api,omitempty"`
+	// TypeConcurrency is how many place types Google is searched for at once; one after another
+	// when 0 or 1. Requests are still held to the rate limit and budget.
+	TypeConcurrency int `json:"type_concurrency,omitempty"`
+	// Cadence is the outreach sequence that sets each lead's next action; none when omitted
+	Cadence Cadence `json:"cadence,omitempty"`
+	// Notifiers are told about finished runs, hot leads and errors, each for the events it lists
+	Notifiers []NotifierConfig `json:"notifiers,omitempty"`
+	// SMTP is the mail server email notifiers and the send command send through
+	SMTP *SMTPConfig `json:"smtp,omitempty"`
+	// Policy limits scraping and how long personal contact data is kept
+	Policy Policy `json:"policy"`
+	// Enrichers name the steps each new lead goes through, in order: suggest-domain, crawl,
+	// domain-age, guess-email, companies-house, score and the hooks. All of them when omitted,
+	// with the hooks run just before score.
+	Enrichers []string `json:"enrichers,omitempty"`
+	// Hooks are external programs or HTTP endpoints each new lead is passed to for enrichment
+	Hooks []HookConfig `json:"hooks,omitempty"`
+}
+
+// ExcludedTypes returns the place types never searched
+func (c *Config) ExcludedTypes() ([]maps.PlaceType, error) {
+	return parsePlaceTypes(c.ExcludeTypes)
+}
+
+// ReviewSink returns the review sink's config, or nil when leads go straight to Notion
+func (c *Config) ReviewSink() *SinkConfig {
+	for i := range c.Sinks {
+		if c.Sinks[i].Type == "review" {
+			return &c.Sinks[i]
+		}
+	}
+	return nil
+}
+
+// Campaign holds settings that differ between prospecting campaigns
+type Campaign struct {
+	// ScoreWeights are applied over the top-level weights, so a campaign only lists what it changes
+	ScoreWeights json.RawMessage `json:"score_weights,omitempty"`
+	// MonthlyBudget caps the campaign's estimated Google spend, in US dollars, each calendar
+	// month, so one campaign can't use up the shared billing account; 0 means no cap
+	MonthlyBudget float64 `json:"monthly_budget,omitempty"`
+}
+
+// DetailsFieldMask returns the Place Details fields to request; Validate has checked their names
+func (c *Config) DetailsFieldMask() []maps.PlaceDetailsFieldMask {
+	mask, _ := detailsFieldMask(c.DetailsFields)
+	return mask
+}
+
+// ProfileBudget returns the monthly budget of a usage ledger profile: a campaign, or
+// defaultProfile for runs without one
+func (c *Config) ProfileBudget(profile string) float64 {
+	if profile == defaultProfile {
+		return c.MonthlyBudget
+	}
+	return c.Campaigns[profile].MonthlyBudget
+}
+
+// Weights returns the lead score weights for a campaign, falling back to the top-level weights
+func (c *Config) Weights(campaign string) (ScoreWeights, error) {
+	if campaign == "" {
+		return c.ScoreWeights, nil
+	}
+	camp, ok := c.Campaigns[campaign]
+	if !ok {
+		return ScoreWeights{}, fmt.Errorf("unknown campaign %q", campaign)
+	}
+	weights := c.ScoreWeights
+	if len(camp.ScoreWeights) > 0 {
+		if err := json.Unmarshal(camp.ScoreWeights, &weights); err != nil {
+			return ScoreWeights{}, fmt.Errorf("campaign %q score_weights: %w", campaign, err)
+		}
+	}
+	return weights, nil
+}
+
+// defaultConfig returns the settings used when no config file exists
+func defaultConfig() *Config {
+	return &Config{
+		UrgencyRules: defaultUrgencyRules(),
+		ScoreWeights: defaultScoreWeights(),
+	}
+}
+
+// LoadConfig reads the config file at path. A missing file is only an error if required is set.
+func LoadConfig(path string, required bool) (*Config, error) {
+	cfg := defaultConfig()
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) && !required {
+		return cfg, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, cfg); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// Validate checks the config for values that would otherwise fail mid-run
+func (c *Config) Validate() error {
+	for i, location := range c.Locations {
+		if location.Name == "" {
+			return fmt.Errorf("locations[%d]: name is required", i)
+		}
+		for _, st := range location.Types {
+			if _, err := parsePlaceType(string(st.Type)); err != nil {
+				return fmt.Errorf("locations[%d]: %w", i, err)
+			}
+		}
+	}
+	for i, st := range c.Types {
+		if _, err := parsePlaceType(string(st.Type)); err != nil {
+			return fmt.Errorf("types[%d]: %w", i, err)
+		}
+	}
+	if _, err := c.ExcludedTypes(); err != nil {
+		return fmt.Errorf("exclude_types: %w", err)
+	}
+	for i, rule := range c.UrgencyRules {
+		if err := rule.Validate(); err != nil {
+			return fmt.Errorf("urgency_rules[%d]: %w", i, err)
+		}
+	}
+	switch c.PlacesAPI {
+	case "", "legacy", "new":
+	default:
+		return fmt.Errorf("places_api must be \"legacy\" or \"new\", got %q", c.PlacesAPI)
+	}
+	if c.TypeConcurrency < 0 {
+		return fmt.Errorf("type_concurrency must not be negative")
+	}
+	if _, ok := c.Campaigns[defaultProfile]; ok {
+		return fmt.Errorf("campaigns: %q is reserved for runs without a campaign", defaultProfile)
+	}
+	for i, source := range c.Sources {
+		if !slices.Contains(knownSources, source) {
+			return fmt.Errorf("sources[%d]: unknown source %q", i, source)
+		}
+	}
+	if _, err := detailsFieldMask(c.DetailsFields); err != nil {
+		return fmt.Errorf("details_fields: %w", err)
+	}
+	if err := c.FieldSources.Validate(); err != nil {
+		return fmt.Errorf("field_sources: %w", err)
+	}
+	if err := c.Filters.Validate(); err != nil {
+		return fmt.Errorf("filters: %w", err)
+	}
+	if err := c.Policy.Validate(); err != nil {
+		return fmt.Errorf("policy: %w", err)
+	}
+	for i, hook := range c.Hooks {
+		if err := hook.Validate(); err != nil {
+			return fmt.Errorf("hooks[%d]: %w", i, err)
+		}
+		if slices.ContainsFunc(c.Hooks[:i], func(other HookConfig) bool { return other.Name == hook.Name }) {
+			return fmt.Errorf("hooks[%d]: hook %q is defined twice", i, hook.Name)
+		}
+	}
+	if err := validateEnrichers(c.Enrichers, c.Hooks); err != nil {
+		return fmt.Errorf("enrichers: %w", err)
+	}
+	if err := c.Cadence.Validate(); err != nil {
+		return fmt.Errorf("cadence: %w", err)
+	}
+	for i, notifier := range c.Notifiers {
+		if err := notifier.Validate(); err != nil {
+			return fmt.Errorf("notifiers[%d]: %w", i, err)
+		}
+		if notifier.Type == "email" && c.SMTP == nil {
+			return fmt.Errorf("notifiers[%d]: email notifier needs the smtp server configured", i)
+		}
+	}
+	if c.SMTP != nil {
+		if err := c.SMTP.Validate(); err != nil {
+			return err
+		}
+	}
+	for i, route := range c.NotionRoutes {
+		if err := route.Validate(); err != nil {
+			return fmt.Errorf("notion_routes[%d]: %w", i, err)
+		}
+		if slices.ContainsFunc(c.NotionRoutes[:i], func(other NotionRoute) bool { return other.Name == route.Name }) {
+			return fmt.Errorf("notion_routes[%d]: route %q is defined twice", i, route.Name)
+		}
+	}
+	held := 0
+	for i, sink := range c.Sinks {
+		if err := sink.Validate(); err != nil {
+			return fmt.Errorf("sinks[%d]: %w", i, err)
+		}
+		if sink.Type == "review" || sink.Type == "notion" {
+			held++
+		}
+	}
+	if held > 1 && c.ReviewSink() != nil {
+		return fmt.Errorf("sinks: a review sink holds leads back from Notion, so it can't be combined with a notion or another review sink")
+	}
+	return nil
+}
@@ -0,0 +1,9 @@
+//go:build !windows
+
+package main
+
+import "os"
+
+// enableANSI reports whether a terminal interprets ANSI escape sequences, which all but Windows
+// consoles do without being asked
+func enableANSI(f *os.File) bool { return true }
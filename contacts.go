@@ -0,0 +1,107 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/jomei/notionapi"
+)
+
+// contactProperties is the schema of the Contacts database, which holds one record per person or
+// inbox a lead can be reached at, so outreach can be tracked per contact. Its relation back to the
+// leads is the property Notion syncs with the leads' Contacts relation.
+func contactProperties() notionapi.PropertyConfigs {
+	return notionapi.PropertyConfigs{
+		"Name": notionapi.TitlePropertyConfig{
+			Type: notionapi.PropertyConfigTypeTitle,
+		},
+		"Email": notionapi.EmailPropertyConfig{
+			Type: notionapi.PropertyConfigTypeEmail,
+		},
+		"Phone": notionapi.PhoneNumberPropertyConfig{
+			Type: notionapi.PropertyConfigTypePhoneNumber,
+		},
+		"Contacted": notionapi.SelectPropertyConfig{
+			Type: notionapi.PropertyConfigTypeSelect,
+			Select: notionapi.Select{
+				Options: []notionapi.Option{
+					{Name: "Not Contacted"},
+					{Name: "Contacted"},
+					{Name: followedUp},
+					{Name: replied},
+					{Name: doNotContact},
+				},
+			},
+		},
+		"LastContactDate": notionapi.DatePropertyConfig{
+			Type: notionapi.PropertyConfigTypeDate,
+		},
+	}
+}
+
+// contactsRelation is the leads' relation to their records in the Contacts database
+func contactsRelation(contactsID notionapi.DatabaseID) notionapi.RelationPropertyConfig {
+	return notionapi.RelationPropertyConfig{
+		Type: notionapi.PropertyConfigTypeRelation,
+		Relation: notionapi.RelationConfig{
+			DatabaseID:   contactsID,
+			Type:         "dual_property",
+			DualProperty: &notionapi.DualProperty{},
+		},
+	}
+}
+
+// sameDatabase compares database IDs, which Notion returns dashed whether or not they were given so
+func sameDatabase(a, b notionapi.DatabaseID) bool {
+	return strings.ReplaceAll(string(a), "-", "") == strings.ReplaceAll(string(b), "-", "")
+}
+
+// createContact adds a record to the Contacts database for the email and phone scraped for a
+// business, as far as fields allow them, returning its page ID
+func (nc *NotionClient) createContact(ctx context.Context, business *Business, fields FieldSelector) (string, error) {
+	email := business.Email
+	if !fields.Allows("Email") {
+		email = ""
+	}
+	phone := business.Phone
+	if !fields.Allows("Phone") {
+		phone = ""
+	}
+	if email == "" && phone == "" {
+		return "", nil
+	}
+
+	properties, err := NewProperties(nil).
+		Title("Name", cmp.Or(email, phone)).
+		Email("Email", email).
+		Phone("Phone", phone).
+		Select("Contacted", "Not Contacted").
+		Build()
+	if err != nil {
+		return "", err
+	}
+	created, err := nc.client.Page.Create(ctx, &notionapi.PageCreateRequest{
+		Parent:     notionapi.Parent{DatabaseID: nc.contactsID},
+		Properties: properties,
+	})
+	if err != nil {
+		return "", err
+	}
+	return created.ID.String(), nil
+}
+
+// archiveContacts moves a lead's contact records to the trash; the personal data they hold goes
+// with the lead's own
+func (nc *NotionClient) archiveContacts(ctx context.Context, b Business) error {
+	var errs []error
+	for _, id := range b.Contacts {
+		_, err := nc.client.Page.Update(ctx, notionapi.PageID(id), &notionapi.PageUpdateRequest{
+			Properties: notionapi.Properties{},
+			Archived:   true,
+		})
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
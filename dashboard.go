@@ -0,0 +1,136 @@
+package main
+
+import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"golang.org/x/time/rate"
+)
+
+//go:embed dashboard/index.html
+var dashboardPage []byte
+
+// reviewLead is a queued lead as the dashboard shows it: its merge variables and where it is
+type reviewLead struct {
+	Fields map[string]string `json:"fields"`
+	Lat    float64           `json:"lat"`
+	Lng    float64           `json:"lng"`
+}
+
+// reviewQueue returns the queue of the configured review sink, or nil without one
+func (api *APIServer) reviewQueue() (*ReviewQueue, *SinkConfig, error) {
+	sc := api.runner.run.cfg.ReviewSink()
+	if sc == nil {
+		return nil, nil, nil
+	}
+	queue, err := openReviewQueue(sc.Path)
+	return queue, sc, err
+}
+
+func (api *APIServer) dashboard(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write(dashboardPage)
+}
+
+// listReview returns the leads waiting for review
+func (api *APIServer) listReview(w http.ResponseWriter, r *http.Request) {
+	queue, _, err := api.reviewQueue()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	leads := []reviewLead{}
+	if queue != nil {
+		for _, b := range queue.Leads() {
+			leads = append(leads, reviewLead{Fields: templateData(b), Lat: b.Location.Lat, Lng: b.Location.Lng})
+		}
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"enabled": queue != nil, "leads": leads})
+}
+
+// reviewSelection reads the place IDs a review action applies to, e.g. {"place_ids": ["..."]}
+func reviewSelection(r *http.Request) ([]string, error) {
+	var body struct {
+		PlaceIDs []string `json:"place_ids"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("invalid body: %w", err)
+	}
+	if len(body.PlaceIDs) == 0 {
+		return nil, errors.New("no place_ids selected")
+	}
+	return body.PlaceIDs, nil
+}
+
+// pushReview inserts the selected leads into Notion with the review sink's fields and takes them
+// off the queue; leads that fail stay queued
+func (api *APIServer) pushReview(w http.ResponseWriter, r *http.Request) {
+	queue, sc, err := api.reviewQueue()
+	if err == nil && queue == nil {
+		err = errors.New("no review sink is configured")
+	}
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	placeIDs, err := reviewSelection(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	pushed, failed := map[string]string{}, map[string]string{}
+	limiter := rate.NewLimiter(notionRequestsPerSecond, 1)
+	for _, b := range queue.Take(placeIDs) {
+		if err := limiter.Wait(r.Context()); err != nil {
+			queue.Add(&b)
+			failed[b.PlaceID] = err.Error()
+			continue
+		}
+		err := api.runner.run.notionClient.InsertRouted(r.Context(), &b, sc.FieldSelector)
+		if errors.Is(err, errBusinessExists) {
+			pushed[b.PlaceID] = ""
+			continue
+		}
+		if err != nil {
+			slog.Error("Failed to push lead", "operation", "review", "place_id", b.PlaceID, "name", b.Name, "err", err)
+			queue.Add(&b)
+			failed[b.PlaceID] = err.Error()
+			continue
+		}
+		pushed[b.PlaceID] = b.LeadNumber
+		if b.Urgency == "High" {
+			api.runner.run.notify.Send(r.Context(), Event{Type: eventHotLead, Summary: fmt.Sprintf("New High urgency lead: %s, %s", b.Name, b.Address), Lead: &b})
+		}
+	}
+	if err := queue.Save(); err != nil {
+		slog.Error("Failed to save review queue", "operation", "review", "path", sc.Path, "err", err)
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"pushed": pushed, "failed": failed})
+}
+
+// discardReview drops the selected leads from the queue without inserting them
+func (api *APIServer) discardReview(w http.ResponseWriter, r *http.Request) {
+	queue, sc, err := api.reviewQueue()
+	if err == nil && queue == nil {
+		err = errors.New("no review sink is configured")
+	}
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	placeIDs, err := reviewSelection(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	discarded := queue.Take(placeIDs)
+	if err := queue.Save(); err != nil {
+		slog.Error("Failed to save review queue", "operation", "review", "path", sc.Path, "err", err)
+	}
+	writeJSON(w, http.StatusOK, map[string]int{"discarded": len(discarded)})
+}
@@ -0,0 +1,331 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"log"
+	"log/slog"
+	"net"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jomei/notionapi"
+	"golang.org/x/time/rate"
+)
+
+// defaultDeadLetterPath is where failed writes are kept unless --dead-letters says otherwise
+const defaultDeadLetterPath = ".business-finder-failed.json"
+
+// deadLetter is a lead a sink failed to write, kept to be written again by retry-failed
+type deadLetter struct {
+	Sink     string    `json:"sink"`
+	Category string    `json:"category"`
+	Error    string    `json:"error"`
+	Failed   time.Time `json:"failed"`
+	Attempts int       `json:"attempts"`
+	Lead     Business  `json:"lead"`
+}
+
+// DeadLetters are the failed writes of every run not yet retried successfully, one per lead and
+// sink. A nil DeadLetters keeps nothing.
+type DeadLetters struct {
+	path string
+
+	mu      sync.Mutex
+	entries []deadLetter
+	changed bool
+	// added are the failures since the last Reset, for the run summary
+	added []deadLetter
+}
+
+// LoadDeadLetters reads the dead letter file at path; a missing file has no failures
+func LoadDeadLetters(path string) (*DeadLetters, error) {
+	dl := &DeadLetters{path: path}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return dl, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &dl.entries); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	return dl, nil
+}
+
+// Add records that a sink failed to write a lead, replacing an earlier failure of the same lead
+// and sink
+func (dl *DeadLetters) Add(sink string, b *Business, err error) {
+	if dl == nil {
+		return
+	}
+	entry := deadLetter{Sink: sink, Category: writeErrorCategory(err), Error: err.Error(), Failed: time.Now(), Attempts: 1, Lead: *b}
+	dl.mu.Lock()
+	defer dl.mu.Unlock()
+	if i := dl.index(sink, b.PlaceID); i >= 0 {
+		entry.Attempts += dl.entries[i].Attempts
+		dl.entries[i] = entry
+	} else {
+		dl.entries = append(dl.entries, entry)
+	}
+	dl.added = append(dl.added, entry)
+	dl.changed = true
+}
+
+// index returns the position of a lead's failure in a sink, or -1; dl.mu must be held
+func (dl *DeadLetters) index(sink, placeID string) int {
+	return slices.IndexFunc(dl.entries, func(entry deadLetter) bool {
+		return entry.Sink == sink && entry.Lead.PlaceID == placeID
+	})
+}
+
+// remove drops a lead's failure in a sink, once it was written after all
+func (dl *DeadLetters) remove(sink, placeID string) {
+	dl.mu.Lock()
+	defer dl.mu.Unlock()
+	if i := dl.index(sink, placeID); i >= 0 {
+		dl.entries = slices.Delete(dl.entries, i, i+1)
+		dl.changed = true
+	}
+}
+
+// Purge strips the personal contact data of the failed writes matching drop, so a retry can't
+// write back what an opt-out or the retention period removed everywhere else. It returns how
+// many it purged.
+func (dl *DeadLetters) Purge(drop func(entry deadLetter) bool) int {
+	if dl == nil {
+		return 0
+	}
+	dl.mu.Lock()
+	defer dl.mu.Unlock()
+	purged := 0
+	for i := range dl.entries {
+		entry := &dl.entries[i]
+		if !hasPersonalData(entry.Lead) || !drop(*entry) {
+			continue
+		}
+		clearPersonalFields(&entry.Lead)
+		dl.changed = true
+		purged++
+	}
+	return purged
+}
+
+// Len returns how many failed writes are kept
+func (dl *DeadLetters) Len() int {
+	if dl == nil {
+		return 0
+	}
+	dl.mu.Lock()
+	defer dl.mu.Unlock()
+	return len(dl.entries)
+}
+
+// Summary reports the writes that failed since the last Reset by category and sink, or "" if none
+func (dl *DeadLetters) Summary() string {
+	if dl == nil {
+		return ""
+	}
+	dl.mu.Lock()
+	defer dl.mu.Unlock()
+	if len(dl.added) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("Failed writes: %d (%s)\n", len(dl.added), describeDeadLetters(dl.added))
+}
+
+// describeDeadLetters counts failures by category and sink, most common first, e.g.
+// "3 rate limited to notion, 1 file to csv"
+func describeDeadLetters(entries []deadLetter) string {
+	counts := make(map[string]int)
+	for _, entry := range entries {
+		counts[entry.Category+" to "+entry.Sink]++
+	}
+	keys := make([]string, 0, len(counts))
+	for key := range counts {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if counts[keys[i]] != counts[keys[j]] {
+			return counts[keys[i]] > counts[keys[j]]
+		}
+		return keys[i] < keys[j]
+	})
+	parts := make([]string, len(keys))
+	for i, key := range keys {
+		parts[i] = fmt.Sprintf("%d %s", counts[key], key)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Reset forgets the failures counted for the summary, for the next run
+func (dl *DeadLetters) Reset() {
+	if dl == nil {
+		return
+	}
+	dl.mu.Lock()
+	defer dl.mu.Unlock()
+	dl.added = nil
+}
+
+// Save writes the failures to the dead letter file if they changed, removing the file once none
+// are left
+func (dl *DeadLetters) Save() error {
+	if dl == nil {
+		return nil
+	}
+	dl.mu.Lock()
+	defer dl.mu.Unlock()
+	if !dl.changed {
+		return nil
+	}
+	if len(dl.entries) == 0 {
+		if err := os.Remove(dl.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		dl.changed = false
+		return nil
+	}
+	data, err := json.MarshalIndent(dl.entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	err = replaceFile(dl.path, func(f *os.File) error {
+		_, err := f.Write(data)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	dl.changed = false
+	return nil
+}
+
+// writeErrorCategory sorts a failed write by its cause, telling failures worth retrying as they are,
+// such as rate limits and timeouts, from ones that need the lead or the setup fixed first
+func writeErrorCategory(err error) string {
+	var rateLimited *notionapi.RateLimitedError
+	var notionErr *notionapi.Error
+	var netErr net.Error
+	var pathErr *fs.PathError
+	switch {
+	case errors.As(err, &rateLimited):
+		return "rate limited"
+	case errors.As(err, &notionErr):
+		switch {
+		case notionErr.Status == 429:
+			return "rate limited"
+		case notionErr.Status == 400:
+			return "validation"
+		case notionErr.Status == 401 || notionErr.Status == 403:
+			return "unauthorized"
+		case notionErr.Status == 404:
+			return "not found"
+		case notionErr.Status == 409:
+			return "conflict"
+		case notionErr.Status >= 500:
+			return "unavailable"
+		}
+		return "notion"
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+		return "timeout"
+	case errors.As(err, &netErr):
+		return "network"
+	case errors.As(err, &pathErr):
+		return "file"
+	}
+	return "other"
+}
+
+// runRetryFailed writes the leads in the dead letter file again, each to the sink that failed it.
+// Writes that succeed, or find the lead already there, are dropped from the file; the rest stay
+// with their latest error. Leads marked Do Not Contact since, or past the retention period, are
+// written without their contact data.
+func runRetryFailed(notionClient *NotionClient, policy Policy, deadLetters *DeadLetters, sinks []Sink, args []string) {
+	fs := flag.NewFlagSet("retry-failed", flag.ExitOnError)
+	category := fs.String("category", "", "only retry failures of this category, e.g. rate limited or timeout")
+	sinkName := fs.String("sink", "", "only retry failures of this sink, e.g. notion")
+	dryRun := fs.Bool("dry-run", false, "list the failures that would be retried without retrying them")
+	fs.Parse(args)
+
+	deadLetters.mu.Lock()
+	entries := slices.Clone(deadLetters.entries)
+	deadLetters.mu.Unlock()
+	if len(entries) == 0 {
+		fmt.Printf("No failed writes in %s\n", deadLetters.path)
+		return
+	}
+
+	ctx := context.Background()
+	optedOut := make(map[string]bool)
+	err := notionClient.EachLead(ctx, &notionapi.PropertyFilter{
+		Property: "Contacted",
+		Select:   &notionapi.SelectFilterCondition{Equals: doNotContact},
+	}, func(b Business) error {
+		optedOut[b.PlaceID] = true
+		return nil
+	})
+	if err != nil {
+		log.Fatalf("Failed to list %s leads: %v", doNotContact, err)
+	}
+	cutoff := time.Now().AddDate(0, 0, -policy.RetentionDays)
+	purged := deadLetters.Purge(func(entry deadLetter) bool {
+		return optedOut[entry.Lead.PlaceID] || (policy.RetentionDays > 0 && entry.Failed.Before(cutoff))
+	})
+	if purged > 0 {
+		fmt.Printf("Purged contact data of %d failed writes\n", purged)
+	}
+	deadLetters.mu.Lock()
+	entries = slices.Clone(deadLetters.entries)
+	deadLetters.mu.Unlock()
+
+	limiter := rate.NewLimiter(notionRequestsPerSecond, 1)
+	retried, written, failed := 0, 0, 0
+	for _, entry := range entries {
+		if (*category != "" && entry.Category != *category) || (*sinkName != "" && entry.Sink != *sinkName) {
+			continue
+		}
+		i := slices.IndexFunc(sinks, func(sink Sink) bool { return sink.Name() == entry.Sink })
+		if i < 0 {
+			slog.Warn("Sink of failed write isn't configured", "operation", "retry-failed", "sink", entry.Sink, "place_id", entry.Lead.PlaceID, "name", entry.Lead.Name)
+			continue
+		}
+		retried++
+		if *dryRun {
+			fmt.Printf("%s to %s: %s (%s, %d attempts)\n", entry.Lead.Name, entry.Sink, entry.Error, entry.Category, entry.Attempts)
+			continue
+		}
+		if err := limiter.Wait(ctx); err != nil {
+			log.Fatal(err)
+		}
+		lead := entry.Lead
+		err := sinks[i].Write(ctx, &lead)
+		if err != nil && !errors.Is(err, errBusinessExists) {
+			slog.Error("Failed to write lead", "operation", "retry-failed", "sink", entry.Sink, "place_id", lead.PlaceID, "name", lead.Name, "err", err)
+			deadLetters.Add(entry.Sink, &entry.Lead, err)
+			failed++
+			continue
+		}
+		deadLetters.remove(entry.Sink, lead.PlaceID)
+		written++
+		fmt.Printf("Wrote %s to %s\n", lead.Name, entry.Sink)
+	}
+
+	if *dryRun {
+		fmt.Printf("Would retry %d of %d failed writes\n", retried, len(entries))
+		return
+	}
+	fmt.Printf("Retried %d of %d failed writes: %d written, %d failed again\n", retried, len(entries), written, failed)
+	if err := deadLetters.Save(); err != nil {
+		slog.Error("Failed to save failed writes", "operation", "retry-failed", "path", deadLetters.path, "err", err)
+	}
+}
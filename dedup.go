@@ -0,0 +1,161 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"log/slog"
+	"slices"
+
+	"github.com/jomei/notionapi"
+	"googlemaps.github.io/maps"
+)
+
+const (
+	// duplicateNameSimilarity is how alike two names must be, from 0 to 1, for listings under
+	// different PlaceIDs to be flagged as the same business
+	duplicateNameSimilarity = 0.8
+	// duplicateAddressSimilarity is how alike their addresses must be when no postcodes or
+	// locations settle it
+	duplicateAddressSimilarity = 0.6
+)
+
+// DuplicateFinder is implemented by sinks that can look up a lead they already hold under another
+// PlaceID, such as a business that was re-listed, that a new lead looks like
+type DuplicateFinder interface {
+	Duplicate(ctx context.Context, b *Business) (*Business, error)
+}
+
+// levenshtein is the number of single character edits turning a into b
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := range ra {
+		cur := make([]int, len(rb)+1)
+		cur[0] = i + 1
+		for j := range rb {
+			cost := 1
+			if ra[i] == rb[j] {
+				cost = 0
+			}
+			cur[j+1] = min(prev[j+1]+1, cur[j]+1, prev[j]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(rb)]
+}
+
+// nameSimilarity scores how alike two business names are from 0 to 1: the better of their
+// shared words and their edit distance, so both reordered words and typos count as alike
+func nameSimilarity(a, b string) float64 {
+	na, nb := normalizeName(a), normalizeName(b)
+	longest := max(len([]rune(na)), len([]rune(nb)))
+	if longest == 0 {
+		return 0
+	}
+	edits := 1 - float64(levenshtein(na, nb))/float64(longest)
+	return max(tokenSetSimilarity(a, b), edits)
+}
+
+// longestNameWord returns the longest word of a business name, or "" when it has none of at
+// least three letters worth narrowing a search by
+func longestNameWord(name string) string {
+	tokens := nameTokens(name)
+	if len(tokens) == 0 {
+		return ""
+	}
+	word := slices.MaxFunc(tokens, func(a, b string) int { return cmp.Compare(len(a), len(b)) })
+	if len(word) < 3 {
+		return ""
+	}
+	return word
+}
+
+// duplicateKeys are the buckets a lead's likely duplicates are looked for in, as FindDuplicate
+// looks them up in Notion: its postcode and the longest word of its name
+func duplicateKeys(b *Business) []string {
+	var keys []string
+	if postcode := extractPostcode(b.Address); postcode != "" {
+		keys = append(keys, "postcode:"+postcode)
+	}
+	if word := longestNameWord(b.Name); word != "" {
+		keys = append(keys, "name:"+word)
+	}
+	return keys
+}
+
+// likelyDuplicate reports whether two listings under different PlaceIDs look like the same
+// business: near-identical names at the same postcode, location or a similar address
+func likelyDuplicate(a, b *Business) bool {
+	if a.PlaceID == b.PlaceID || nameSimilarity(a.Name, b.Name) < duplicateNameSimilarity {
+		return false
+	}
+	postcodeA, postcodeB := extractPostcode(a.Address), extractPostcode(b.Address)
+	if postcodeA != "" && postcodeB != "" {
+		return postcodeA == postcodeB
+	}
+	var zero maps.LatLng
+	if a.Location != zero && b.Location != zero {
+		return distanceMetres(a.Location, b.Location) <= sameBusinessDistance
+	}
+	return a.Address != "" && b.Address != "" && tokenSetSimilarity(a.Address, b.Address) >= duplicateAddressSimilarity
+}
+
+// flagDuplicate marks a lead about to be written that looks like one a sink already holds under
+// another PlaceID, so it is checked by hand rather than worked twice
+func (f *Finder) flagDuplicate(ctx context.Context, job *insertJob) {
+	business := job.lead
+	for _, sink := range f.sinks {
+		finder, ok := sink.(DuplicateFinder)
+		if !ok {
+			continue
+		}
+		duplicate, err := finder.Duplicate(ctx, business)
+		if err != nil {
+			slog.Warn("Failed to look for duplicates", "operation", "dedup", "sink", sink.Name(), "place_id", business.PlaceID, "name", business.Name, "err", err)
+			continue
+		}
+		if duplicate == nil {
+			continue
+		}
+		business.DuplicateOf = cmp.Or(duplicate.LeadNumber, duplicate.Name)
+		job.issues.add(severityHigh, "likely duplicate", business, "looks like %s %s (%s) under another PlaceID", duplicate.LeadNumber, duplicate.Name, duplicate.Address)
+		return
+	}
+}
+
+// FindDuplicate returns the lead in the database most like a business under another PlaceID, or
+// nil. Candidates are the leads at the business's postcode or sharing the longest word of its name.
+func (nc *NotionClient) FindDuplicate(ctx context.Context, b *Business) (*Business, error) {
+	var filter notionapi.OrCompoundFilter
+	if postcode := extractPostcode(b.Address); postcode != "" {
+		filter = append(filter, notionapi.PropertyFilter{
+			Property: "Address",
+			RichText: &notionapi.TextFilterCondition{Contains: postcode},
+		})
+	}
+	if word := longestNameWord(b.Name); word != "" {
+		filter = append(filter, notionapi.PropertyFilter{
+			Property: "Name",
+			RichText: &notionapi.TextFilterCondition{Contains: word},
+		})
+	}
+	if len(filter) == 0 {
+		return nil, nil
+	}
+
+	var best *Business
+	bestSimilarity := 0.0
+	err := nc.EachBusiness(ctx, filter, func(candidate Business) error {
+		if !likelyDuplicate(&candidate, b) {
+			return nil
+		}
+		if similarity := nameSimilarity(candidate.Name, b.Name); similarity > bestSimilarity {
+			best, bestSimilarity = &candidate, similarity
+		}
+		return nil
+	})
+	return best, err
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"math"
+	"testing"
+
+	"googlemaps.github.io/maps"
+)
+
+func TestExtractPostcode(t *testing.T) {
+	tests := []struct {
+		address string
+		want    string
+	}{
+		{"12 Arwenack Street, Falmouth TR11 3JA, UK", "TR11 3JA"},
+		{"12 Arwenack Street, Falmouth tr113ja", "TR11 3JA"},
+		{"1 Victoria Street, London SW1A 1AA", "SW1A 1AA"},
+		{"Unit 4, Manchester M1 1AE", "M1 1AE"},
+		{"The Quay, Flushing, Falmouth", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := extractPostcode(tt.address); got != tt.want {
+			t.Errorf("extractPostcode(%q) = %q, want %q", tt.address, got, tt.want)
+		}
+	}
+}
+
+func TestNameSimilarity(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		atLeast  float64
+		lessThan float64
+	}{
+		{"Harbour Bakery", "Harbour Bakery", 1, 1.01},
+		{"The Harbour Bakery Ltd", "Harbour Bakery", 1, 1.01},
+		{"Bakery Harbour", "Harbour Bakery", 1, 1.01},
+		{"Harbour Bakrey", "Harbour Bakery", duplicateNameSimilarity, 1},
+		{"Harbour Bakery", "Harbour Cafe", 0, duplicateNameSimilarity},
+		{"Harbour Bakery", "Quay Cafe", 0, duplicateNameSimilarity},
+		{"", "Harbour Bakery", 0, 0.01},
+	}
+	for _, tt := range tests {
+		if got := nameSimilarity(tt.a, tt.b); got < tt.atLeast || got >= tt.lessThan {
+			t.Errorf("nameSimilarity(%q, %q) = %.2f, want in [%.2f, %.2f)", tt.a, tt.b, got, tt.atLeast, tt.lessThan)
+		}
+	}
+}
+
+func TestTokenSetSimilarity(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want float64
+	}{
+		{"Harbour Bakery", "Bakery Harbour", 1},
+		{"Harbour Bakery & Cafe", "Harbour Bakery and Cafe", 1},
+		{"Harbour Bakery", "Harbour Cafe", 1.0 / 3},
+		{"Harbour Bakery", "Quay Cafe", 0},
+		{"The Ltd", "Harbour Bakery", 0},
+	}
+	for _, tt := range tests {
+		if got := tokenSetSimilarity(tt.a, tt.b); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("tokenSetSimilarity(%q, %q) = %.3f, want %.3f", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestLikelyDuplicate(t *testing.T) {
+	falmouth := maps.LatLng{Lat: 50.1531, Lng: -5.0672}
+	nextDoor := maps.LatLng{Lat: 50.1536, Lng: -5.0672}
+	acrossTown := maps.LatLng{Lat: 50.1600, Lng: -5.0672}
+	lead := func(placeID, name, address string, location maps.LatLng) *Business {
+		return &Business{PlaceID: placeID, Name: name, Address: address, Location: location}
+	}
+	tests := []struct {
+		name string
+		a, b *Business
+		want bool
+	}{
+		{"same PlaceID",
+			lead("p1", "Harbour Bakery", "12 Arwenack Street, Falmouth TR11 3JA", falmouth),
+			lead("p1", "Harbour Bakery", "12 Arwenack Street, Falmouth TR11 3JA", falmouth), false},
+		{"same name and postcode",
+			lead("p1", "Harbour Bakery", "12 Arwenack Street, Falmouth TR11 3JA", falmouth),
+			lead("p2", "The Harbour Bakery", "Arwenack St, Falmouth TR11 3JA", acrossTown), true},
+		{"same name at another postcode",
+			lead("p1", "Harbour Bakery", "12 Arwenack Street, Falmouth TR11 3JA", falmouth),
+			lead("p2", "Harbour Bakery", "3 Killigrew Street, Falmouth TR11 3PN", nextDoor), false},
+		{"other name at the same postcode",
+			lead("p1", "Harbour Bakery", "12 Arwenack Street, Falmouth TR11 3JA", falmouth),
+			lead("p2", "Quay Cafe", "14 Arwenack Street, Falmouth TR11 3JA", falmouth), false},
+		{"typo in the name",
+			lead("p1", "Harbour Bakery", "Falmouth TR11 3JA", falmouth),
+			lead("p2", "Harbour Bakrey", "Falmouth TR11 3JA", falmouth), true},
+		{"no postcode, nearby",
+			lead("p1", "Harbour Bakery", "12 Arwenack Street, Falmouth", falmouth),
+			lead("p2", "Harbour Bakery", "Arwenack Street, Falmouth TR11 3JA", nextDoor), true},
+		{"no postcode, across town",
+			lead("p1", "Harbour Bakery", "12 Arwenack Street, Falmouth", falmouth),
+			lead("p2", "Harbour Bakery", "Arwenack Street, Falmouth TR11 3JA", acrossTown), false},
+		{"no postcode or location, similar address",
+			lead("p1", "Harbour Bakery", "12 Arwenack Street, Falmouth", maps.LatLng{}),
+			lead("p2", "Harbour Bakery", "12 Arwenack Street, Falmouth, Cornwall", falmouth), true},
+		{"no postcode or location, other address",
+			lead("p1", "Harbour Bakery", "12 Arwenack Street, Falmouth", maps.LatLng{}),
+			lead("p2", "Harbour Bakery", "The Quay, Flushing", falmouth), false},
+		{"no postcode, location or address",
+			lead("p1", "Harbour Bakery", "", maps.LatLng{}),
+			lead("p2", "Harbour Bakery", "12 Arwenack Street, Falmouth", falmouth), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := likelyDuplicate(tt.a, tt.b); got != tt.want {
+				t.Errorf("likelyDuplicate() = %v, want %v", got, tt.want)
+			}
+			if got := likelyDuplicate(tt.b, tt.a); got != tt.want {
+				t.Errorf("likelyDuplicate() with the leads swapped = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDuplicateKeys(t *testing.T) {
+	keys := duplicateKeys(&Business{Name: "The Harbour Bakery", Address: "12 Arwenack Street, Falmouth TR11 3JA"})
+	if len(keys) != 2 || keys[0] != "postcode:TR11 3JA" || keys[1] != "name:harbour" {
+		t.Errorf("duplicateKeys() = %q, want the postcode and longest name word", keys)
+	}
+	if keys := duplicateKeys(&Business{Name: "A1", Address: "The Quay"}); len(keys) != 0 {
+		t.Errorf("duplicateKeys() = %q, want none for a short name without a postcode", keys)
+	}
+}
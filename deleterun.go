@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"log"
+	"log/slog"
+	"time"
+
+	"github.com/jomei/notionapi"
+	"golang.org/x/time/rate"
+)
+
+// newRunID returns the ID leads are stamped with by the run started at a time: the time, to sort
+// runs by, and a random suffix telling apart runs started the same second
+func newRunID(started time.Time) string {
+	suffix := make([]byte, 2)
+	rand.Read(suffix)
+	return started.Format("20060102-150405") + "-" + hex.EncodeToString(suffix)
+}
+
+// ArchiveBusiness moves a lead's page and its contact records to the trash
+func (nc *NotionClient) ArchiveBusiness(ctx context.Context, b Business) error {
+	if err := nc.archiveContacts(ctx, b); err != nil {
+		return fmt.Errorf("archiving contacts: %w", err)
+	}
+	_, err := nc.client.Page.Update(ctx, notionapi.PageID(b.PageID), &notionapi.PageUpdateRequest{
+		Properties: notionapi.Properties{},
+		Archived:   true,
+	})
+	return err
+}
+
+// runDeleteRun moves every lead a run wrote to the Notion trash, in the main database and the
+// routed ones, to undo a run with a bad configuration. Leads stay in the trash for 30 days, so a
+// run deleted by mistake can be restored from Notion.
+func runDeleteRun(notionClient *NotionClient, args []string) {
+	fs := flag.NewFlagSet("delete-run", flag.ExitOnError)
+	runID := fs.String("run", "", "ID of the run whose leads to delete, as printed in its summary")
+	dryRun := fs.Bool("dry-run", false, "list the leads that would be deleted without deleting them")
+	fs.Parse(args)
+
+	if *runID == "" {
+		log.Fatal("delete-run: --run is required")
+	}
+	ctx := context.Background()
+	filter := notionapi.PropertyFilter{
+		Property: "RunID",
+		RichText: &notionapi.TextFilterCondition{Equals: *runID},
+	}
+	var leads []Business
+	err := notionClient.EachLead(ctx, filter, func(b Business) error {
+		leads = append(leads, b)
+		return nil
+	})
+	if err != nil {
+		log.Fatalf("Failed to list leads: %v", err)
+	}
+
+	limiter := rate.NewLimiter(notionRequestsPerSecond, 1)
+	matched, deleted, failed := len(leads), 0, 0
+	for _, b := range leads {
+		if *dryRun {
+			fmt.Printf("Would delete %s (%s), %s\n", b.Name, b.LeadNumber, b.Address)
+			continue
+		}
+		if err := limiter.Wait(ctx); err != nil {
+			log.Fatal(err)
+		}
+		if err := notionClient.pageClient(b.PageID).ArchiveBusiness(ctx, b); err != nil {
+			slog.Error("Failed to delete lead", "operation", "delete-run", "place_id", b.PlaceID, "name", b.Name, "err", err)
+			failed++
+			continue
+		}
+		deleted++
+	}
+
+	if *dryRun {
+		fmt.Printf("Would delete %d leads of run %s\n", matched, *runID)
+		return
+	}
+	fmt.Printf("Deleted %d of %d leads of run %s, %d failed\n", deleted, matched, *runID, failed)
+	if deleted > 0 {
+		fmt.Println("CSV and JSONL sinks keep their copies of the leads; --since-last-run skips the deleted places until a run without it finds them again")
+	}
+}
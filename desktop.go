@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// DesktopNotifier shows events as native desktop notifications, through osascript on macOS,
+// notify-send on Linux and PowerShell on Windows
+type DesktopNotifier struct{}
+
+func (dn DesktopNotifier) Name() string { return "desktop" }
+
+// desktopTitles title the notification of each event type
+var desktopTitles = map[string]string{
+	eventRunFinished: "Search run finished",
+	eventHotLead:     "New High urgency lead",
+	eventError:       "Business finder error",
+}
+
+func (dn DesktopNotifier) Notify(ctx context.Context, event Event) error {
+	title, body := desktopTitles[event.Type], event.Summary
+	if event.Lead != nil {
+		body = event.Lead.Name
+		if event.Lead.Address != "" {
+			body += "\n" + event.Lead.Address
+		}
+	}
+
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		script := fmt.Sprintf("display notification %s with title %s", appleScriptString(body), appleScriptString(title))
+		cmd = exec.CommandContext(ctx, "osascript", "-e", script)
+	case "windows":
+		script := `Add-Type -AssemblyName System.Windows.Forms
+$n = New-Object System.Windows.Forms.NotifyIcon
+$n.Icon = [System.Drawing.SystemIcons]::Information
+$n.Visible = $true
+$n.ShowBalloonTip(10000, $env:BF_TITLE, $env:BF_BODY, 'Info')
+Start-Sleep -Seconds 5
+$n.Dispose()`
+		cmd = exec.CommandContext(ctx, "powershell", "-NoProfile", "-Command", script)
+		// Passed through the environment so the text needs no PowerShell quoting
+		cmd.Env = append(cmd.Environ(), "BF_TITLE="+title, "BF_BODY="+body)
+	default:
+		cmd = exec.CommandContext(ctx, "notify-send", "--app-name=business-finder", title, body)
+	}
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("%s: %w %s", cmd.Path, err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// appleScriptString quotes s as an AppleScript string literal
+func appleScriptString(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"sync"
+	"time"
+
+	"googlemaps.github.io/maps"
+)
+
+const (
+	// defaultDetailsCachePath is where place details are cached unless --details-cache says otherwise
+	defaultDetailsCachePath = ".business-finder-details.json"
+	// defaultDetailsCacheDays is how long cached place details are used before being fetched again
+	defaultDetailsCacheDays = 30
+)
+
+// cachedDetails is a place's details as fetched, with the fields asked for
+type cachedDetails struct {
+	Fetched time.Time               `json:"fetched"`
+	Fields  []string                `json:"fields"`
+	Details maps.PlaceDetailsResult `json:"details"`
+}
+
+// DetailsCache keeps the place details fetched by earlier runs, keyed by PlaceID, so runs over
+// overlapping areas don't pay for the same details again until they are ttl old. A nil
+// DetailsCache caches nothing.
+type DetailsCache struct {
+	path string
+	ttl  time.Duration
+
+	mu      sync.Mutex
+	entries map[string]cachedDetails
+	changed bool
+	hits    int
+}
+
+// LoadDetailsCache reads the cache file at path, dropping details older than ttl; a missing file
+// is an empty cache
+func LoadDetailsCache(path string, ttl time.Duration) (*DetailsCache, error) {
+	dc := &DetailsCache{path: path, ttl: ttl, entries: make(map[string]cachedDetails)}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return dc, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &dc.entries); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	for placeID, entry := range dc.entries {
+		if time.Since(entry.Fetched) > ttl {
+			delete(dc.entries, placeID)
+			dc.changed = true
+		}
+	}
+	return dc, nil
+}
+
+// Get returns a place's cached details, if they have every field asked for and aren't expired.
+// Requests for every field, which name none, are never cached.
+func (dc *DetailsCache) Get(placeID string, fields []maps.PlaceDetailsFieldMask) (maps.PlaceDetailsResult, bool) {
+	if len(fields) == 0 {
+		return maps.PlaceDetailsResult{}, false
+	}
+	dc.mu.Lock()
+	defer dc.mu.Unlock()
+	entry, ok := dc.entries[placeID]
+	if !ok || time.Since(entry.Fetched) > dc.ttl {
+		return maps.PlaceDetailsResult{}, false
+	}
+	for _, field := range fields {
+		if !slices.Contains(entry.Fields, string(field)) {
+			return maps.PlaceDetailsResult{}, false
+		}
+	}
+	dc.hits++
+	return entry.Details, true
+}
+
+// Put caches the details fetched for a place with the fields asked for
+func (dc *DetailsCache) Put(placeID string, fields []maps.PlaceDetailsFieldMask, details maps.PlaceDetailsResult) {
+	if len(fields) == 0 {
+		return
+	}
+	names := make([]string, len(fields))
+	for i, field := range fields {
+		names[i] = string(field)
+	}
+	dc.mu.Lock()
+	defer dc.mu.Unlock()
+	dc.entries[placeID] = cachedDetails{Fetched: time.Now(), Fields: names, Details: details}
+	dc.changed = true
+}
+
+// Summary reports how many details lookups the cache saved this run, or "" if none
+func (dc *DetailsCache) Summary() string {
+	if dc == nil {
+		return ""
+	}
+	dc.mu.Lock()
+	defer dc.mu.Unlock()
+	if dc.hits == 0 {
+		return ""
+	}
+	return fmt.Sprintf("Place details cache: %d lookups saved\n", dc.hits)
+}
+
+// Reset zeroes the count of lookups saved, for the next run
+func (dc *DetailsCache) Reset() {
+	if dc == nil {
+		return
+	}
+	dc.mu.Lock()
+	defer dc.mu.Unlock()
+	dc.hits = 0
+}
+
+// Save writes the cache if details were added or expired since it was loaded
+func (dc *DetailsCache) Save() error {
+	if dc == nil {
+		return nil
+	}
+	dc.mu.Lock()
+	defer dc.mu.Unlock()
+	if !dc.changed {
+		return nil
+	}
+	data, err := json.Marshal(dc.entries)
+	if err != nil {
+		return err
+	}
+	err = replaceFile(dc.path, func(f *os.File) error {
+		_, err := f.Write(data)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	dc.changed = false
+	return nil
+}
+
+// cachedPlaces answers details requests from the cache when it can. It wraps the metered
+// provider, so cached details aren't charged to the budget.
+type cachedPlaces struct {
+	PlacesProvider
+	cache *DetailsCache
+}
+
+func (cp cachedPlaces) PlaceDetails(ctx context.Context, r *maps.PlaceDetailsRequest) (maps.PlaceDetailsResult, error) {
+	if details, ok := cp.cache.Get(r.PlaceID, r.Fields); ok {
+		return details, nil
+	}
+	details, err := cp.PlacesProvider.PlaceDetails(ctx, r)
+	if err == nil {
+		cp.cache.Put(r.PlaceID, r.Fields, details)
+	}
+	return details, err
+}
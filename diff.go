@@ -0,0 +1,85 @@
+package main
+
+import (
+	"encoding/json"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+)
+
+// diffLog records the changes made to existing leads; it is nil, recording nothing, unless
+// --diff-file is given
+var diffLog *DiffLog
+
+// fieldChange is one field of an existing lead changed, as written to the diff file
+type fieldChange struct {
+	Time    time.Time `json:"time"`
+	PlaceID string    `json:"place_id"`
+	Lead    string    `json:"lead,omitempty"`
+	Name    string    `json:"name"`
+	Field   string    `json:"field"`
+	Old     string    `json:"old"`
+	New     string    `json:"new"`
+	Reason  string    `json:"reason"`
+}
+
+// DiffLog appends a JSON line per changed field of an existing lead, saying what it was, what
+// it became and why, for auditing and for comparing runs
+type DiffLog struct {
+	mu  sync.Mutex
+	f   *os.File
+	enc *json.Encoder
+}
+
+// OpenDiffLog opens a diff file for appending, so repeated runs add to it
+func OpenDiffLog(path string) (*DiffLog, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	return &DiffLog{f: f, enc: json.NewEncoder(f)}, nil
+}
+
+// Record logs the fields that differ between a lead before and after a change
+func (dl *DiffLog) Record(before, after Business, fields []string, reason string) {
+	if dl == nil {
+		return
+	}
+	old, changed := templateData(before), templateData(after)
+	for _, field := range fields {
+		if old[field] != changed[field] {
+			dl.Add(after, field, old[field], changed[field], reason)
+		}
+	}
+}
+
+// Add logs one changed field of a lead
+func (dl *DiffLog) Add(b Business, field, old, new, reason string) {
+	if dl == nil {
+		return
+	}
+	dl.mu.Lock()
+	defer dl.mu.Unlock()
+	err := dl.enc.Encode(fieldChange{
+		Time:    time.Now().UTC(),
+		PlaceID: b.PlaceID,
+		Lead:    b.LeadNumber,
+		Name:    b.Name,
+		Field:   field,
+		Old:     old,
+		New:     new,
+		Reason:  reason,
+	})
+	if err != nil {
+		slog.Error("Failed to write diff", "operation", "diff", "place_id", b.PlaceID, "field", field, "err", err)
+	}
+}
+
+// Close closes the diff file
+func (dl *DiffLog) Close() error {
+	if dl == nil {
+		return nil
+	}
+	return dl.f.Close()
+}
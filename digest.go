@@ -0,0 +1,94 @@
+package main
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/csv"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// RunDigest is what a search run wrote and what went wrong, for the run_finished event
+type RunDigest struct {
+	// Leads are the new leads written this run
+	Leads []*Business
+	// Failures describe searches and writes that failed
+	Failures []string
+}
+
+// leadType is the type a lead is counted under: the type searched, or its first place type for
+// leads found by keyword
+func leadType(b *Business) string {
+	if b.SearchedType != "" {
+		return b.SearchedType
+	}
+	if len(b.Type) > 0 {
+		return b.Type[0]
+	}
+	return "Other"
+}
+
+// String lists the new leads by type and urgency, the broken websites found and the failures
+func (rd *RunDigest) String() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "New leads: %d\n", len(rd.Leads))
+	counts := make(map[string]map[string]int)
+	var broken []*Business
+	for _, b := range rd.Leads {
+		t := leadType(b)
+		if counts[t] == nil {
+			counts[t] = make(map[string]int)
+		}
+		counts[t][cmp.Or(b.Urgency, "Low")]++
+		if b.WebsiteStatus == "Broken Website" {
+			broken = append(broken, b)
+		}
+	}
+	types := make([]string, 0, len(counts))
+	for t := range counts {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	for _, t := range types {
+		var parts []string
+		for _, urgency := range []string{"High", "Medium", "Low"} {
+			if n := counts[t][urgency]; n > 0 {
+				parts = append(parts, fmt.Sprintf("%d %s", n, urgency))
+			}
+		}
+		fmt.Fprintf(&sb, "  %s: %s\n", t, strings.Join(parts, ", "))
+	}
+
+	if len(broken) > 0 {
+		fmt.Fprintf(&sb, "\nBroken websites: %d\n", len(broken))
+		for _, b := range broken {
+			fmt.Fprintf(&sb, "  %s (%s): %s\n", b.Name, b.LeadNumber, b.URL)
+		}
+	}
+	if len(rd.Failures) > 0 {
+		fmt.Fprintf(&sb, "\nFailures: %d\n", len(rd.Failures))
+		for _, failure := range rd.Failures {
+			fmt.Fprintf(&sb, "  %s\n", failure)
+		}
+	}
+	return sb.String()
+}
+
+// CSV renders the new leads with every merge variable, as the CSV sink writes them
+func (rd *RunDigest) CSV() ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	fields := FieldSelector{}.fields()
+	w.Write(fields)
+	for _, b := range rd.Leads {
+		data := templateData(*b)
+		row := make([]string, len(fields))
+		for i, field := range fields {
+			row[i] = data[field]
+		}
+		w.Write(row)
+	}
+	w.Flush()
+	return buf.Bytes(), w.Error()
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// discordEscaper escapes the characters Discord's markdown gives a meaning to
+var discordEscaper = strings.NewReplacer(`\`, `\\`, "*", `\*`, "_", `\_`, "~", `\~`, "`", "\\`", "|", `\|`, "[", `\[`, "]", `\]`)
+
+// DiscordNotifier posts events to a Discord channel through a webhook
+type DiscordNotifier struct {
+	url    string
+	client *http.Client
+}
+
+// NewDiscordNotifier initializes a new DiscordNotifier
+func NewDiscordNotifier(url string) *DiscordNotifier {
+	return &DiscordNotifier{url: url, client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+func (dn *DiscordNotifier) Name() string { return "discord" }
+
+// Notify posts the event summary, or for hot_lead events the lead's name linked to its Notion
+// page, its address and why it is urgent
+func (dn *DiscordNotifier) Notify(ctx context.Context, event Event) error {
+	content := discordEscaper.Replace(event.Summary)
+	if event.Lead != nil {
+		content = discordLead(*event.Lead)
+	}
+	// Mentions in business names mustn't ping the channel
+	return postJSON(ctx, dn.client, dn.url, map[string]any{
+		"content":          content,
+		"allowed_mentions": map[string][]string{"parse": {}},
+	})
+}
+
+// discordLead formats a hot lead as a Discord message
+func discordLead(b Business) string {
+	name := "**" + discordEscaper.Replace(b.Name) + "**"
+	if b.PageURL != "" {
+		name = fmt.Sprintf("[%s](<%s>)", discordEscaper.Replace(b.Name), b.PageURL)
+	}
+	lines := []string{":fire: New High urgency lead: " + name}
+	if b.Address != "" {
+		lines = append(lines, discordEscaper.Replace(b.Address))
+	}
+	reason := explainScore(b.ScoreBreakdown)
+	if reason == "" {
+		reason = b.WebsiteStatus
+	}
+	lines = append(lines, "Why: "+discordEscaper.Replace(reason))
+	return strings.Join(lines, "\n")
+}
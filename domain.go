@@ -0,0 +1,245 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// rdapBaseURL resolves any TLD to its registry's RDAP server via redirect
+const rdapBaseURL = "https://rdap.org/domain/"
+
+// suggestedTLDs are the extensions checked for domain suggestions, in order of preference
+var suggestedTLDs = []string{".co.uk", ".com", ".uk"}
+
+// domainStopWords are dropped from business names when generating domain candidates
+var domainStopWords = map[string]bool{
+	"the":     true,
+	"ltd":     true,
+	"limited": true,
+	"llp":     true,
+	"plc":     true,
+	"inc":     true,
+	"llc":     true,
+	"co":      true,
+}
+
+// DomainChecker checks domain registration status over RDAP
+type DomainChecker struct {
+	client *http.Client
+}
+
+// NewDomainChecker initializes a new DomainChecker
+func NewDomainChecker() *DomainChecker {
+	return &DomainChecker{
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// rdapDomain is the part of an RDAP domain record used for domain age
+type rdapDomain struct {
+	Events []struct {
+		Action string    `json:"eventAction"`
+		Date   time.Time `json:"eventDate"`
+	} `json:"events"`
+	Entities []struct {
+		Roles []string `json:"roles"`
+		// VCard is a jCard: ["vcard", [[name, params, type, value], ...]]
+		VCard []json.RawMessage `json:"vcardArray"`
+	} `json:"entities"`
+}
+
+// lookup fetches the RDAP record of a domain into v, when v isn't nil, reporting whether the
+// domain is registered. RDAP servers answer 404 for unknown domains.
+func (dc *DomainChecker) lookup(ctx context.Context, domain string, v any) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rdapBaseURL+domain, nil)
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Accept", "application/rdap+json")
+
+	resp, err := dc.client.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		if v == nil {
+			return true, nil
+		}
+		if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+			return true, fmt.Errorf("rdap lookup for %s: %w", domain, err)
+		}
+		return true, nil
+	case http.StatusNotFound:
+		return false, nil
+	default:
+		return false, fmt.Errorf("rdap lookup for %s: status %d", domain, resp.StatusCode)
+	}
+}
+
+// Available reports whether a domain is unregistered
+func (dc *DomainChecker) Available(ctx context.Context, domain string) (bool, error) {
+	registered, err := dc.lookup(ctx, domain, nil)
+	return !registered && err == nil, err
+}
+
+// Registration looks up when the domain of a website was registered and with which registrar.
+// Both are empty when the registry doesn't publish them.
+func (dc *DomainChecker) Registration(ctx context.Context, website string) (time.Time, string, error) {
+	domain := registeredDomain(website)
+	if domain == "" {
+		return time.Time{}, "", fmt.Errorf("no domain in %q", website)
+	}
+	var record rdapDomain
+	registered, err := dc.lookup(ctx, domain, &record)
+	if err != nil || !registered {
+		return time.Time{}, "", err
+	}
+
+	var created time.Time
+	for _, event := range record.Events {
+		if event.Action == "registration" {
+			created = event.Date
+		}
+	}
+	registrar := ""
+	for _, entity := range record.Entities {
+		if slices.Contains(entity.Roles, "registrar") && len(entity.VCard) == 2 {
+			registrar = vcardName(entity.VCard[1])
+		}
+	}
+	return created, registrar, nil
+}
+
+// vcardName returns the formatted name in the properties of a jCard
+func vcardName(properties json.RawMessage) string {
+	var props [][]any
+	if json.Unmarshal(properties, &props) != nil {
+		return ""
+	}
+	for _, prop := range props {
+		if len(prop) == 4 && prop[0] == "fn" {
+			name, _ := prop[3].(string)
+			return name
+		}
+	}
+	return ""
+}
+
+// ukSecondLevel are the UK second-level domains registrations sit under, so shop.joes.co.uk is
+// registered as joes.co.uk rather than co.uk
+var ukSecondLevel = map[string]bool{"co": true, "org": true, "me": true, "ltd": true, "plc": true, "net": true, "sch": true, "ac": true, "gov": true}
+
+// registeredDomain is the domain a website's host is registered under, e.g. joes.co.uk for
+// https://www.joes.co.uk/menu, or "" for URLs without a host
+func registeredDomain(website string) string {
+	u, err := url.Parse(website)
+	if err != nil || u.Hostname() == "" {
+		return ""
+	}
+	labels := strings.Split(strings.ToLower(u.Hostname()), ".")
+	keep := 2
+	if n := len(labels); n >= 3 && labels[n-1] == "uk" && ukSecondLevel[labels[n-2]] {
+		keep = 3
+	}
+	if len(labels) < keep {
+		return ""
+	}
+	return strings.Join(labels[len(labels)-keep:], ".")
+}
+
+// domainAge renders how long ago a domain was registered, e.g. "12 years", or "" when unknown
+func domainAge(registered, now time.Time) string {
+	if registered.IsZero() {
+		return ""
+	}
+	years := now.Year() - registered.Year()
+	if now.YearDay() < registered.YearDay() {
+		years--
+	}
+	count, unit := years, "year"
+	if years < 1 {
+		count, unit = int(now.Sub(registered).Hours()/24/30), "month"
+	}
+	if count != 1 {
+		unit += "s"
+	}
+	return fmt.Sprintf("%d %s", count, unit)
+}
+
+// SuggestDomain returns the first available domain generated from the business name, or "" if
+// none are free. When the name alone is taken everywhere, the town is added, e.g.
+// joesbakeryfalmouth.co.uk, which still reads as the business's own.
+func (dc *DomainChecker) SuggestDomain(ctx context.Context, businessName, town string) (string, error) {
+	var lastErr error
+	candidates := domainCandidates(businessName)
+	// Geocoded areas are named like "Falmouth, UK"
+	town, _, _ = strings.Cut(town, ",")
+	if townWords := domainWords(town); len(townWords) > 0 && !strings.Contains(strings.Join(domainWords(businessName), ""), strings.Join(townWords, "")) {
+		candidates = append(candidates, domainCandidates(businessName+" "+town)...)
+	}
+	for _, candidate := range candidates {
+		available, err := dc.Available(ctx, candidate)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if available {
+			return candidate, nil
+		}
+	}
+	return "", lastErr
+}
+
+// domainCandidates builds plausible domains from a business name, e.g. "Joe's Bakery Ltd" -> joesbakery.co.uk
+func domainCandidates(businessName string) []string {
+	words := domainWords(businessName)
+	if len(words) == 0 {
+		return nil
+	}
+
+	labels := []string{strings.Join(words, "")}
+	if len(words) > 1 {
+		labels = append(labels, strings.Join(words, "-"))
+	}
+
+	var candidates []string
+	for _, tld := range suggestedTLDs {
+		for _, label := range labels {
+			if len(label) <= 63 {
+				candidates = append(candidates, label+tld)
+			}
+		}
+	}
+	return candidates
+}
+
+// domainWords lowercases a name and splits it into ASCII alphanumeric words, dropping legal suffixes
+func domainWords(name string) []string {
+	name = strings.ToLower(strings.ReplaceAll(name, "&", " and "))
+	name = strings.Map(func(r rune) rune {
+		if r == '\'' || r == '’' || r > unicode.MaxASCII {
+			return -1
+		}
+		return r
+	}, name)
+
+	var words []string
+	for _, word := range strings.FieldsFunc(name, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		if !domainStopWords[word] {
+			words = append(words, word)
+		}
+	}
+	return words
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultSMTPPort is the submission port, which expects STARTTLS
+const defaultSMTPPort = 587
+
+// SMTPConfig is the mail server emails are sent through. The password is read from
+// SMTP_PASSWORD rather than the config file.
+type SMTPConfig struct {
+	Host string `json:"host"`
+	// Port defaults to 587
+	Port     int    `json:"port,omitempty"`
+	Username string `json:"username,omitempty"`
+	From     string `json:"from"`
+}
+
+// Validate checks that the server and sender are set
+func (sc *SMTPConfig) Validate() error {
+	if sc.Host == "" {
+		return fmt.Errorf("smtp: host is required")
+	}
+	if _, err := mail.ParseAddress(sc.From); err != nil {
+		return fmt.Errorf("smtp: from: %w", err)
+	}
+	return nil
+}
+
+// send delivers a message to the recipients, authenticating when a username is set
+func (sc *SMTPConfig) send(to []string, message []byte) error {
+	addr := net.JoinHostPort(sc.Host, strconv.Itoa(cmp.Or(sc.Port, defaultSMTPPort)))
+	var auth smtp.Auth
+	if sc.Username != "" {
+		auth = smtp.PlainAuth("", sc.Username, os.Getenv("SMTP_PASSWORD"), sc.Host)
+	}
+	from, err := mail.ParseAddress(sc.From)
+	if err != nil {
+		return fmt.Errorf("smtp: from: %w", err)
+	}
+	// smtp.SendMail has no timeout, so the whole exchange runs under a deadline on the connection
+	conn, err := net.DialTimeout("tcp", addr, requestTimeout)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if err := conn.SetDeadline(time.Now().Add(requestTimeout)); err != nil {
+		return err
+	}
+	client, err := smtp.NewClient(conn, sc.Host)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: sc.Host}); err != nil {
+			return err
+		}
+	}
+	if auth != nil {
+		if ok, _ := client.Extension("AUTH"); !ok {
+			return errors.New("smtp: server doesn't support AUTH")
+		}
+		if err := client.Auth(auth); err != nil {
+			return err
+		}
+	}
+	if err := client.Mail(from.Address); err != nil {
+		return err
+	}
+	for _, addr := range to {
+		if err := client.Rcpt(addr); err != nil {
+			return err
+		}
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(message); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+// EmailNotifier emails events, sending finished runs as a digest of the new leads, broken
+// websites and failures with the new leads attached as CSV
+type EmailNotifier struct {
+	smtp *SMTPConfig
+	to   []string
+}
+
+// NewEmailNotifier initializes a new EmailNotifier
+func NewEmailNotifier(smtp *SMTPConfig, to []string) *EmailNotifier {
+	return &EmailNotifier{smtp: smtp, to: to}
+}
+
+func (en *EmailNotifier) Name() string { return "email" }
+
+func (en *EmailNotifier) Notify(ctx context.Context, event Event) error {
+	subject, body := desktopTitles[event.Type], event.Summary
+	var attachment []byte
+	if event.Lead != nil {
+		subject = fmt.Sprintf("%s: %s", subject, event.Lead.Name)
+		if event.Lead.PageURL != "" {
+			body += "\n" + event.Lead.PageURL
+		}
+	}
+	if event.Digest != nil {
+		subject = fmt.Sprintf("%s: %d new leads", subject, len(event.Digest.Leads))
+		body += "\n\n" + event.Digest.String()
+		if len(event.Digest.Leads) > 0 {
+			var err error
+			if attachment, err = event.Digest.CSV(); err != nil {
+				return err
+			}
+		}
+	}
+	message, err := buildMessage(en.smtp.From, en.to, subject, body, attachment)
+	if err != nil {
+		return err
+	}
+	return en.smtp.send(en.to, message)
+}
+
+// buildMessage builds a plain text email, with the CSV of new leads attached when there is one
+func buildMessage(from string, to []string, subject, body string, attachment []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", from)
+	fmt.Fprintf(&buf, "To: %s\r\n", strings.Join(to, ", "))
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	buf.WriteString("MIME-Version: 1.0\r\n")
+
+	mw := multipart.NewWriter(&buf)
+	fmt.Fprintf(&buf, "Content-Type: multipart/mixed; boundary=%q\r\n\r\n", mw.Boundary())
+	part, err := mw.CreatePart(textproto.MIMEHeader{"Content-Type": {"text/plain; charset=utf-8"}})
+	if err != nil {
+		return nil, err
+	}
+	part.Write([]byte(strings.ReplaceAll(body, "\n", "\r\n")))
+	if attachment != nil {
+		part, err := mw.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {"text/csv; charset=utf-8"},
+			"Content-Transfer-Encoding": {"base64"},
+			"Content-Disposition":       {fmt.Sprintf("attachment; filename=%q", "new-leads-"+time.Now().Format(time.DateOnly)+".csv")},
+		})
+		if err != nil {
+			return nil, err
+		}
+		encoded := base64.StdEncoding.EncodeToString(attachment)
+		for len(encoded) > 76 {
+			part.Write([]byte(encoded[:76] + "\r\n"))
+			encoded = encoded[76:]
+		}
+		part.Write([]byte(encoded))
+	}
+	if err := mw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
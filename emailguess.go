@@ -0,0 +1,141 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"slices"
+	"time"
+)
+
+// guessedMailboxes are the mailboxes small businesses commonly take email at, most likely first
+var guessedMailboxes = []string{"info", "hello", "enquiries", "contact", "office"}
+
+const (
+	// scrapedEmailConfidence is the confidence of emails found on the business's own site
+	scrapedEmailConfidence = 100
+	// verifiedGuessConfidence is the confidence of a guess the mail server accepts, while
+	// rejecting a made-up mailbox
+	verifiedGuessConfidence = 80
+	// catchAllGuessConfidence is the confidence of a guess at a server that accepts any mailbox
+	catchAllGuessConfidence = 40
+	// unverifiedGuessConfidence is the confidence of a guess at a domain that takes mail, when its
+	// server can't be asked about mailboxes
+	unverifiedGuessConfidence = 25
+)
+
+// EmailGuesser guesses the email of businesses whose site lists none, from the mail servers of
+// their website's domain. Guesses are checked by asking the server whether it would accept the
+// mailbox, then hanging up before sending anything.
+type EmailGuesser struct {
+	resolver *net.Resolver
+	// helo is the name the guesser introduces itself with to mail servers
+	helo string
+	// from is the envelope sender of probes; empty, like a bounce, by default
+	from    string
+	timeout time.Duration
+}
+
+// NewEmailGuesser initializes a new EmailGuesser
+func NewEmailGuesser() *EmailGuesser {
+	return &EmailGuesser{resolver: net.DefaultResolver, helo: "localhost", timeout: 10 * time.Second}
+}
+
+// Guess returns the likeliest email for a website's domain, with its confidence from 0 to 100.
+// It returns "" when the domain takes no email or rejects every guess.
+func (eg *EmailGuesser) Guess(ctx context.Context, website string) (string, int, error) {
+	if eg == nil {
+		return "", 0, nil
+	}
+	domain := registeredDomain(website)
+	if domain == "" {
+		return "", 0, fmt.Errorf("no domain in %q", website)
+	}
+	records, err := eg.resolver.LookupMX(ctx, domain)
+	if err != nil {
+		var dnsErr *net.DNSError
+		if errors.As(err, &dnsErr) && dnsErr.IsNotFound {
+			return "", 0, nil
+		}
+		return "", 0, err
+	}
+	// A null MX, a single "." record, declares the domain takes no mail
+	records = slices.DeleteFunc(records, func(mx *net.MX) bool { return mx.Host == "." })
+	if len(records) == 0 {
+		return "", 0, nil
+	}
+
+	guess := guessedMailboxes[0] + "@" + domain
+	// LookupMX sorts by preference; the first server that answers decides
+	for _, mx := range records {
+		email, confidence, err := eg.probe(ctx, mx.Host, domain)
+		if err == nil {
+			return email, confidence, nil
+		}
+	}
+	// Outgoing port 25 is blocked on many networks; the domain taking mail is all that is known
+	return guess, unverifiedGuessConfidence, nil
+}
+
+// probe asks a mail server which of the guessed mailboxes it accepts. A server that also accepts
+// a made-up mailbox accepts everything, so its answer says little.
+func (eg *EmailGuesser) probe(ctx context.Context, host, domain string) (string, int, error) {
+	dialer := net.Dialer{Timeout: eg.timeout}
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(host, "25"))
+	if err != nil {
+		return "", 0, err
+	}
+	conn.SetDeadline(time.Now().Add(eg.timeout))
+	client, err := smtp.NewClient(conn, host)
+	if err != nil {
+		conn.Close()
+		return "", 0, err
+	}
+	defer client.Close()
+	if err := client.Hello(eg.helo); err != nil {
+		return "", 0, err
+	}
+	// Each accepted recipient is only checked, never sent to; Quit ends the session before DATA
+	defer client.Quit()
+
+	accepts := func(email string) (bool, error) {
+		if err := client.Reset(); err != nil {
+			return false, err
+		}
+		if err := client.Mail(eg.from); err != nil {
+			return false, err
+		}
+		err := client.Rcpt(email)
+		var protoErr *textproto.Error
+		if errors.As(err, &protoErr) && protoErr.Code >= 500 {
+			return false, nil
+		}
+		return err == nil, err
+	}
+
+	suffix := make([]byte, 8)
+	rand.Read(suffix)
+	catchAll, err := accepts("no-such-mailbox-" + hex.EncodeToString(suffix) + "@" + domain)
+	if err != nil {
+		return "", 0, err
+	}
+	if catchAll {
+		return guessedMailboxes[0] + "@" + domain, catchAllGuessConfidence, nil
+	}
+	for _, mailbox := range guessedMailboxes {
+		email := mailbox + "@" + domain
+		ok, err := accepts(email)
+		if err != nil {
+			return "", 0, err
+		}
+		if ok {
+			return email, verifiedGuessConfidence, nil
+		}
+	}
+	return "", 0, nil
+}
@@ -0,0 +1,239 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Enricher fills in something that can be learnt about a lead beyond its listing. Each new lead
+// goes through the configured enrichers in order, so each sees what the ones before it filled in.
+type Enricher interface {
+	Name() string
+	// Enrich fills in the lead's fields; a failure is reported as a data quality issue and doesn't
+	// stop the enrichers after it
+	Enrich(ctx context.Context, b *Business) error
+}
+
+// severeEnricher is implemented by enrichers whose failures leave a lead misleading rather than
+// just incomplete; they are reported as medium severity issues
+type severeEnricher interface {
+	severe()
+}
+
+// enricherFactories build the built-in enrichers from what a Finder is configured with; a nil
+// enricher is one the run has turned off
+var enricherFactories = map[string]func(f *Finder) Enricher{
+	"suggest-domain": func(f *Finder) Enricher {
+		if f.domainChecker == nil {
+			return nil
+		}
+		return &domainSuggester{checker: f.domainChecker}
+	},
+	"crawl": func(f *Finder) Enricher {
+		if f.crawler == nil {
+			return nil
+		}
+		return &siteCrawler{crawler: f.crawler, policy: f.policy}
+	},
+	"domain-age": func(f *Finder) Enricher {
+		if f.domainChecker == nil {
+			return nil
+		}
+		return &domainAger{checker: f.domainChecker}
+	},
+	"guess-email": func(f *Finder) Enricher { return &emailGuesser{guesser: f.emails, policy: f.policy} },
+	"companies-house": func(f *Finder) Enricher {
+		if f.companies == nil {
+			return nil
+		}
+		return f.companies
+	},
+	"score": func(f *Finder) Enricher {
+		return &leadScorer{history: f.history, rules: f.urgencyRules, weights: f.weights}
+	},
+}
+
+// defaultEnrichers are run, in this order, when the config lists none. Scoring goes last, as
+// urgency rules and weights look at everything the others fill in.
+var defaultEnrichers = []string{"suggest-domain", "crawl", "domain-age", "guess-email", "companies-house", "score"}
+
+// enricherNames returns the enrichers run when the config lists none: the defaults, with the
+// hooks just before scoring so the score counts what they fill in
+func enricherNames(hooks []HookConfig) []string {
+	names := slices.Clone(defaultEnrichers)
+	for _, hook := range hooks {
+		names = slices.Insert(names, len(names)-1, hook.Name)
+	}
+	return names
+}
+
+// validateEnrichers checks that every name is a built-in enricher or a hook, listed once, and
+// that a list leaves out no hook
+func validateEnrichers(names []string, hooks []HookConfig) error {
+	for i, name := range names {
+		_, builtIn := enricherFactories[name]
+		if !builtIn && !slices.ContainsFunc(hooks, func(hook HookConfig) bool { return hook.Name == name }) {
+			return fmt.Errorf("unknown enricher %q, expected a hook or one of %s", name, strings.Join(defaultEnrichers, ", "))
+		}
+		if slices.Contains(names[:i], name) {
+			return fmt.Errorf("enricher %q is listed twice", name)
+		}
+	}
+	for _, hook := range hooks {
+		if len(names) > 0 && !slices.Contains(names, hook.Name) {
+			return fmt.Errorf("hook %q is missing; list it where it should run", hook.Name)
+		}
+	}
+	return nil
+}
+
+// newEnrichers builds the named enrichers and hooks, or the default ones when names is empty,
+// leaving out those the Finder has turned off
+func (f *Finder) newEnrichers(names []string) []Enricher {
+	if len(names) == 0 {
+		names = enricherNames(f.hooks)
+	}
+	var enrichers []Enricher
+	for _, name := range names {
+		if factory, ok := enricherFactories[name]; ok {
+			if enricher := factory(f); enricher != nil {
+				enrichers = append(enrichers, enricher)
+			}
+			continue
+		}
+		if i := slices.IndexFunc(f.hooks, func(hook HookConfig) bool { return hook.Name == name }); i >= 0 {
+			enrichers = append(enrichers, NewHook(f.hooks[i]))
+		}
+	}
+	return enrichers
+}
+
+// runEnrichers runs the lead through every enricher in turn, recording the failures in issues
+func runEnrichers(ctx context.Context, enrichers []Enricher, b *Business, issues *QualityReport) {
+	for _, enricher := range enrichers {
+		err := enricher.Enrich(ctx, b)
+		if err == nil {
+			continue
+		}
+		slog.Warn("Failed to enrich lead", "operation", enricher.Name(), "place_id", b.PlaceID, "name", b.Name, "url", b.URL, "err", err)
+		severity := severityLow
+		if _, ok := enricher.(severeEnricher); ok {
+			severity = severityMedium
+		}
+		issues.add(severity, "failed enrichment", b, "%s: %v", enricher.Name(), err)
+	}
+}
+
+// hasSite reports whether a lead lists a website, working or not
+func hasSite(b *Business) bool {
+	return b.WebsiteStatus == "Has Website" || b.WebsiteStatus == "Broken Website"
+}
+
+// domainSuggester suggests an available domain to leads without a website
+type domainSuggester struct {
+	checker *DomainChecker
+}
+
+func (ds *domainSuggester) Name() string { return "suggest-domain" }
+
+func (ds *domainSuggester) Enrich(ctx context.Context, b *Business) error {
+	if b.WebsiteStatus != "No Website" {
+		return nil
+	}
+	domain, err := ds.checker.SuggestDomain(ctx, b.Name, b.SearchArea)
+	b.SuggestedDomain = domain
+	return err
+}
+
+// siteCrawler fetches a lead's website for its email, social links and SSL, marking the website
+// broken when it can't be fetched
+type siteCrawler struct {
+	crawler *WebsiteCrawler
+	policy  Policy
+}
+
+func (sc *siteCrawler) Name() string { return "crawl" }
+
+func (sc *siteCrawler) severe() {}
+
+func (sc *siteCrawler) Enrich(ctx context.Context, b *Business) error {
+	if b.WebsiteStatus != "Has Website" {
+		return nil
+	}
+	if !sc.policy.AllowsSite(b.URL) {
+		// Without fetching the site, its scheme is the best guess at whether it has SSL
+		b.HTTPS = strings.HasPrefix(b.URL, "https://")
+		return nil
+	}
+	site, err := sc.crawler.Crawl(ctx, b.URL)
+	if err != nil {
+		b.WebsiteStatus = "Broken Website"
+		return err
+	}
+	b.Email = site.Email
+	if site.Email != "" {
+		b.EmailConfidence = scrapedEmailConfidence
+	}
+	b.HTTPS = site.HTTPS
+	b.SetSocials(site.Socials)
+	return nil
+}
+
+// domainAger looks up when a lead's website domain was registered, and with whom
+type domainAger struct {
+	checker *DomainChecker
+}
+
+func (da *domainAger) Name() string { return "domain-age" }
+
+func (da *domainAger) Enrich(ctx context.Context, b *Business) error {
+	if !hasSite(b) {
+		return nil
+	}
+	registered, registrar, err := da.checker.Registration(ctx, b.URL)
+	b.DomainRegistered, b.Registrar = registered, registrar
+	return err
+}
+
+// emailGuesser guesses the email of leads whose website lists none
+type emailGuesser struct {
+	guesser *EmailGuesser
+	policy  Policy
+}
+
+func (eg *emailGuesser) Name() string { return "guess-email" }
+
+func (eg *emailGuesser) Enrich(ctx context.Context, b *Business) error {
+	if !hasSite(b) || b.Email != "" {
+		return nil
+	}
+	// Probing the domain's mail servers reaches it as much as crawling its site does
+	if !eg.policy.AllowsSite(b.URL) {
+		return nil
+	}
+	email, confidence, err := eg.guesser.Guess(ctx, b.URL)
+	b.Email, b.EmailConfidence = email, confidence
+	return err
+}
+
+// leadScorer records the lead in the history, then sets its urgency and lead score
+type leadScorer struct {
+	history *History
+	rules   []UrgencyRule
+	weights ScoreWeights
+}
+
+func (ls *leadScorer) Name() string { return "score" }
+
+func (ls *leadScorer) Enrich(ctx context.Context, b *Business) error {
+	now := time.Now()
+	ls.history.Record(b, now)
+	ls.history.Apply(b, now)
+	b.Urgency = EvaluateUrgency(ls.rules, b)
+	b.LeadScore, b.ScoreBreakdown = ScoreLead(ls.weights, b)
+	return nil
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jomei/notionapi"
+)
+
+// runExport writes leads from the database to a file in another format, named by the first
+// argument: vcf for contacts to import into a phone, kml, geojson or html for maps
+func runExport(notionClient *NotionClient, args []string) {
+	if len(args) == 0 {
+		log.Fatal("export: a format is required: vcf, kml, geojson or html")
+	}
+	format, args := args[0], args[1:]
+	switch format {
+	case "vcf":
+		runExportVCF(notionClient, args)
+	case "kml", "geojson", "html":
+		runExportMap(notionClient, format, args)
+	default:
+		log.Fatalf("export: unknown format %q", format)
+	}
+}
+
+// exportLeads lists the leads to export: those with the Contacted status, or all but the ones not
+// to contact when it is empty, optionally in one search area
+func exportLeads(ctx context.Context, notionClient *NotionClient, contacted, area string) ([]Business, error) {
+	var filter notionapi.AndCompoundFilter
+	if contacted != "" {
+		filter = append(filter, notionapi.PropertyFilter{
+			Property: "Contacted",
+			Select:   &notionapi.SelectFilterCondition{Equals: contacted},
+		})
+	} else {
+		filter = append(filter, notionapi.PropertyFilter{
+			Property: "Contacted",
+			Select:   &notionapi.SelectFilterCondition{DoesNotEqual: doNotContact},
+		})
+	}
+	if area != "" {
+		filter = append(filter, notionapi.PropertyFilter{
+			Property: "SearchArea",
+			Select:   &notionapi.SelectFilterCondition{Equals: area},
+		})
+	}
+	return notionClient.ListBusinesses(ctx, filter)
+}
+
+// runExportVCF writes the leads with a phone number or email to a vCard file, nearest the search
+// area's centre first, for a day of visiting them in person
+func runExportVCF(notionClient *NotionClient, args []string) {
+	fs := flag.NewFlagSet("export vcf", flag.ExitOnError)
+	out := fs.String("out", "", "vCard file to write (default leads-YYYY-MM-DD.vcf)")
+	area := fs.String("area", "", "only export leads found searching this area")
+	contacted := fs.String("contacted", "Not Contacted", `only export leads with this Contacted status; "" for every lead not marked Do Not Contact`)
+	fs.Parse(args)
+
+	if *out == "" {
+		*out = fmt.Sprintf("leads-%s.vcf", time.Now().Format("2006-01-02"))
+	}
+	leads, err := exportLeads(context.Background(), notionClient, *contacted, *area)
+	if err != nil {
+		log.Fatalf("Failed to list leads: %v", err)
+	}
+
+	var contactable []Business
+	for _, lead := range leads {
+		if lead.Phone != "" || lead.Email != "" {
+			contactable = append(contactable, lead)
+		}
+	}
+	sortBusinesses(contactable)
+	// Leads without a known distance go last
+	sort.SliceStable(contactable, func(i, j int) bool {
+		a, b := contactable[i].DistanceKm, contactable[j].DistanceKm
+		return a > 0 && (b == 0 || a < b)
+	})
+
+	file, err := os.Create(*out)
+	if err != nil {
+		log.Fatalf("export: %v", err)
+	}
+	defer file.Close()
+	w := bufio.NewWriter(file)
+	for _, lead := range contactable {
+		writeVCard(w, lead)
+	}
+	if err := w.Flush(); err != nil {
+		log.Fatalf("export: %v", err)
+	}
+	fmt.Printf("Exported %d of %d leads to %s\n", len(contactable), len(leads), *out)
+}
+
+// writeVCard writes a lead as a vCard 3.0 contact, the version phones import most reliably
+func writeVCard(w io.Writer, b Business) {
+	line := func(name string, values ...string) {
+		for i, value := range values {
+			values[i] = vcardEscape(value)
+		}
+		fmt.Fprint(w, foldVCardLine(name+":"+strings.Join(values, ";")))
+	}
+
+	fmt.Fprint(w, "BEGIN:VCARD\r\nVERSION:3.0\r\n")
+	line("FN", b.Name)
+	line("ORG", b.Name)
+	if b.Phone != "" {
+		line("TEL;TYPE=WORK,VOICE", b.Phone)
+	}
+	if b.Email != "" {
+		line("EMAIL;TYPE=INTERNET,WORK", b.Email)
+	}
+	if b.Street != "" || b.City != "" || b.Postcode != "" {
+		line("ADR;TYPE=WORK", "", "", b.Street, b.City, "", b.Postcode, b.Country)
+	} else if b.Address != "" {
+		line("ADR;TYPE=WORK", "", "", b.Address, "", "", "", "")
+	}
+	if b.WebsiteStatus == "Has Website" || b.WebsiteStatus == "Broken Website" {
+		line("URL", b.URL)
+	}
+	var note []string
+	for _, part := range []string{b.LeadNumber, b.WebsiteStatus, b.Urgency} {
+		if part != "" {
+			note = append(note, part)
+		}
+	}
+	if b.OpeningHours != "" {
+		note = append(note, "Hours: "+strings.ReplaceAll(b.OpeningHours, "\n", "; "))
+	}
+	if len(note) > 0 {
+		line("NOTE", strings.Join(note, "\n"))
+	}
+	if len(b.Type) > 0 {
+		// Categories are separated by commas, so each is escaped on its own
+		categories := make([]string, len(b.Type))
+		for i, t := range b.Type {
+			categories[i] = vcardEscape(t)
+		}
+		fmt.Fprint(w, foldVCardLine("CATEGORIES:"+strings.Join(categories, ",")))
+	}
+	fmt.Fprint(w, "END:VCARD\r\n")
+}
+
+// vcardEscape escapes the characters vCard values give a meaning to
+func vcardEscape(value string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`).Replace(value)
+}
+
+// foldVCardLine ends a content line with CRLF, folding it onto continuation lines so none is
+// longer than the 75 octets vCard allows, without splitting a UTF-8 character
+func foldVCardLine(line string) string {
+	var sb strings.Builder
+	width := 0
+	for _, r := range line {
+		size := len(string(r))
+		if width+size > 75 {
+			sb.WriteString("\r\n ")
+			width = 1
+		}
+		sb.WriteRune(r)
+		width += size
+	}
+	sb.WriteString("\r\n")
+	return sb.String()
+}
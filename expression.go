@@ -0,0 +1,92 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"sync"
+
+	"github.com/expr-lang/expr"
+	"github.com/expr-lang/expr/vm"
+)
+
+// expressionEnv is what filter and urgency rule expressions see of a business, e.g.
+// `rating >= 4.0 && reviews > 25 && website == ""`
+type expressionEnv struct {
+	Name  string   `expr:"name"`
+	Types []string `expr:"types"`
+	// Rating and Reviews are 0 for listings from sources without ratings
+	Rating  float64 `expr:"rating"`
+	Reviews int     `expr:"reviews"`
+	// Website is the URL of the business's website, working or not, or "" when it lists none
+	Website string `expr:"website"`
+	// WebsiteStatus is "none", "present", "broken" or "unknown", as in urgency rules
+	WebsiteStatus string  `expr:"website_status"`
+	SSL           bool    `expr:"ssl"`
+	HoursListed   bool    `expr:"hours_listed"`
+	Phone         string  `expr:"phone"`
+	Email         string  `expr:"email"`
+	Status        string  `expr:"status"`
+	City          string  `expr:"city"`
+	Postcode      string  `expr:"postcode"`
+	Country       string  `expr:"country"`
+	DistanceKm    float64 `expr:"distance_km"`
+	Area          string  `expr:"area"`
+	Source        string  `expr:"source"`
+}
+
+// newExpressionEnv returns what expressions see of a business
+func newExpressionEnv(b *Business) expressionEnv {
+	env := expressionEnv{
+		Name:          b.Name,
+		Types:         b.Type,
+		Rating:        b.Rating,
+		Reviews:       b.ReviewCount,
+		WebsiteStatus: websiteState(b),
+		SSL:           b.HTTPS,
+		HoursListed:   b.HoursListed,
+		Phone:         b.Phone,
+		Email:         b.Email,
+		Status:        b.BusinessStatus,
+		City:          b.City,
+		Postcode:      b.Postcode,
+		Country:       b.Country,
+		DistanceKm:    b.DistanceKm,
+		Area:          b.SearchArea,
+		Source:        b.FirstSource,
+	}
+	// Leads without a website have their Maps search as URL
+	if hasSite(b) {
+		env.Website = b.URL
+	}
+	return env
+}
+
+// expressions caches the compiled expressions by their source, as rules are evaluated per business
+var expressions sync.Map
+
+// compileExpression compiles a condition on a business, which must evaluate to true or false
+func compileExpression(source string) (*vm.Program, error) {
+	if program, ok := expressions.Load(source); ok {
+		return program.(*vm.Program), nil
+	}
+	program, err := expr.Compile(source, expr.Env(expressionEnv{}), expr.AsBool())
+	if err != nil {
+		return nil, fmt.Errorf("expression %q: %w", source, err)
+	}
+	expressions.Store(source, program)
+	return program, nil
+}
+
+// matchExpression reports whether a business satisfies an expression. Validate has compiled it, so
+// an expression that fails to is logged and doesn't match.
+func matchExpression(source string, b *Business) bool {
+	program, err := compileExpression(source)
+	if err == nil {
+		var matched any
+		if matched, err = expr.Run(program, newExpressionEnv(b)); err == nil {
+			return matched.(bool)
+		}
+	}
+	slog.Error("Failed to evaluate expression", "operation", "expression", "place_id", b.PlaceID, "name", b.Name, "err", err)
+	return false
+}
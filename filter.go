@@ -0,0 +1,142 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// fieldAliases are shorter names accepted in filter expressions for catalog fields
+var fieldAliases = map[string]string{
+	"type":   "Types",
+	"town":   "SearchArea",
+	"area":   "SearchArea",
+	"status": "Contacted",
+	"score":  "LeadScore",
+	"source": "Sources",
+}
+
+// resolveField maps a field name from the command line, in any case or as an alias, to its catalog name
+func resolveField(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if field, ok := fieldAliases[strings.ToLower(name)]; ok {
+		return field, nil
+	}
+	for _, v := range templateVariables {
+		if strings.EqualFold(v.Name, name) {
+			return v.Name, nil
+		}
+	}
+	return "", fmt.Errorf("unknown field %q (see `template vars`)", name)
+}
+
+// condition is one comparison of a filter expression, e.g. type=cafe
+type condition struct {
+	field string
+	op    string
+	value string
+}
+
+// Filter selects businesses with an expression of conditions joined by "and", e.g.
+//
+//	type=cafe and town=Falmouth and score>=60
+//
+// Operators are = and != (case-insensitive), ~ (contains) and <, <=, >, >= (numeric).
+// Multi-valued fields such as Types and Sources match when any of their values does.
+type Filter struct {
+	conditions []condition
+}
+
+var (
+	andPattern       = regexp.MustCompile(`(?i)\s+and\s+`)
+	conditionPattern = regexp.MustCompile(`^\s*([A-Za-z]+)\s*(!=|<=|>=|=|~|<|>)\s*(.*?)\s*$`)
+)
+
+// ParseFilter parses a filter expression; an empty expression matches everything
+func ParseFilter(expr string) (*Filter, error) {
+	f := &Filter{}
+	if strings.TrimSpace(expr) == "" {
+		return f, nil
+	}
+	for _, part := range andPattern.Split(strings.TrimSpace(expr), -1) {
+		m := conditionPattern.FindStringSubmatch(part)
+		if m == nil {
+			return nil, fmt.Errorf("invalid condition %q, expected e.g. type=cafe", part)
+		}
+		field, err := resolveField(m[1])
+		if err != nil {
+			return nil, err
+		}
+		c := condition{field: field, op: m[2], value: strings.Trim(m[3], `"'`)}
+		if strings.ContainsAny(c.op, "<>") {
+			if _, err := strconv.ParseFloat(c.value, 64); err != nil {
+				return nil, fmt.Errorf("condition %q needs a number", part)
+			}
+		}
+		f.conditions = append(f.conditions, c)
+	}
+	return f, nil
+}
+
+// Matches reports whether a business satisfies every condition
+func (f *Filter) Matches(b Business) bool {
+	if len(f.conditions) == 0 {
+		return true
+	}
+	data := templateData(b)
+	for _, c := range f.conditions {
+		if !c.matches(data[c.field]) {
+			return false
+		}
+	}
+	return true
+}
+
+// matches compares a field value, trying each value of comma-separated fields
+func (c condition) matches(value string) bool {
+	values := []string{value}
+	if c.field == "Types" || c.field == "Sources" {
+		values = strings.Split(value, ", ")
+	}
+	if c.op == "!=" {
+		for _, v := range values {
+			if strings.EqualFold(v, c.value) {
+				return false
+			}
+		}
+		return true
+	}
+	for _, v := range values {
+		if c.compare(v) {
+			return true
+		}
+	}
+	return false
+}
+
+// compare applies a positive operator to a single value
+func (c condition) compare(value string) bool {
+	switch c.op {
+	case "=":
+		return strings.EqualFold(value, c.value)
+	case "~":
+		return strings.Contains(strings.ToLower(value), strings.ToLower(c.value))
+	}
+	got, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return false
+	}
+	want, _ := strconv.ParseFloat(c.value, 64)
+	switch c.op {
+	case "<":
+		return got < want
+	case "<=":
+		return got <= want
+	case ">":
+		return got > want
+	case ">=":
+		return got >= want
+	}
+	return false
+}
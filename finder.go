@@ -0,0 +1,465 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Finder searches its sources for businesses, enriches them, and writes them to the configured sinks
+type Finder struct {
+	sources []Source
+	sinks   []Sink
+	// crawler and domainChecker are nil in fixture runs, which stay offline
+	crawler       *WebsiteCrawler
+	domainChecker *DomainChecker
+	// companies is nil unless Companies House lookups are enabled
+	companies *CompaniesHouse
+	// emails is nil unless emails are guessed for sites that list none
+	emails       *EmailGuesser
+	urgencyRules []UrgencyRule
+	weights      ScoreWeights
+	policy       Policy
+	cadence      Cadence
+	// state records the listings seen; with sinceLastRun, ones previous runs saw are skipped
+	state        *RunState
+	sinceLastRun bool
+	notify       *Notifications
+	// campaign tags the leads found with the campaign the run is for
+	campaign string
+	// runID tags the leads found with the run that wrote them
+	runID string
+	// onLead, when set, is called with every new lead once it is written
+	onLead func(*Business)
+	// fieldSources decide which fields later sources' listings of a lead fill in or replace
+	fieldSources FieldSources
+	// history gets a snapshot of every listing enriched, known leads included, for trends
+	history *History
+	// deadLetters keep the leads a sink failed to write, to be written again later
+	deadLetters *DeadLetters
+	// filter drops listings not worth enriching, such as chains
+	filter *ListingFilter
+	// enrichSteps name the enrichers each new lead goes through, in order; the defaults when empty
+	enrichSteps []string
+	// hooks are the external enrichers enrichSteps can name
+	hooks []HookConfig
+	// enrichers are built from enrichSteps when the run starts
+	enrichers []Enricher
+
+	// inserts writes the enriched leads to the sinks in the background
+	inserts *insertPipeline
+
+	// mu guards what the insert workers share with searching: the fields below and the writes to
+	// sinks that aren't safe for concurrent use
+	mu sync.Mutex
+	// found are the leads written, or queued for writing, this run, so later sources' listings of
+	// them are merged in
+	found []*Business
+	// byPlace indexes the found leads by PlaceID, and nearby by their duplicateKeys, so listings
+	// and duplicates are looked up without going over every lead
+	byPlace map[string]*Business
+	nearby  map[string][]*Business
+	// queued are the insert jobs of the found leads not written yet
+	queued map[*Business]*insertJob
+	// failures describe the searches and writes that failed this run, for the digest
+	failures []string
+	stats    map[string]*sourceStats
+	// Quality collects the data quality issues of the leads written this run
+	Quality QualityReport
+}
+
+// sourceStats count what one source contributed this run
+type sourceStats struct {
+	listings int
+	// leads are new leads the source found first
+	leads int
+	// matched are listings of leads another source found first
+	matched int
+}
+
+// Start starts the workers writing leads; Wait must be called once searching is done. The leads
+// queued when ctx is cancelled are still written.
+func (f *Finder) Start(ctx context.Context) {
+	for _, source := range f.sources {
+		if scoped, ok := source.(RunScoped); ok {
+			scoped.Reset()
+		}
+	}
+	f.queued = make(map[*Business]*insertJob)
+	f.byPlace, f.nearby = make(map[string]*Business), make(map[string][]*Business)
+	f.enrichers = f.newEnrichers(f.enrichSteps)
+	f.inserts = newInsertPipeline(context.WithoutCancel(ctx), f)
+}
+
+// Wait waits for the leads still queued to be written
+func (f *Finder) Wait() {
+	f.inserts.Close()
+}
+
+// Search searches the area with every source in turn, until ctx is cancelled
+func (f *Finder) Search(ctx context.Context, area *SearchArea) {
+	progress.Printf("Searching area: %s\n", area.Name)
+	for _, source := range f.sources {
+		if ctx.Err() != nil {
+			return
+		}
+		err := source.Search(ctx, area, func(b *Business) {
+			f.mu.Lock()
+			f.sourceStats(source.Name()).listings++
+			f.mu.Unlock()
+			progress.Found()
+			b.setDistance(area)
+			f.process(ctx, source.Name(), b)
+		})
+		switch {
+		case ctx.Err() != nil:
+			progress.Printf("Stopped searching %s on %s\n", area.Name, source.Name())
+		case errors.Is(err, errBudgetExhausted):
+			slog.Warn("Stopped searching, Google API budget reached", "operation", "search", "area", area.Name, "source", source.Name(), "err", err)
+			f.fail(ctx, fmt.Sprintf("Searching %s on %s stopped: %v", area.Name, source.Name(), err))
+		case err != nil:
+			slog.Error("Failed to search area", "operation", "search", "area", area.Name, "source", source.Name(), "err", err)
+			f.fail(ctx, fmt.Sprintf("Searching %s on %s failed: %v", area.Name, source.Name(), err))
+		}
+	}
+}
+
+// fail records a failure for the digest and sends it as an error event
+func (f *Finder) fail(ctx context.Context, failure string) {
+	f.mu.Lock()
+	f.failures = append(f.failures, failure)
+	f.mu.Unlock()
+	f.notify.Send(ctx, Event{Type: eventError, Summary: failure})
+}
+
+// process merges a listing into a lead another source already found, or enriches it and queues it
+// for writing to every sink
+func (f *Finder) process(ctx context.Context, source string, business *Business) {
+	// Once the run is stopped, listings still coming in are dropped, but one already being
+	// enriched is finished and written rather than left half done
+	if ctx.Err() != nil {
+		return
+	}
+	ctx = context.WithoutCancel(ctx)
+	// Another listing of the place, e.g. from an overlapping grid cell or another type's search,
+	// was already written or queued
+	if lead := f.listed(business.PlaceID); lead != nil {
+		f.addTypes(ctx, lead, business)
+		progress.Skipped()
+		return
+	}
+	if f.state.Seen(business.PlaceID) && f.sinceLastRun {
+		progress.Skipped()
+		return
+	}
+	if reason := f.filter.DropComplete(source, business); reason != "" {
+		progress.Verbosef("Skipped %s: %s\n", business.Name, reason)
+		progress.Skipped()
+		return
+	}
+	if lead := f.match(source, business); lead != nil {
+		f.merge(ctx, source, lead, business)
+		f.mu.Lock()
+		f.sourceStats(source).matched++
+		f.mu.Unlock()
+		return
+	}
+
+	business.Contacted = "Not Contacted"
+	business.Sources, business.FirstSource = []string{source}, source
+	business.Campaign, business.RunID = f.campaign, f.runID
+	f.cadence.Schedule(business, time.Now())
+	// Issues are only reported for leads that get written, not ones skipped as known
+	job := &insertJob{lead: business, source: source, done: make(chan struct{})}
+	f.enrich(ctx, business, &job.issues)
+
+	f.mu.Lock()
+	f.addFound(business)
+	f.queued[business] = job
+	f.mu.Unlock()
+	f.inserts.Add(job)
+}
+
+// finish writes a queued lead, unless the batch check found it known, and records the outcome
+func (f *Finder) finish(ctx context.Context, job *insertJob, known bool) {
+	business := job.lead
+	if known {
+		progress.Verbosef("Business with PlaceID %s already exists, skipping...\n", business.PlaceID)
+		slog.Debug("Lead already exists", "operation", "write", "place_id", business.PlaceID, "place_type", business.Type)
+		progress.Skipped()
+	} else {
+		f.flagDuplicate(ctx, job)
+		job.written = f.write(ctx, business)
+	}
+
+	f.mu.Lock()
+	delete(f.queued, business)
+	if !job.written {
+		f.dropFound(business)
+		close(job.done)
+		f.mu.Unlock()
+		return
+	}
+	// Leads still queued are being written by other workers, and checked against this one when done
+	var candidates []*Business
+	for _, key := range duplicateKeys(business) {
+		for _, lead := range f.nearby[key] {
+			if lead != business && f.queued[lead] == nil && !slices.Contains(candidates, lead) {
+				candidates = append(candidates, lead)
+			}
+		}
+	}
+	f.mu.Unlock()
+	// Names, addresses and locations aren't changed by merges, so they are compared without
+	// holding up the other workers
+	candidates = slices.DeleteFunc(candidates, func(lead *Business) bool {
+		return !sameBusiness(lead, business) && !likelyDuplicate(lead, business)
+	})
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	defer close(job.done)
+	job.issues.CheckLead(business)
+	for _, lead := range candidates {
+		job.issues.add(severityHigh, "suspicious duplicate", business, "looks like %s %s (%s)", lead.LeadNumber, lead.Name, lead.Address)
+	}
+	f.Quality.Merge(job.issues)
+	f.sourceStats(job.source).leads++
+	// Only what merging needs is kept, so memory stays flat over long runs
+	business.Photos, business.ReviewSnippets, business.ReviewThemes = nil, nil, ""
+}
+
+// listed returns the lead of the place written or queued this run, or nil
+func (f *Finder) listed(placeID string) *Business {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.byPlace[placeID]
+}
+
+// addFound adds a lead to the found ones and their indexes; f.mu must be held
+func (f *Finder) addFound(b *Business) {
+	f.found = append(f.found, b)
+	f.byPlace[b.PlaceID] = b
+	for _, key := range duplicateKeys(b) {
+		f.nearby[key] = append(f.nearby[key], b)
+	}
+}
+
+// dropFound removes a lead that wasn't written from the found ones; f.mu must be held
+func (f *Finder) dropFound(b *Business) {
+	isLead := func(lead *Business) bool { return lead == b }
+	f.found = slices.DeleteFunc(f.found, isLead)
+	if f.byPlace[b.PlaceID] == b {
+		delete(f.byPlace, b.PlaceID)
+	}
+	for _, key := range duplicateKeys(b) {
+		f.nearby[key] = slices.DeleteFunc(f.nearby[key], isLead)
+		if len(f.nearby[key]) == 0 {
+			delete(f.nearby, key)
+		}
+	}
+}
+
+// addTypes merges the place types of another listing of a lead's place into the lead's, along
+// with the type searched for, so the lead carries every type it was found under
+func (f *Finder) addTypes(ctx context.Context, lead, listing *Business) {
+	f.mu.Lock()
+	var added []string
+	for _, t := range append(slices.Clone(listing.Type), listing.SearchedType) {
+		if t != "" && !slices.Contains(lead.Type, t) && !slices.Contains(added, t) {
+			added = append(added, t)
+		}
+	}
+	job := f.queued[lead]
+	f.mu.Unlock()
+	if len(added) == 0 {
+		return
+	}
+	if job != nil {
+		// The lead is being written; its types are updated once it is
+		<-job.done
+		if !job.written {
+			return
+		}
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	before := *lead
+	types := slices.DeleteFunc(slices.Clone(lead.Type), func(t string) bool { return t == "Other" })
+	lead.Type = append(types, added...)
+	updateSinks(ctx, f.sinks, before, lead, []string{"Types"}, "found as "+cmp.Or(listing.SearchedType, listing.SearchKeyword))
+}
+
+// sourceStats returns the counts of a source; f.mu must be held while insert workers run
+func (f *Finder) sourceStats(source string) *sourceStats {
+	if f.stats == nil {
+		f.stats = make(map[string]*sourceStats)
+	}
+	stats, ok := f.stats[source]
+	if !ok {
+		stats = &sourceStats{}
+		f.stats[source] = stats
+	}
+	return stats
+}
+
+// SourceReport summarizes what each source contributed this run, in search order
+func (f *Finder) SourceReport() string {
+	var sb strings.Builder
+	sb.WriteString("Sources:\n")
+	for _, source := range f.sources {
+		stats := f.sourceStats(source.Name())
+		fmt.Fprintf(&sb, "  %s: %d listings, %d new leads, %d matched to leads found by another source\n",
+			source.Name(), stats.listings, stats.leads, stats.matched)
+	}
+	return sb.String()
+}
+
+// enrich fills in what can be learnt about a business beyond its listing with each enricher in
+// turn. Steps that fail are recorded in issues.
+func (f *Finder) enrich(ctx context.Context, business *Business, issues *QualityReport) {
+	business.fillAddressParts()
+	if business.WebsiteStatus == "No Website" {
+		business.URL = "https://www.google.com/maps/search/?api=1&query=" + url.QueryEscape(business.Address)
+	}
+	runEnrichers(ctx, f.enrichers, business, issues)
+}
+
+// write sends a business to every sink, reporting whether it was new. Sinks that can't take
+// concurrent writes are written under f.mu.
+func (f *Finder) write(ctx context.Context, business *Business) bool {
+	// The Notion sink runs first and sets the lead number the other sinks record
+	inserted := false
+	for _, sink := range f.sinks {
+		var err error
+		if _, ok := sink.(concurrentWriter); ok {
+			err = sink.Write(ctx, business)
+		} else {
+			f.mu.Lock()
+			err = sink.Write(ctx, business)
+			f.mu.Unlock()
+		}
+		if errors.Is(err, errBusinessExists) {
+			// Known leads aren't written again, so other sinks don't get duplicates or re-gain
+			// contact data purged since
+			progress.Verbosef("Business with PlaceID %s already exists, skipping...\n", business.PlaceID)
+			slog.Debug("Lead already exists", "operation", "write", "place_id", business.PlaceID, "place_type", business.Type)
+			progress.Skipped()
+			return false
+		}
+		if err != nil {
+			slog.Error("Failed to write lead", "operation", "write", "sink", sink.Name(), "place_id", business.PlaceID, "name", business.Name, "err", err)
+			f.mu.Lock()
+			f.failures = append(f.failures, fmt.Sprintf("Writing %s to %s failed: %v", business.Name, sink.Name(), err))
+			f.deadLetters.Add(sink.Name(), business, err)
+			f.mu.Unlock()
+			continue
+		}
+		inserted = true
+	}
+	if !inserted {
+		progress.Failed()
+		return false
+	}
+	progress.Inserted()
+	slog.Debug("Inserted lead", "operation", "write", "place_id", business.PlaceID, "place_type", business.Type,
+		"lead", business.LeadNumber, "urgency", business.Urgency, "score", business.LeadScore)
+	progress.Printf("Inserted %s: Name: %s, Address: %s, Types: %v, WebsiteStatus: %s, Urgency: %s, Score: %d\n", business.LeadNumber, business.Name, business.Address, business.Type, business.WebsiteStatus, business.Urgency, business.LeadScore)
+	if business.Urgency == "High" {
+		f.notify.Send(ctx, Event{Type: eventHotLead, Summary: fmt.Sprintf("New High urgency lead: %s, %s", business.Name, business.Address), Lead: business})
+	}
+	if f.onLead != nil {
+		f.mu.Lock()
+		f.onLead(business)
+		f.mu.Unlock()
+	}
+	return true
+}
+
+// match returns the lead other sources found this run that a listing describes, if any. A lead
+// still queued is waited for, so the listing is merged into what the sinks hold, and skipped if
+// it turned out to be known.
+func (f *Finder) match(source string, business *Business) *Business {
+	f.mu.Lock()
+	var lead *Business
+	for _, found := range f.found {
+		if !slices.Contains(found.Sources, source) && sameBusiness(found, business) {
+			lead = found
+			break
+		}
+	}
+	job := f.queued[lead]
+	f.mu.Unlock()
+	if job == nil {
+		return lead
+	}
+	<-job.done
+	if !job.written {
+		return nil
+	}
+	return lead
+}
+
+// merge records another source's listing of a lead, fills in or replaces the lead's details from
+// it as the field source rules allow, and updates the sinks. Phone numbers both sources list but
+// disagree on, and that the rules don't settle, are logged for checking by hand.
+func (f *Finder) merge(ctx context.Context, source string, lead, listing *Business) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	before := *lead
+	before.Provenance = maps.Clone(lead.Provenance)
+	lead.Sources = append(lead.Sources, source)
+	changed := []string{"Sources"}
+	fill := func(field string, current *string, value string) {
+		if value == "" || *current == value {
+			return
+		}
+		from := ""
+		if *current != "" {
+			from = cmp.Or(lead.Provenance[field], lead.FirstSource)
+		}
+		if !f.fieldSources.allows(field, source, from) {
+			if field == "Phone" && from != "" {
+				slog.Warn("Phone numbers disagree", "operation", "merge", "place_id", lead.PlaceID, "name", lead.Name, "lead_phone", *current, "source", source, "source_phone", value)
+			}
+			return
+		}
+		*current = value
+		if lead.Provenance == nil {
+			lead.Provenance = make(map[string]string)
+		}
+		lead.Provenance[field] = source
+		changed = append(changed, field)
+	}
+	fill("Phone", &lead.Phone, listing.Phone)
+	fill("Email", &lead.Email, listing.Email)
+	fill("OpeningHours", &lead.OpeningHours, listing.OpeningHours)
+	fill("Facebook", &lead.Facebook, listing.Facebook)
+	fill("Instagram", &lead.Instagram, listing.Instagram)
+	fill("LinkedIn", &lead.LinkedIn, listing.LinkedIn)
+	fill("X", &lead.X, listing.X)
+	if lead.Provenance["OpeningHours"] == source {
+		lead.HoursListed = true
+		changed = append(changed, "HoursListed")
+	}
+	if lead.Provenance["Email"] == source && slices.Contains(changed, "Email") {
+		lead.EmailConfidence = cmp.Or(listing.EmailConfidence, scrapedEmailConfidence)
+		changed = append(changed, "EmailConfidence")
+	}
+	if len(changed) > 1 {
+		changed = append(changed, "Provenance")
+	}
+
+	progress.Verbosef("Matched %s on %s to %s\n", listing.Name, source, cmp.Or(lead.LeadNumber, lead.PlaceID))
+	progress.Merged()
+	updateSinks(ctx, f.sinks, before, lead, changed, "merged "+source+" listing")
+}
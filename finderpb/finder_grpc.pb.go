@@ -0,0 +1,165 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: finder.proto
+
+package finderpb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	Finder_Search_FullMethodName    = "/businessfinder.v1.Finder/Search"
+	Finder_ListLeads_FullMethodName = "/businessfinder.v1.Finder/ListLeads"
+)
+
+// FinderClient is the client API for Finder service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type FinderClient interface {
+	Search(ctx context.Context, in *SearchRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[SearchEvent], error)
+	ListLeads(ctx context.Context, in *ListLeadsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Lead], error)
+}
+
+type finderClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewFinderClient(cc grpc.ClientConnInterface) FinderClient {
+	return &finderClient{cc}
+}
+
+func (c *finderClient) Search(ctx context.Context, in *SearchRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[SearchEvent], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Finder_ServiceDesc.Streams[0], Finder_Search_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[SearchRequest, SearchEvent]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Finder_SearchClient = grpc.ServerStreamingClient[SearchEvent]
+
+func (c *finderClient) ListLeads(ctx context.Context, in *ListLeadsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Lead], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Finder_ServiceDesc.Streams[1], Finder_ListLeads_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[ListLeadsRequest, Lead]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Finder_ListLeadsClient = grpc.ServerStreamingClient[Lead]
+
+// FinderServer is the server API for Finder service.
+// All implementations must embed UnimplementedFinderServer
+// for forward compatibility.
+type FinderServer interface {
+	Search(*SearchRequest, grpc.ServerStreamingServer[SearchEvent]) error
+	ListLeads(*ListLeadsRequest, grpc.ServerStreamingServer[Lead]) error
+	mustEmbedUnimplementedFinderServer()
+}
+
+// UnimplementedFinderServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedFinderServer struct{}
+
+func (UnimplementedFinderServer) Search(*SearchRequest, grpc.ServerStreamingServer[SearchEvent]) error {
+	return status.Errorf(codes.Unimplemented, "method Search not implemented")
+}
+func (UnimplementedFinderServer) ListLeads(*ListLeadsRequest, grpc.ServerStreamingServer[Lead]) error {
+	return status.Errorf(codes.Unimplemented, "method ListLeads not implemented")
+}
+func (UnimplementedFinderServer) mustEmbedUnimplementedFinderServer() {}
+func (UnimplementedFinderServer) testEmbeddedByValue()                {}
+
+// UnsafeFinderServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to FinderServer will
+// result in compilation errors.
+type UnsafeFinderServer interface {
+	mustEmbedUnimplementedFinderServer()
+}
+
+func RegisterFinderServer(s grpc.ServiceRegistrar, srv FinderServer) {
+	// If the following call pancis, it indicates UnimplementedFinderServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Finder_ServiceDesc, srv)
+}
+
+func _Finder_Search_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(SearchRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(FinderServer).Search(m, &grpc.GenericServerStream[SearchRequest, SearchEvent]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Finder_SearchServer = grpc.ServerStreamingServer[SearchEvent]
+
+func _Finder_ListLeads_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ListLeadsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(FinderServer).ListLeads(m, &grpc.GenericServerStream[ListLeadsRequest, Lead]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Finder_ListLeadsServer = grpc.ServerStreamingServer[Lead]
+
+// Finder_ServiceDesc is the grpc.ServiceDesc for Finder service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Finder_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "businessfinder.v1.Finder",
+	HandlerType: (*FinderServer)(nil),
+	Methods:     []grpc.MethodDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Search",
+			Handler:       _Finder_Search_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "ListLeads",
+			Handler:       _Finder_ListLeads_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "finder.proto",
+}
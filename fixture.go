@@ -0,0 +1,165 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+
+	"googlemaps.github.io/maps"
+)
+
+const (
+	// fixtureSourceName is the source searching canned Places data instead of the Places API
+	fixtureSourceName = "fixture"
+	// defaultFixturePath is the canned Places data searched unless --fixtures says otherwise
+	defaultFixturePath = "fixtures/places.json"
+)
+
+// errFixtureNotFound is returned for details of a place the fixtures don't have
+var errFixtureNotFound = errors.New("NOT_FOUND: no fixture for place")
+
+// placesFixture is canned Places data, in the legacy API's JSON: the search results of every
+// place, and each place's details keyed by PlaceID
+type placesFixture struct {
+	Results []maps.PlacesSearchResult          `json:"results"`
+	Details map[string]maps.PlaceDetailsResult `json:"details"`
+}
+
+// FixturePlaces answers Places requests from canned data, so the fixture source runs the Google
+// source's pipeline without an API key or spend. Searches return the places of the type asked
+// for within the radius, in one page.
+type FixturePlaces struct {
+	fixture placesFixture
+}
+
+// LoadFixturePlaces reads the canned Places data at path
+func LoadFixturePlaces(path string) (*FixturePlaces, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var fixture placesFixture
+	if err := json.Unmarshal(data, &fixture); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	return &FixturePlaces{fixture: fixture}, nil
+}
+
+func (fp *FixturePlaces) NearbySearch(ctx context.Context, r *maps.NearbySearchRequest) (maps.PlacesSearchResponse, error) {
+	var res maps.PlacesSearchResponse
+	for _, place := range fp.fixture.Results {
+		if r.Location != nil && distanceMetres(*r.Location, place.Geometry.Location) > float64(r.Radius) {
+			continue
+		}
+		if r.Type != "" && !slices.Contains(place.Types, string(r.Type)) {
+			continue
+		}
+		if r.Keyword != "" && !containsFold(place.Name, r.Keyword) {
+			continue
+		}
+		res.Results = append(res.Results, place)
+	}
+	return res, nil
+}
+
+func (fp *FixturePlaces) TextSearch(ctx context.Context, r *maps.TextSearchRequest) (maps.PlacesSearchResponse, error) {
+	var res maps.PlacesSearchResponse
+	words := strings.Fields(r.Query)
+	for _, place := range fp.fixture.Results {
+		text := place.Name + " " + strings.Join(place.Types, " ")
+		if slices.ContainsFunc(words, func(word string) bool { return containsFold(text, word) }) {
+			res.Results = append(res.Results, place)
+		}
+	}
+	return res, nil
+}
+
+func (fp *FixturePlaces) PlaceDetails(ctx context.Context, r *maps.PlaceDetailsRequest) (maps.PlaceDetailsResult, error) {
+	details, ok := fp.fixture.Details[r.PlaceID]
+	if !ok {
+		return maps.PlaceDetailsResult{}, fmt.Errorf("%w %s", errFixtureNotFound, r.PlaceID)
+	}
+	return details, nil
+}
+
+// FindPlaceFromText returns the place whose name the input starts with, as imports put the
+// name first
+func (fp *FixturePlaces) FindPlaceFromText(ctx context.Context, r *maps.FindPlaceFromTextRequest) (maps.FindPlaceFromTextResponse, error) {
+	var res maps.FindPlaceFromTextResponse
+	for _, place := range fp.fixture.Results {
+		if strings.HasPrefix(strings.ToLower(r.Input), strings.ToLower(place.Name)) {
+			res.Candidates = append(res.Candidates, place)
+			break
+		}
+	}
+	return res, nil
+}
+
+func (fp *FixturePlaces) PhotoURL(ctx context.Context, reference string) (string, error) {
+	return "https://example.com/fixture-photos/" + reference, nil
+}
+
+// containsFold reports whether substr is in s, ignoring case
+func containsFold(s, substr string) bool {
+	return strings.Contains(strings.ToLower(s), strings.ToLower(substr))
+}
+
+// MockSink stands in for the Notion sink with --mock-notion: it numbers the leads as Notion does
+// and keeps them in memory, for demos and end-to-end tests without a Notion workspace
+type MockSink struct {
+	mu    sync.Mutex
+	leads []Business
+}
+
+// NewMockSink initializes a new MockSink
+func NewMockSink() *MockSink {
+	return &MockSink{}
+}
+
+func (ms *MockSink) Name() string { return "mock-notion" }
+
+// Write stores a copy of the lead, or returns errBusinessExists for a PlaceID written before
+func (ms *MockSink) Write(ctx context.Context, b *Business) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	if slices.ContainsFunc(ms.leads, func(lead Business) bool { return lead.PlaceID == b.PlaceID }) {
+		return errBusinessExists
+	}
+	n := len(ms.leads) + 1
+	b.LeadNumber, b.PageID = fmt.Sprintf("LEAD-%d", n), fmt.Sprintf("mock-%d", n)
+	ms.leads = append(ms.leads, *b)
+	return nil
+}
+
+// Update replaces the stored copy of a lead written earlier
+func (ms *MockSink) Update(ctx context.Context, b *Business, fields []string) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	if i := slices.IndexFunc(ms.leads, func(lead Business) bool { return lead.PlaceID == b.PlaceID }); i >= 0 {
+		ms.leads[i] = *b
+	}
+	return nil
+}
+
+// Leads returns copies of the leads written, in the order they were
+func (ms *MockSink) Leads() []Business {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	return slices.Clone(ms.leads)
+}
+
+// Close lists the leads written, as there is no database to look them up in
+func (ms *MockSink) Close() error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	fmt.Printf("Mock Notion received %d leads\n", len(ms.leads))
+	for _, lead := range ms.leads {
+		fmt.Printf("  %s %s (%s): %s, %s urgency, score %d\n", lead.LeadNumber, lead.Name, strings.Join(lead.Type, ", "), lead.WebsiteStatus, lead.Urgency, lead.LeadScore)
+	}
+	return nil
+}
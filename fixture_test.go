@@ -0,0 +1,142 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"googlemaps.github.io/maps"
+)
+
+// fixtureFinder builds the Finder of a `--source fixture --mock-notion` run on the state file at
+// statePath, without a crawler or domain checker, so nothing leaves the machine
+func fixtureFinder(t *testing.T, places *FixturePlaces, statePath string) (*Finder, *MockSink) {
+	t.Helper()
+	state, err := LoadRunState(statePath)
+	if err != nil {
+		t.Fatalf("LoadRunState() error = %v", err)
+	}
+	history, err := LoadHistory(filepath.Join(t.TempDir(), "history.json"))
+	if err != nil {
+		t.Fatalf("LoadHistory() error = %v", err)
+	}
+	cfg := &Config{}
+	filter := NewListingFilter(cfg.Filters, false)
+	google := NewGoogleSource(places, NewReviewSummarizer(nil), cfg.DetailsFieldMask())
+	google.filter = filter
+	google.searchRatings = true
+	google.typeConcurrency = 1
+	sink := NewMockSink()
+	finder := &Finder{
+		sources: []Source{google},
+		sinks:   []Sink{sink},
+		filter:  filter,
+		state:   state,
+		history: history,
+		runID:   "fixture-test",
+	}
+	return finder, sink
+}
+
+// TestFixtureRun searches the bundled fixtures into a MockSink as `--source fixture --mock-notion`
+// does, without a crawler or domain checker, so nothing leaves the machine
+func TestFixtureRun(t *testing.T) {
+	places, err := LoadFixturePlaces(defaultFixturePath)
+	if err != nil {
+		t.Fatalf("LoadFixturePlaces() error = %v", err)
+	}
+	finder, sink := fixtureFinder(t, places, filepath.Join(t.TempDir(), "state.json"))
+	finder.Start(context.Background())
+	finder.Search(context.Background(), defaultSearchArea())
+	finder.Wait()
+
+	leads := sink.Leads()
+	names := make([]string, len(leads))
+	for i, lead := range leads {
+		names[i] = lead.Name
+	}
+	slices.Sort(names)
+	// The tearoom is closed for good and Costa is a chain, so neither is written
+	want := []string{"Gyllyngvase Surf Hire", "Harbour Bakery", "Maritime Cuts", "Pendennis Plumbing & Heating"}
+	if !slices.Equal(names, want) {
+		t.Fatalf("MockSink got leads %q, want %q", names, want)
+	}
+
+	for _, lead := range leads {
+		if lead.LeadNumber == "" || lead.RunID != "fixture-test" {
+			t.Errorf("%s has lead number %q and run ID %q", lead.Name, lead.LeadNumber, lead.RunID)
+		}
+		switch lead.PlaceID {
+		case "fixture-harbour-bakery":
+			if lead.Phone != "+441326210000" || lead.URL != "https://example.com/" || lead.WebsiteStatus != "Has Website" {
+				t.Errorf("Harbour Bakery = phone %q, URL %q, website %q", lead.Phone, lead.URL, lead.WebsiteStatus)
+			}
+		case "fixture-pendennis-plumbing":
+			if lead.WebsiteStatus != "No Website" || lead.SuggestedDomain != "" {
+				t.Errorf("Pendennis Plumbing = website %q, suggested domain %q; want no website and no RDAP lookup", lead.WebsiteStatus, lead.SuggestedDomain)
+			}
+		}
+	}
+}
+
+// TestFixtureRunSinceLastRun checks that --since-last-run only drops places earlier runs listed:
+// a new place listed under a second type this run still gets that type, and is counted once
+func TestFixtureRunSinceLastRun(t *testing.T) {
+	at := maps.LatLng{Lat: 50.1531, Lng: -5.0672}
+	listing := func(placeID, name string, types ...string) maps.PlacesSearchResult {
+		return maps.PlacesSearchResult{
+			PlaceID: placeID, Name: name, Types: types, Rating: 4.5, UserRatingsTotal: 20, BusinessStatus: "OPERATIONAL",
+			Geometry: maps.AddressGeometry{Location: at},
+		}
+	}
+	places := &FixturePlaces{fixture: placesFixture{
+		// The fixtures list a place once per type search that finds it, with that type
+		Results: []maps.PlacesSearchResult{
+			listing("new-place", "Harbour Bakery", "bakery"),
+			listing("new-place", "Harbour Bakery", "cafe"),
+			listing("known-place", "Quay Cafe", "bakery"),
+			listing("known-place", "Quay Cafe", "cafe"),
+		},
+		Details: map[string]maps.PlaceDetailsResult{
+			"new-place":   {FormattedAddress: "12 Arwenack Street, Falmouth TR11 3JA, UK", BusinessStatus: "OPERATIONAL"},
+			"known-place": {FormattedAddress: "1 The Quay, Falmouth TR11 3HH, UK", BusinessStatus: "OPERATIONAL"},
+		},
+	}}
+	statePath := filepath.Join(t.TempDir(), "state.json")
+	lastRun := time.Date(2026, 10, 1, 9, 0, 0, 0, time.UTC)
+	data, err := json.Marshal(runStateFile{LastRun: lastRun, PlaceIDs: []string{"known-place"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(statePath, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	finder, sink := fixtureFinder(t, places, statePath)
+	finder.sinceLastRun = true
+	finder.sources[0].(*GoogleSource).skip = func(placeID string) bool {
+		skip, _ := finder.state.Skip(placeID)
+		return skip
+	}
+	area := &SearchArea{Name: "falmouth", Location: at, Radius: 1000, Types: []SearchType{{Type: "bakery"}, {Type: "cafe"}}}
+	finder.Start(context.Background())
+	finder.Search(context.Background(), area)
+	finder.Wait()
+
+	leads := sink.Leads()
+	if len(leads) != 1 || leads[0].PlaceID != "new-place" {
+		t.Fatalf("MockSink got %d leads, want only new-place", len(leads))
+	}
+	if !slices.Equal(leads[0].Type, []string{"bakery", "cafe"}) {
+		t.Errorf("new-place has types %q, want the types of both searches", leads[0].Type)
+	}
+	want := fmt.Sprintf("1 new businesses since the last run at %s, 1 seen before\n", lastRun.Format(time.DateTime))
+	if got := finder.state.Summary(); got != want {
+		t.Errorf("Summary() = %q, want %q", got, want)
+	}
+}
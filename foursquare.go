@@ -0,0 +1,273 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+
+	"googlemaps.github.io/maps"
+)
+
+const (
+	// foursquareSearchURL is the Foursquare Places place search endpoint
+	foursquareSearchURL = "https://api.foursquare.com/v3/places/search"
+	// foursquarePageSize is the most places Foursquare returns per request
+	foursquarePageSize = 50
+	// foursquareMaxRadius is the largest search radius Foursquare accepts, in metres
+	foursquareMaxRadius = 100000
+	// foursquareFields are requested from searches; contact details are only returned when asked for
+	foursquareFields = "fsq_id,name,location,geocodes,categories,tel,email,website,social_media,rating,stats,hours,closed_bucket"
+)
+
+// foursquareCategories map Foursquare category names onto the Google place types leads are typed
+// with. Other categories are recorded under their own name.
+var foursquareCategories = map[string]maps.PlaceType{
+	"art gallery":          maps.PlaceTypeArtGallery,
+	"bakery":               maps.PlaceTypeBakery,
+	"bar":                  maps.PlaceTypeBar,
+	"pub":                  maps.PlaceTypeBar,
+	"barbershop":           maps.PlaceTypeHairCare,
+	"hair salon":           maps.PlaceTypeHairCare,
+	"beauty salon":         maps.PlaceTypeBeautySalon,
+	"bed and breakfast":    maps.PlaceTypeLodging,
+	"hotel":                maps.PlaceTypeLodging,
+	"bookstore":            maps.PlaceTypeBookStore,
+	"café":                 maps.PlaceTypeCafe,
+	"coffee shop":          maps.PlaceTypeCafe,
+	"tea room":             maps.PlaceTypeCafe,
+	"clothing store":       maps.PlaceTypeClothingStore,
+	"convenience store":    maps.PlaceTypeConvenienceStore,
+	"electrician":          maps.PlaceTypeElectrician,
+	"florist":              maps.PlaceTypeFlorist,
+	"grocery store":        maps.PlaceTypeSupermarket,
+	"supermarket":          maps.PlaceTypeSupermarket,
+	"gym":                  maps.PlaceTypeGym,
+	"gym and studio":       maps.PlaceTypeGym,
+	"jewelry store":        maps.PlaceTypeJewelryStore,
+	"museum":               maps.PlaceTypeMuseum,
+	"night club":           maps.PlaceTypeNightClub,
+	"pet supplies store":   maps.PlaceTypePetStore,
+	"plumber":              maps.PlaceTypePlumber,
+	"restaurant":           maps.PlaceTypeRestaurant,
+	"shoe store":           maps.PlaceTypeShoeStore,
+	"spa":                  maps.PlaceTypeSpa,
+	"veterinarian":         maps.PlaceTypeVeterinaryCare,
+	"fast food restaurant": maps.PlaceTypeMealTakeaway,
+}
+
+// FoursquareSource finds businesses with the Foursquare Places API
+type FoursquareSource struct {
+	apiKey string
+	client *http.Client
+}
+
+// NewFoursquareSource initializes a new FoursquareSource
+func NewFoursquareSource(apiKey string) *FoursquareSource {
+	return &FoursquareSource{
+		apiKey: apiKey,
+		client: &http.Client{Timeout: requestTimeout},
+	}
+}
+
+func (fs *FoursquareSource) Name() string { return "foursquare" }
+
+// foursquarePlace is a place as returned by Foursquare's search
+type foursquarePlace struct {
+	FsqID    string `json:"fsq_id"`
+	Name     string `json:"name"`
+	Location struct {
+		FormattedAddress string `json:"formatted_address"`
+	} `json:"location"`
+	Geocodes struct {
+		Main struct {
+			Latitude  float64 `json:"latitude"`
+			Longitude float64 `json:"longitude"`
+		} `json:"main"`
+	} `json:"geocodes"`
+	Categories []struct {
+		Name string `json:"name"`
+	} `json:"categories"`
+	Tel         string `json:"tel"`
+	Email       string `json:"email"`
+	Website     string `json:"website"`
+	SocialMedia struct {
+		FacebookID string `json:"facebook_id"`
+		Instagram  string `json:"instagram"`
+		Twitter    string `json:"twitter"`
+	} `json:"social_media"`
+	// Rating is out of 10
+	Rating float64 `json:"rating"`
+	Stats  struct {
+		TotalRatings int `json:"total_ratings"`
+	} `json:"stats"`
+	Hours struct {
+		Display string `json:"display"`
+	} `json:"hours"`
+	ClosedBucket string `json:"closed_bucket"`
+}
+
+// Search runs a query for each of the area's types and queries in every search cell
+func (fs *FoursquareSource) Search(ctx context.Context, area *SearchArea, fn func(*Business)) error {
+	progress.Verbosef("Searching %s on Foursquare\n", area.Name)
+	seen := make(map[string]struct{})
+	for _, st := range area.searchTerms() {
+		typeArea := area.forType(st)
+		cells := typeArea.Cells()
+		progress.Stage(fmt.Sprintf("foursquare %q", st.term()), len(cells))
+		for _, cell := range cells {
+			if !progress.Proceed(ctx) {
+				break
+			}
+			if err := fs.searchCell(ctx, typeArea, cell, st, seen, fn); err != nil {
+				return err
+			}
+			progress.Step()
+		}
+	}
+	return nil
+}
+
+// searchCell follows the pages of one query in one cell
+func (fs *FoursquareSource) searchCell(ctx context.Context, area *SearchArea, cell SearchCell, st SearchType, seen map[string]struct{}, fn func(*Business)) error {
+	term := st.term()
+	query := url.Values{}
+	query.Set("query", term)
+	query.Set("ll", strconv.FormatFloat(cell.Center.Lat, 'f', 6, 64)+","+strconv.FormatFloat(cell.Center.Lng, 'f', 6, 64))
+	query.Set("radius", strconv.Itoa(int(min(cell.Radius, foursquareMaxRadius))))
+	query.Set("limit", strconv.Itoa(foursquarePageSize))
+	query.Set("fields", foursquareFields)
+	next := foursquareSearchURL + "?" + query.Encode()
+
+	for next != "" {
+		var res struct {
+			Results []foursquarePlace `json:"results"`
+		}
+		var err error
+		next, err = fs.get(ctx, next, &res)
+		if err != nil {
+			return fmt.Errorf("searching %q: %w", term, err)
+		}
+		for _, place := range res.Results {
+			if _, ok := seen[place.FsqID]; ok {
+				continue
+			}
+			seen[place.FsqID] = struct{}{}
+			business := place.business(area)
+			if !area.InBoundary(business.Location) {
+				continue
+			}
+			business.tagSearch(st, cell)
+			fn(business)
+		}
+	}
+	return nil
+}
+
+// get fetches a page of results and returns the URL of the next one, from the Link header
+func (fs *FoursquareSource) get(ctx context.Context, link string, out any) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, link, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Authorization", fs.apiKey)
+	req.Header.Set("Accept", "application/json")
+	resp, err := fs.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("foursquare: unexpected status %d", resp.StatusCode)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return "", err
+	}
+	return nextLink(resp.Header.Get("Link")), nil
+}
+
+// nextLink returns the rel="next" URL of a Link header, e.g. <https://...>; rel="next"
+func nextLink(header string) string {
+	for _, part := range strings.Split(header, ",") {
+		target, params, _ := strings.Cut(part, ";")
+		if strings.Contains(params, `rel="next"`) {
+			return strings.Trim(strings.TrimSpace(target), "<>")
+		}
+	}
+	return ""
+}
+
+// foursquareType maps a Foursquare category onto a place type, falling back to the category's own
+// name in the same lower_snake_case form
+func foursquareType(category string) string {
+	name := strings.ToLower(strings.TrimSpace(category))
+	if placeType, ok := foursquareCategories[name]; ok {
+		return string(placeType)
+	}
+	if strings.HasSuffix(name, " restaurant") {
+		return string(maps.PlaceTypeRestaurant)
+	}
+	return strings.ReplaceAll(name, " ", "_")
+}
+
+// business converts a Foursquare place to a Business. Its PlaceID is prefixed so it can't collide
+// with Google's.
+func (fp foursquarePlace) business(area *SearchArea) *Business {
+	var types []string
+	for _, c := range fp.Categories {
+		if t := foursquareType(c.Name); t != "" && !slices.Contains(types, t) {
+			types = append(types, t)
+		}
+	}
+	if len(types) == 0 {
+		types = []string{"Other"}
+	}
+
+	business := &Business{
+		Name:          fp.Name,
+		Address:       fp.Location.FormattedAddress,
+		PlaceID:       "foursquare:" + fp.FsqID,
+		Type:          types,
+		WebsiteStatus: "Unknown Website",
+		Phone:         normalizePhone("", fp.Tel),
+		ListedPhone:   fp.Tel,
+		OpeningHours:  fp.Hours.Display,
+		HoursListed:   fp.Hours.Display != "",
+		SearchArea:    area.Name,
+		Location:      maps.LatLng{Lat: fp.Geocodes.Main.Latitude, Lng: fp.Geocodes.Main.Longitude},
+	}
+	business.Email, _ = validateEmail(fp.Email)
+	if business.Email != "" {
+		business.EmailConfidence = scrapedEmailConfidence
+	}
+	// Foursquare only guesses whether a place closed; the likeliest guess counts as closed
+	if fp.ClosedBucket == "VeryLikelyClosed" {
+		business.BusinessStatus = statusClosedPermanently
+	}
+	if fp.Stats.TotalRatings > 0 && fp.Rating > 0 {
+		business.Rating = fp.Rating / 2
+		business.ReviewCount = fp.Stats.TotalRatings
+	}
+	if fp.SocialMedia.FacebookID != "" {
+		business.Facebook = "https://www.facebook.com/" + fp.SocialMedia.FacebookID
+	}
+	if fp.SocialMedia.Instagram != "" {
+		business.Instagram = "https://www.instagram.com/" + fp.SocialMedia.Instagram
+	}
+	if fp.SocialMedia.Twitter != "" {
+		business.X = "https://x.com/" + fp.SocialMedia.Twitter
+	}
+	if fp.Website != "" {
+		if profile := classifySocialURL(fp.Website); !profile.IsEmpty() {
+			business.SetSocials(profile)
+		} else {
+			business.WebsiteStatus = "Has Website"
+			business.URL = fp.Website
+		}
+	}
+	return business
+}
@@ -0,0 +1,26 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"googlemaps.github.io/maps"
+)
+
+// geocodeArea resolves a human-readable location such as "Falmouth, UK" into a search area centred
+// on it, with the built-in radius and place types
+func geocodeArea(ctx context.Context, client *maps.Client, location string) (*SearchArea, error) {
+	results, err := client.Geocode(ctx, &maps.GeocodingRequest{Address: location})
+	if err != nil {
+		return nil, fmt.Errorf("geocoding %q: %w", location, err)
+	}
+	if len(results) == 0 {
+		return nil, fmt.Errorf("no geocoding results for %q", location)
+	}
+	area := SearchArea{
+		Name:        location,
+		Description: results[0].FormattedAddress,
+		Location:    results[0].Geometry.Location,
+	}
+	return area.withDefaults(), nil
+}
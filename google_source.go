@@ -0,0 +1,405 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"sync"
+	"time"
+
+	"googlemaps.github.io/maps"
+)
+
+// GoogleSource finds businesses with Google Places searches and fills them in from place details,
+// reviews and photos
+type GoogleSource struct {
+	places           PlacesProvider
+	photoResolver    *PhotoResolver
+	reviewSummarizer *ReviewSummarizer
+	// detailsFields are requested from Place Details, which bills by the fields asked for
+	detailsFields []maps.PlaceDetailsFieldMask
+	// skip, when set, drops listings before their details are fetched
+	skip func(placeID string) bool
+	// filter, when set, drops listings by what the search result says of them, before their
+	// details are fetched
+	filter *ListingFilter
+	// searchRatings is whether search results carry ratings; the new Places API's don't, so the
+	// rating filters wait for the details
+	searchRatings bool
+	// typeConcurrency is how many place types are searched at once; the searches share the
+	// places rate limiter and budget
+	typeConcurrency int
+
+	mu sync.Mutex
+	// detailed are the places whose details were fetched this run, or are being fetched by another
+	// type's search. Searches for other types finding them again pass on only the search result,
+	// for its types to be merged into the lead.
+	detailed map[string]*detailing
+}
+
+// detailing is a place whose details one search fetches; searches for other types finding it
+// meanwhile wait until it is passed on
+type detailing struct {
+	done chan struct{}
+	// listed is set before done is closed if the place was passed on
+	listed bool
+}
+
+// NewGoogleSource initializes a new GoogleSource
+func NewGoogleSource(places PlacesProvider, reviewSummarizer *ReviewSummarizer, detailsFields []maps.PlaceDetailsFieldMask) *GoogleSource {
+	return &GoogleSource{
+		places:           places,
+		photoResolver:    NewPhotoResolver(places),
+		reviewSummarizer: reviewSummarizer,
+		detailsFields:    detailsFields,
+		typeConcurrency:  1,
+		detailed:         make(map[string]*detailing),
+	}
+}
+
+func (gs *GoogleSource) Name() string { return "google" }
+
+// Reset forgets the places detailed, for the next run
+func (gs *GoogleSource) Reset() {
+	gs.mu.Lock()
+	defer gs.mu.Unlock()
+	gs.detailed = make(map[string]*detailing)
+}
+
+// Search runs a nearby search for every place type in the area, tiling it into grid cells when
+// configured, then a text search for each of the area's queries
+func (gs *GoogleSource) Search(ctx context.Context, area *SearchArea, fn func(*Business)) error {
+	progress.Verbosef("Searching %s on Google\n", area.Name)
+
+	if gs.typeConcurrency > 1 && len(area.Types) > 1 {
+		if err := gs.searchTypes(ctx, area, fn); err != nil {
+			return err
+		}
+	} else {
+		for _, searchType := range area.Types {
+			typeArea := area.forType(searchType)
+			cells := typeArea.Cells()
+			progress.Stage(fmt.Sprintf("google %s", searchType), len(cells))
+			if err := gs.searchType(ctx, typeArea, searchType, cells, fn); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, query := range area.Queries {
+		progress.Stage(fmt.Sprintf("google %q", query), 1)
+		if !progress.Proceed(ctx) {
+			continue
+		}
+		if err := gs.searchText(ctx, area, query, make(map[string]struct{}), fn); err != nil {
+			return err
+		}
+		progress.Step()
+	}
+	return nil
+}
+
+// searchType searches the cells of one place type, a step of the current progress stage each
+func (gs *GoogleSource) searchType(ctx context.Context, area *SearchArea, searchType SearchType, cells []SearchCell, fn func(*Business)) error {
+	// Neighbouring cells overlap, so the same place is usually returned more than once
+	seen := make(map[string]struct{})
+	for _, cell := range cells {
+		if !progress.Proceed(ctx) {
+			break
+		}
+		if err := gs.searchCell(ctx, area, cell, searchType, seen, fn); err != nil {
+			return err
+		}
+		progress.Step()
+	}
+	return nil
+}
+
+// searchTypes searches up to typeConcurrency place types at once, as one progress stage, so
+// skipping it skips them all. The first error stops the other searches and is returned.
+func (gs *GoogleSource) searchTypes(ctx context.Context, area *SearchArea, fn func(*Business)) error {
+	typeAreas := make([]*SearchArea, len(area.Types))
+	cells := make([][]SearchCell, len(area.Types))
+	steps := 0
+	for i, searchType := range area.Types {
+		typeAreas[i] = area.forType(searchType)
+		cells[i] = typeAreas[i].Cells()
+		steps += len(cells[i])
+	}
+	progress.Stage(fmt.Sprintf("google %d types, %d at once", len(area.Types), gs.typeConcurrency), steps)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var (
+		wg    sync.WaitGroup
+		once  sync.Once
+		first error
+	)
+	searching := make(chan struct{}, gs.typeConcurrency)
+	for i, searchType := range area.Types {
+		searching <- struct{}{}
+		if ctx.Err() != nil {
+			break
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-searching }()
+			if err := gs.searchType(ctx, typeAreas[i], searchType, cells[i], fn); err != nil {
+				once.Do(func() {
+					first = err
+					cancel()
+				})
+			}
+		}()
+	}
+	wg.Wait()
+	return first
+}
+
+// claim returns the detailing of a place, and whether the caller found it first and so fetches
+// its details, then calls release
+func (gs *GoogleSource) claim(placeID string) (*detailing, bool) {
+	gs.mu.Lock()
+	defer gs.mu.Unlock()
+	if d, ok := gs.detailed[placeID]; ok {
+		return d, false
+	}
+	d := &detailing{done: make(chan struct{})}
+	gs.detailed[placeID] = d
+	return d, true
+}
+
+// release records whether a claimed place was passed on; places that weren't are forgotten, for
+// later searches to try again
+func (gs *GoogleSource) release(placeID string, d *detailing, listed bool) {
+	if !listed {
+		gs.mu.Lock()
+		delete(gs.detailed, placeID)
+		gs.mu.Unlock()
+	}
+	d.listed = listed
+	close(d.done)
+}
+
+// searchCell pages through the nearby search results of one cell, processing places not yet seen
+func (gs *GoogleSource) searchCell(ctx context.Context, area *SearchArea, cell SearchCell, searchType SearchType, seen map[string]struct{}, fn func(*Business)) error {
+	req := &maps.NearbySearchRequest{
+		Location: &cell.Center,
+		Radius:   cell.Radius,
+		Type:     searchType.Type,
+		Keyword:  searchType.Keyword,
+	}
+	tagged := func(b *Business) {
+		b.tagSearch(searchType, cell)
+		fn(b)
+	}
+	return gs.searchPages(ctx, area, searchType.String(), seen, tagged, func(pageToken string) (maps.PlacesSearchResponse, error) {
+		req.PageToken = pageToken
+		return gs.places.NearbySearch(ctx, req)
+	})
+}
+
+// searchText pages through the text search results of a keyword query, biased towards the area.
+// Keyword queries surface businesses whose place types don't match any searched type.
+func (gs *GoogleSource) searchText(ctx context.Context, area *SearchArea, query string, seen map[string]struct{}, fn func(*Business)) error {
+	req := &maps.TextSearchRequest{
+		Query:    query,
+		Location: &area.Location,
+		Radius:   area.Radius,
+	}
+	tagged := func(b *Business) {
+		b.tagSearch(SearchType{Keyword: query}, SearchCell{Center: area.Location, Radius: area.Radius})
+		fn(b)
+	}
+	return gs.searchPages(ctx, area, fmt.Sprintf("%q", query), seen, tagged, func(pageToken string) (maps.PlacesSearchResponse, error) {
+		req.PageToken = pageToken
+		return gs.places.TextSearch(ctx, req)
+	})
+}
+
+// searchPages follows the pages of a search, processing places not yet seen. Failed requests are
+// logged and end the search; only running out of budget, which ends the whole run, is returned.
+func (gs *GoogleSource) searchPages(ctx context.Context, area *SearchArea, label string, seen map[string]struct{}, fn func(*Business), fetch func(pageToken string) (maps.PlacesSearchResponse, error)) error {
+	pageToken := ""
+	pageCount := 0
+	for {
+		pageCount++
+		progress.Verbosef("Fetching page %d for %s\n", pageCount, label)
+
+		places, err := fetch(pageToken)
+		if errors.Is(err, errBudgetExhausted) {
+			return err
+		}
+		if err != nil {
+			slog.Error("Failed to search", "operation", "search", "source", "google", "area", area.Name, "query", label, "err", err)
+			return nil
+		}
+
+		progress.Verbosef("Found %d results on this page\n", len(places.Results))
+
+		outside := 0
+		for _, place := range places.Results {
+			if _, ok := seen[place.PlaceID]; ok {
+				continue
+			}
+			seen[place.PlaceID] = struct{}{}
+			if !area.InBoundary(place.Geometry.Location) {
+				outside++
+				continue
+			}
+			if gs.skip != nil && gs.skip(place.PlaceID) {
+				continue
+			}
+			drop := gs.filter.Drop
+			if !gs.searchRatings {
+				drop = gs.filter.DropUnrated
+			}
+			if reason := drop(gs.Name(), searchResultBusiness(place)); reason != "" {
+				progress.Found()
+				progress.Skipped()
+				progress.Verbosef("Skipped %s: %s\n", place.Name, reason)
+				continue
+			}
+			d, first := gs.claim(place.PlaceID)
+			if !first {
+				// A cafe is also food and a store; one details call covers every search finding
+				// it, which the others wait for so the lead exists to merge their types into
+				select {
+				case <-d.done:
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+				if d.listed {
+					fn(searchResultBusiness(place))
+				}
+				continue
+			}
+			business, err := gs.business(ctx, area, place)
+			if err != nil {
+				gs.release(place.PlaceID, d, false)
+				return err
+			}
+			if business == nil {
+				gs.release(place.PlaceID, d, false)
+				continue
+			}
+			// Each place is enriched and written before the next is fetched; nothing from
+			// details, photos or page crawls is kept once it has been inserted
+			fn(business)
+			gs.release(place.PlaceID, d, true)
+		}
+		if outside > 0 {
+			progress.Verbosef("Skipped %d results outside the %s boundary\n", outside, area.Name)
+		}
+
+		if places.NextPageToken == "" {
+			progress.Verbosef("No more pages for %s\n", label)
+			return nil
+		}
+
+		progress.Verbosef("Waiting before fetching next page...\n")
+		time.Sleep(5 * time.Second) // Increased delay to avoid rate limiting
+		if !progress.Proceed(ctx) {
+			return nil
+		}
+		pageToken = places.NextPageToken
+	}
+}
+
+// searchResultBusiness is what a search result alone says about a place, for filtering it
+func searchResultBusiness(place maps.PlacesSearchResult) *Business {
+	return &Business{
+		Name:           place.Name,
+		Address:        cmp.Or(place.FormattedAddress, place.Vicinity),
+		PlaceID:        place.PlaceID,
+		Type:           place.Types,
+		Rating:         math.Round(float64(place.Rating)*10) / 10,
+		ReviewCount:    place.UserRatingsTotal,
+		BusinessStatus: place.BusinessStatus,
+		Location:       place.Geometry.Location,
+	}
+}
+
+// business fetches details for a search result and builds the business from them. Places whose
+// details fail are logged and return nil; only running out of budget or the run being stopped is
+// returned as an error.
+func (gs *GoogleSource) business(ctx context.Context, area *SearchArea, place maps.PlacesSearchResult) (*Business, error) {
+	placeDetailsReq := &maps.PlaceDetailsRequest{
+		PlaceID: place.PlaceID,
+		Fields:  gs.detailsFields,
+	}
+
+	details, err := gs.places.PlaceDetails(ctx, placeDetailsReq)
+	if errors.Is(err, errBudgetExhausted) {
+		return nil, err
+	}
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	if err != nil {
+		slog.Error("Failed to get place details", "operation", "place-details", "place_id", place.PlaceID, "name", place.Name, "err", err)
+		return nil, nil
+	}
+
+	websiteStatus := "No Website"
+	website := ""
+
+	// Some businesses list a social profile as their website; record it as such
+	socials := classifySocialURL(details.Website)
+	if !socials.IsEmpty() {
+		details.Website = ""
+	}
+
+	if details.Website != "" {
+		websiteStatus = "Has Website"
+		website = details.Website
+	}
+
+	businessType := []string{"Other"}
+	if len(place.Types) > 0 {
+		businessType = place.Types
+	}
+
+	business := &Business{
+		Name:           place.Name,
+		Address:        cmp.Or(details.FormattedAddress, place.FormattedAddress),
+		PlaceID:        place.PlaceID,
+		Type:           businessType,
+		WebsiteStatus:  websiteStatus,
+		URL:            website,
+		Phone:          normalizePhone(details.InternationalPhoneNumber, details.FormattedPhoneNumber),
+		ListedPhone:    cmp.Or(details.InternationalPhoneNumber, details.FormattedPhoneNumber),
+		OpeningHours:   formatOpeningHours(details.OpeningHours),
+		HoursListed:    details.OpeningHours != nil && len(details.OpeningHours.WeekdayText) > 0,
+		BusinessStatus: cmp.Or(details.BusinessStatus, place.BusinessStatus),
+		Rating:         math.Round(float64(cmp.Or(details.Rating, place.Rating))*10) / 10,
+		ReviewCount:    cmp.Or(details.UserRatingsTotal, place.UserRatingsTotal),
+		Facebook:       socials.Facebook,
+		Instagram:      socials.Instagram,
+		LinkedIn:       socials.LinkedIn,
+		X:              socials.X,
+		SearchArea:     area.Name,
+		Location:       place.Geometry.Location,
+	}
+
+	themes, err := gs.reviewSummarizer.Summarize(ctx, details.Reviews)
+	if err != nil {
+		slog.Warn("Failed to summarize reviews", "operation", "review-summary", "place_id", place.PlaceID, "name", place.Name, "err", err)
+	}
+	business.setAddressComponents(details.AddressComponents)
+	business.ReviewThemes = themes
+	business.ReviewSnippets = reviewSnippets(details.Reviews)
+
+	photos, err := gs.photoResolver.Resolve(ctx, details.Photos)
+	if err != nil {
+		slog.Warn("Failed to resolve photos", "operation", "photos", "place_id", place.PlaceID, "name", place.Name, "err", err)
+	}
+	business.Photos = photos
+	business.UnclaimedSignals = unclaimedSignals(place.Name, details, socials, gs.detailsFields)
+	business.LikelyUnclaimed = len(business.UnclaimedSignals) >= unclaimedSignalsNeeded
+	return business, nil
+}
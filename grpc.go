@@ -0,0 +1,151 @@
+package main
+
+//go:generate protoc -I finderpb --go_out=finderpb --go_opt=paths=source_relative --go-grpc_out=finderpb --go-grpc_opt=paths=source_relative finder.proto
+
+import (
+	"crypto/subtle"
+	"errors"
+	"net"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"business-finder/finderpb"
+)
+
+// grpcFinder serves the Finder gRPC service of serve --grpc-listen, sharing the runner with the
+// schedule and the REST API so runs never overlap
+type grpcFinder struct {
+	finderpb.UnimplementedFinderServer
+	runner *runner
+}
+
+// newGRPCServer creates the gRPC server. When BUSINESS_FINDER_API_TOKEN is set, calls must carry
+// it as a bearer token in their authorization metadata, as REST requests do.
+func newGRPCServer(runner *runner, token string) *grpc.Server {
+	server := grpc.NewServer(grpc.StreamInterceptor(func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if token != "" {
+			md, _ := metadata.FromIncomingContext(ss.Context())
+			values := md.Get("authorization")
+			if len(values) == 0 || subtle.ConstantTimeCompare([]byte(strings.TrimPrefix(values[0], "Bearer ")), []byte(token)) != 1 {
+				return status.Error(codes.Unauthenticated, "missing or wrong bearer token")
+			}
+		}
+		return handler(srv, ss)
+	}))
+	finderpb.RegisterFinderServer(server, &grpcFinder{runner: runner})
+	return server
+}
+
+// serveGRPC serves the gRPC service on addr until the server stops
+func serveGRPC(server *grpc.Server, addr string) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	return server.Serve(listener)
+}
+
+// Search runs a search and streams its new leads, then its summary. The run stops if the caller
+// goes away.
+func (gf *grpcFinder) Search(req *finderpb.SearchRequest, stream finderpb.Finder_SearchServer) error {
+	var areas []*SearchArea
+	for _, name := range req.Areas {
+		area := gf.runner.area(name)
+		if area == nil {
+			return status.Errorf(codes.InvalidArgument, "unknown area %q", name)
+		}
+		areas = append(areas, area)
+	}
+	run, err := gf.runner.begin(areas, "grpc")
+	if errors.Is(err, errRunInProgress) {
+		return status.Error(codes.Unavailable, err.Error())
+	}
+
+	// Leads are sent from this goroutine, as gRPC streams can't be sent on concurrently
+	leads := make(chan *finderpb.Lead, 64)
+	var digest *RunDigest
+	go func() {
+		defer close(leads)
+		digest = gf.runner.execute(stream.Context(), run, func(b *Business) { leads <- leadProto(b) })
+	}()
+	var sendErr error
+	for lead := range leads {
+		if sendErr == nil {
+			sendErr = stream.Send(&finderpb.SearchEvent{Event: &finderpb.SearchEvent_Lead{Lead: lead}})
+		}
+	}
+	if sendErr != nil {
+		return sendErr
+	}
+
+	finished := gf.runner.status(run.ID)
+	if finished.Error != "" {
+		return status.Error(codes.Internal, finished.Error)
+	}
+	totals := progress.Snapshot()
+	summary := &finderpb.RunSummary{
+		Found:    int32(totals.Found),
+		Inserted: int32(totals.Inserted),
+		Failed:   int32(totals.Failed),
+		Skipped:  int32(totals.Skipped),
+		Merged:   int32(totals.Merged),
+		Spend:    totals.Spend,
+		Text:     finished.Summary,
+	}
+	if digest != nil {
+		summary.Failures = digest.Failures
+	}
+	return stream.Send(&finderpb.SearchEvent{Event: &finderpb.SearchEvent_Summary{Summary: summary}})
+}
+
+// ListLeads streams the leads in Notion matching the filter
+func (gf *grpcFinder) ListLeads(req *finderpb.ListLeadsRequest, stream finderpb.Finder_ListLeadsServer) error {
+	filter, err := ParseFilter(req.Where)
+	if err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	sent := 0
+	err = gf.runner.run.notionClient.EachBusiness(stream.Context(), nil, func(b Business) error {
+		if !filter.Matches(b) {
+			return nil
+		}
+		if err := stream.Send(leadProto(&b)); err != nil {
+			return err
+		}
+		if sent++; req.Limit > 0 && sent >= int(req.Limit) {
+			return errEnoughLeads
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errEnoughLeads) {
+		return status.Errorf(codes.Unavailable, "listing leads: %v", err)
+	}
+	return nil
+}
+
+// leadProto converts a lead to its gRPC message
+func leadProto(b *Business) *finderpb.Lead {
+	return &finderpb.Lead{
+		PlaceId:       b.PlaceID,
+		LeadNumber:    b.LeadNumber,
+		Name:          b.Name,
+		Address:       b.Address,
+		Types:         b.Type,
+		WebsiteStatus: b.WebsiteStatus,
+		Url:           b.URL,
+		Phone:         b.Phone,
+		Email:         b.Email,
+		Urgency:       b.Urgency,
+		LeadScore:     int32(b.LeadScore),
+		Rating:        b.Rating,
+		ReviewCount:   int32(b.ReviewCount),
+		NotionUrl:     b.PageURL,
+		Lat:           b.Location.Lat,
+		Lng:           b.Location.Lng,
+		Fields:        templateData(*b),
+	}
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultHistoryPath is where metric snapshots are kept unless --history-file says otherwise
+	defaultHistoryPath = ".business-finder-history.json"
+	// trendWindow is how far back trends look; the oldest snapshot within it is the baseline
+	trendWindow = 90 * 24 * time.Hour
+	// minTrendSpan is the shortest span between snapshots that counts as a trend
+	minTrendSpan = 7 * 24 * time.Hour
+	// historyRetention is how long snapshots are kept
+	historyRetention = 2 * 365 * 24 * time.Hour
+)
+
+// metricSnapshot is what a listing showed on one day
+type metricSnapshot struct {
+	Date          time.Time `json:"date"`
+	Rating        float64   `json:"rating,omitempty"`
+	ReviewCount   int       `json:"review_count,omitempty"`
+	WebsiteStatus string    `json:"website_status,omitempty"`
+}
+
+// History keeps daily snapshots of each listing's rating, review count and website status, so
+// scoring and reports can use how a business is changing rather than only where it stands
+type History struct {
+	path string
+
+	mu        sync.Mutex
+	snapshots map[string][]metricSnapshot
+	changed   bool
+}
+
+// LoadHistory reads the history file at path; a missing file is an empty history
+func LoadHistory(path string) (*History, error) {
+	h := &History{path: path, snapshots: make(map[string][]metricSnapshot)}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return h, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &h.snapshots); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	return h, nil
+}
+
+// Record snapshots a listing's metrics at a time, replacing any snapshot from earlier the same day
+// and dropping ones past the retention period
+func (h *History) Record(b *Business, at time.Time) {
+	if b.PlaceID == "" {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	snapshot := metricSnapshot{Date: startOfDayUTC(at), ReviewCount: b.ReviewCount, WebsiteStatus: b.WebsiteStatus}
+	if b.ReviewCount > 0 {
+		snapshot.Rating = b.Rating
+	}
+
+	kept := h.snapshots[b.PlaceID][:0]
+	for _, s := range h.snapshots[b.PlaceID] {
+		if at.Sub(s.Date) <= historyRetention && !s.Date.Equal(snapshot.Date) {
+			kept = append(kept, s)
+		}
+	}
+	h.snapshots[b.PlaceID] = append(kept, snapshot)
+	h.changed = true
+}
+
+// Apply sets a business's trend fields from its snapshots up to now
+func (h *History) Apply(b *Business, now time.Time) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	b.RatingChange, b.ReviewGrowth, b.Trend = 0, 0, ""
+
+	snapshots := h.snapshots[b.PlaceID]
+	if len(snapshots) < 2 {
+		return
+	}
+	latest := snapshots[len(snapshots)-1]
+	var baseline *metricSnapshot
+	for i := range snapshots[:len(snapshots)-1] {
+		if now.Sub(snapshots[i].Date) <= trendWindow {
+			baseline = &snapshots[i]
+			break
+		}
+	}
+	if baseline == nil {
+		return
+	}
+	span := latest.Date.Sub(baseline.Date)
+	if span < minTrendSpan {
+		return
+	}
+
+	var parts []string
+	if baseline.ReviewCount > 0 && latest.ReviewCount > 0 {
+		b.RatingChange = math.Round((latest.Rating-baseline.Rating)*10) / 10
+		switch {
+		case b.RatingChange < 0:
+			parts = append(parts, fmt.Sprintf("rating dropped %.1f", -b.RatingChange))
+		case b.RatingChange > 0:
+			parts = append(parts, fmt.Sprintf("rating rose %.1f", b.RatingChange))
+		}
+	}
+	if b.ReviewGrowth = latest.ReviewCount - baseline.ReviewCount; b.ReviewGrowth != 0 {
+		parts = append(parts, fmt.Sprintf("%+d reviews", b.ReviewGrowth))
+	}
+	if baseline.WebsiteStatus != "" && latest.WebsiteStatus != baseline.WebsiteStatus {
+		parts = append(parts, fmt.Sprintf("website went from %s to %s", baseline.WebsiteStatus, latest.WebsiteStatus))
+	}
+	if len(parts) > 0 {
+		b.Trend = strings.Join(parts, ", ") + " in " + formatSpan(span)
+	}
+}
+
+// formatSpan renders a duration in the largest whole unit that reads naturally, e.g. "3 months"
+func formatSpan(d time.Duration) string {
+	days := int(d.Hours() / 24)
+	unit, n := "day", days
+	switch {
+	case days >= 60:
+		unit, n = "month", int(math.Round(float64(days)/30))
+	case days >= 14:
+		unit, n = "week", days/7
+	}
+	if n != 1 {
+		unit += "s"
+	}
+	return fmt.Sprintf("%d %s", n, unit)
+}
+
+// Save writes the history if snapshots were recorded since it was loaded
+func (h *History) Save() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if !h.changed {
+		return nil
+	}
+	data, err := json.Marshal(h.snapshots)
+	if err != nil {
+		return err
+	}
+	err = replaceFile(h.path, func(f *os.File) error {
+		_, err := f.Write(data)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	h.changed = false
+	return nil
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os/exec"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+)
+
+// hookReplyLimit caps how much of a hook's reply is read
+const hookReplyLimit = 1 << 20
+
+// hookProtectedFields identify a lead and its records; hooks can't change them
+var hookProtectedFields = []string{"PlaceID", "PageID", "PageURL", "Created", "LeadNumber", "Sources", "FirstSource", "Provenance", "Contacts"}
+
+// HookConfig is a program or HTTP endpoint every new lead is passed to, for enrichment the
+// built-in enrichers don't do. It gets the lead as JSON and replies with a JSON object of the
+// fields to set, named as in the lead, e.g. {"Email": "info@example.com", "LeadScore": 80}; an
+// empty reply changes nothing.
+type HookConfig struct {
+	// Name identifies the hook in the enrichers list and logs, and as the source of the fields it sets
+	Name string `json:"name"`
+	// Command is run with the lead on stdin and replies on stdout, e.g. ["python3", "vat.py"]
+	Command []string `json:"command,omitempty"`
+	// URL is posted the lead instead, and replies in the response body
+	URL string `json:"url,omitempty"`
+	// TimeoutSeconds bounds each call; --request-timeout when 0
+	TimeoutSeconds int `json:"timeout_seconds,omitempty"`
+}
+
+// Validate checks that the hook has a name no built-in enricher uses, and one way to call it
+func (hc HookConfig) Validate() error {
+	if hc.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	if _, ok := enricherFactories[hc.Name]; ok {
+		return fmt.Errorf("%q is the name of a built-in enricher", hc.Name)
+	}
+	if (len(hc.Command) == 0) == (hc.URL == "") {
+		return fmt.Errorf("hook %q needs either a command or a url", hc.Name)
+	}
+	if hc.TimeoutSeconds < 0 {
+		return fmt.Errorf("hook %q: timeout_seconds can't be negative", hc.Name)
+	}
+	return nil
+}
+
+// Hook is an Enricher calling an external program or HTTP endpoint
+type Hook struct {
+	config HookConfig
+	client *http.Client
+}
+
+// NewHook initializes a new Hook
+func NewHook(config HookConfig) *Hook {
+	return &Hook{config: config, client: &http.Client{}}
+}
+
+func (h *Hook) Name() string { return h.config.Name }
+
+// Enrich passes the lead to the hook and merges the fields of its reply into the lead
+func (h *Hook) Enrich(ctx context.Context, b *Business) error {
+	input, err := json.Marshal(b)
+	if err != nil {
+		return err
+	}
+	timeout := requestTimeout
+	if h.config.TimeoutSeconds > 0 {
+		timeout = time.Duration(h.config.TimeoutSeconds) * time.Second
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var reply []byte
+	if len(h.config.Command) > 0 {
+		reply, err = h.run(ctx, input)
+	} else {
+		reply, err = h.post(ctx, input)
+	}
+	if err != nil {
+		return err
+	}
+	return h.merge(b, reply)
+}
+
+// run runs the hook's command with the lead on stdin, returning what it wrote to stdout
+func (h *Hook) run(ctx context.Context, input []byte) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, h.config.Command[0], h.config.Command[1:]...)
+	cmd.Stdin = bytes.NewReader(input)
+	stdout := &limitedBuffer{limit: hookReplyLimit}
+	var stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("%w: %s", err, msg)
+		}
+		return nil, err
+	}
+	return stdout.Bytes(), nil
+}
+
+// limitedBuffer keeps the first limit bytes written to it and discards the rest, so a command
+// writing without end neither blocks nor fills memory. The buffer isn't embedded, as its
+// ReadFrom would let io.Copy bypass the limit.
+type limitedBuffer struct {
+	buf   bytes.Buffer
+	limit int
+}
+
+func (lb *limitedBuffer) Write(p []byte) (int, error) {
+	if room := lb.limit - lb.buf.Len(); room > 0 {
+		lb.buf.Write(p[:min(len(p), room)])
+	}
+	return len(p), nil
+}
+
+// Bytes returns what was kept
+func (lb *limitedBuffer) Bytes() []byte { return lb.buf.Bytes() }
+
+// post posts the lead to the hook's URL, returning the response body
+func (h *Hook) post(ctx context.Context, input []byte) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.config.URL, bytes.NewReader(input))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, hookReplyLimit))
+}
+
+// merge sets the fields of a hook's reply on the lead, recording the hook as their source. A
+// reply naming a protected or unknown field changes nothing.
+func (h *Hook) merge(b *Business, reply []byte) error {
+	reply = bytes.TrimSpace(reply)
+	if len(reply) == 0 {
+		return nil
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(reply, &fields); err != nil {
+		return fmt.Errorf("reading reply: %w", err)
+	}
+	for field := range fields {
+		if slices.ContainsFunc(hookProtectedFields, func(protected string) bool { return strings.EqualFold(field, protected) }) {
+			return fmt.Errorf("reply sets %s, which hooks can't change", field)
+		}
+	}
+	updated := *b
+	dec := json.NewDecoder(bytes.NewReader(reply))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&updated); err != nil {
+		return fmt.Errorf("reading reply: %w", err)
+	}
+	if len(fields) > 0 && updated.Provenance == nil {
+		updated.Provenance = make(map[string]string)
+	}
+	for field := range fields {
+		updated.Provenance[businessFieldName(field)] = h.config.Name
+	}
+	*b = updated
+	return nil
+}
+
+// businessFieldName returns the Business field a reply's key sets, which JSON matches ignoring
+// case, so provenance is recorded under the field's own name.
+func businessFieldName(key string) string {
+	field, ok := reflect.TypeFor[Business]().FieldByNameFunc(func(name string) bool { return strings.EqualFold(name, key) })
+	if !ok {
+		return key
+	}
+	return field.Name
+}
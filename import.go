@@ -0,0 +1,124 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"log/slog"
+	"path/filepath"
+	"strings"
+
+	"googlemaps.github.io/maps"
+)
+
+// importSourceName is the source recorded for imported leads
+const importSourceName = "import"
+
+// findPlaceFields are asked of Find Place: enough to build a search result, all on its basic tier
+var findPlaceFields = []maps.PlaceSearchFieldMask{
+	maps.PlaceSearchFieldMaskPlaceID, maps.PlaceSearchFieldMaskName, maps.PlaceSearchFieldMaskFormattedAddress,
+	maps.PlaceSearchFieldMaskTypes, maps.PlaceSearchFieldMaskGeometryLocation, maps.PlaceSearchFieldMaskBusinessStatus,
+}
+
+// ImportSource lists the businesses of a CSV, such as one collected at a trade show, resolving each
+// to its Google place with Find Place and fetching its details like the Google source does
+type ImportSource struct {
+	google *GoogleSource
+	seeds  []baselineBusiness
+}
+
+// NewImportSource initializes an ImportSource listing the seed businesses through a Google source
+func NewImportSource(google *GoogleSource, seeds []baselineBusiness) *ImportSource {
+	return &ImportSource{google: google, seeds: seeds}
+}
+
+func (is *ImportSource) Name() string { return importSourceName }
+
+// Search resolves every seed business and passes on the ones found. Seeds Google can't place are
+// logged and skipped.
+func (is *ImportSource) Search(ctx context.Context, area *SearchArea, fn func(*Business)) error {
+	progress.Printf("Importing %d businesses\n", len(is.seeds))
+	unresolved := 0
+	for _, seed := range is.seeds {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		query := seedQuery(seed)
+		req := &maps.FindPlaceFromTextRequest{
+			Input:     query,
+			InputType: maps.FindPlaceFromTextInputTypeTextQuery,
+			Fields:    findPlaceFields,
+		}
+		res, err := is.google.places.FindPlaceFromText(ctx, req)
+		if errors.Is(err, errBudgetExhausted) {
+			return err
+		}
+		if err != nil {
+			slog.Error("Failed to find place", "operation", "import", "name", seed.Name, "query", query, "err", err)
+			unresolved++
+			continue
+		}
+		if len(res.Candidates) == 0 {
+			slog.Warn("No place found for imported business", "operation", "import", "name", seed.Name, "query", query)
+			unresolved++
+			continue
+		}
+		place := res.Candidates[0]
+		business, err := is.google.business(ctx, area, place)
+		if err != nil {
+			return err
+		}
+		if business == nil {
+			unresolved++
+			continue
+		}
+		business.SearchKeyword = query
+		fn(business)
+	}
+	if unresolved > 0 {
+		progress.Printf("%d of %d imported businesses couldn't be found on Google\n", unresolved, len(is.seeds))
+	}
+	return nil
+}
+
+// seedQuery is the Find Place query for a seed business: its name and whatever of its address
+// the CSV has
+func seedQuery(seed baselineBusiness) string {
+	parts := []string{seed.Name}
+	if seed.Address != "" {
+		parts = append(parts, seed.Address)
+	}
+	if seed.Postcode != "" && !strings.Contains(strings.ToUpper(seed.Address), seed.Postcode) {
+		parts = append(parts, seed.Postcode)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// importArea parses the import command's arguments into the area imported leads are recorded
+// under, and the businesses to import
+func importArea(args []string) (*SearchArea, []baselineBusiness) {
+	fs := flag.NewFlagSet("import", flag.ExitOnError)
+	path := fs.String("file", "", "CSV of businesses with a name column and optional address/postcode columns")
+	name := fs.String("area", "", "search area to record the imported leads under (default the file's name)")
+	fs.Parse(args)
+
+	if *path == "" && fs.NArg() > 0 {
+		*path = fs.Arg(0)
+	}
+	if *path == "" {
+		log.Fatal("import: --file is required")
+	}
+	seeds, err := readBaseline(*path)
+	if err != nil {
+		log.Fatalf("Failed to read businesses to import: %v", err)
+	}
+	if len(seeds) == 0 {
+		log.Fatalf("import: %s lists no businesses", *path)
+	}
+	if *name == "" {
+		*name = strings.TrimSuffix(filepath.Base(*path), filepath.Ext(*path))
+	}
+	return &SearchArea{Name: *name, Description: fmt.Sprintf("Imported from %s", *path)}, seeds
+}
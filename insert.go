@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"sync"
+
+	"golang.org/x/time/rate"
+)
+
+const (
+	// insertWorkers is how many leads are written at once; Notion's rate limit, not the workers,
+	// sets the pace, but requests overlap their round trips instead of queueing behind each other
+	insertWorkers = 4
+	// insertBatch is how many queued leads are checked against the sinks in one request
+	insertBatch = 25
+	// insertQueue is how many enriched leads can wait to be written before searching waits too
+	insertQueue = 200
+)
+
+// ExistenceChecker is implemented by sinks that can tell which of many leads they already hold in
+// one request, so the leads don't each cost a lookup of their own
+type ExistenceChecker interface {
+	Existing(ctx context.Context, leads []*Business) (map[string]bool, error)
+}
+
+// concurrentWriter is implemented by sinks whose Write may be called from several goroutines at
+// once; the others are written one lead at a time
+type concurrentWriter interface {
+	concurrentWrites()
+}
+
+// insertJob is an enriched lead waiting to be written, with the issues found enriching it
+type insertJob struct {
+	lead   *Business
+	source string
+	issues QualityReport
+	// done is closed once the lead was written, or found to be known; written says which
+	done    chan struct{}
+	written bool
+}
+
+// insertPipeline writes the leads a Finder enriches to its sinks in the background, in batches
+// and with several workers, so searching isn't held up by one page insert after another
+type insertPipeline struct {
+	f    *Finder
+	jobs chan *insertJob
+	wg   sync.WaitGroup
+}
+
+// newInsertPipeline starts the workers writing a Finder's leads
+func newInsertPipeline(ctx context.Context, f *Finder) *insertPipeline {
+	ip := &insertPipeline{f: f, jobs: make(chan *insertJob, insertQueue)}
+	for range insertWorkers {
+		ip.wg.Add(1)
+		go ip.work(ctx)
+	}
+	return ip
+}
+
+// Add queues a lead, waiting while the queue is full
+func (ip *insertPipeline) Add(job *insertJob) {
+	ip.jobs <- job
+}
+
+// Close waits for every queued lead to be written and stops the workers
+func (ip *insertPipeline) Close() {
+	close(ip.jobs)
+	ip.wg.Wait()
+}
+
+// work writes batches of whatever leads are queued, checking first which the sinks already hold
+func (ip *insertPipeline) work(ctx context.Context) {
+	defer ip.wg.Done()
+	for job := range ip.jobs {
+		batch := []*insertJob{job}
+	fill:
+		for len(batch) < insertBatch {
+			select {
+			case next, ok := <-ip.jobs:
+				if !ok {
+					break fill
+				}
+				batch = append(batch, next)
+			default:
+				break fill
+			}
+		}
+		known := ip.f.existing(ctx, batch)
+		for _, job := range batch {
+			ip.f.finish(ctx, job, known[job.lead.PlaceID])
+		}
+	}
+}
+
+// existing asks the first sink that can check many leads at once which of a batch it holds. On
+// failure the batch is written as usual, each sink's Write checking its lead itself.
+func (f *Finder) existing(ctx context.Context, batch []*insertJob) map[string]bool {
+	for _, sink := range f.sinks {
+		checker, ok := sink.(ExistenceChecker)
+		if !ok {
+			continue
+		}
+		leads := make([]*Business, len(batch))
+		for i, job := range batch {
+			leads[i] = job.lead
+		}
+		known, err := checker.Existing(ctx, leads)
+		if err != nil {
+			slog.Warn("Failed to check leads in a batch", "operation", "write", "sink", sink.Name(), "leads", len(leads), "err", err)
+			return nil
+		}
+		return known
+	}
+	return nil
+}
+
+// limitedTransport holds requests to the pace of a rate limiter, shared by every goroutine using
+// the client
+type limitedTransport struct {
+	base    http.RoundTripper
+	limiter *rate.Limiter
+}
+
+func (lt *limitedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := lt.limiter.Wait(req.Context()); err != nil {
+		return nil, err
+	}
+	return lt.base.RoundTrip(req)
+}
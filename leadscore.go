@@ -0,0 +1,145 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/jomei/notionapi"
+)
+
+// ScoreWeights are the points each signal contributes to a 0–100 lead score
+type ScoreWeights struct {
+	NoWebsite     float64 `json:"no_website"`
+	BrokenWebsite float64 `json:"broken_website"`
+	NoSSL         float64 `json:"no_ssl"`
+	// Reviews is awarded in full at 100+ reviews, scaling logarithmically below that
+	Reviews float64 `json:"reviews"`
+	// Rating is awarded in full at 5.0 stars, scaling linearly from 3.0
+	Rating    float64 `json:"rating"`
+	NoHours   float64 `json:"no_hours"`
+	NoEmail   float64 `json:"no_email"`
+	NoSocials float64 `json:"no_socials"`
+	// RatingDrop is awarded in full when the rating fell 0.5 stars or more over the trend window,
+	// since a business losing customers' goodwill is more open to a pitch
+	RatingDrop float64 `json:"rating_drop"`
+	// ReviewGrowth is awarded in full at 20+ new reviews over the trend window, for businesses
+	// that are growing
+	ReviewGrowth float64 `json:"review_growth"`
+	// Categories adds (or with negative values subtracts) points per Google place type
+	Categories map[string]float64 `json:"categories,omitempty"`
+}
+
+// defaultScoreWeights favour established businesses without a working web presence
+func defaultScoreWeights() ScoreWeights {
+	return ScoreWeights{
+		NoWebsite:     50,
+		BrokenWebsite: 45,
+		NoSSL:         15,
+		Reviews:       20,
+		Rating:        10,
+		NoHours:       5,
+		NoEmail:       5,
+		NoSocials:     5,
+		RatingDrop:    10,
+		ReviewGrowth:  5,
+	}
+}
+
+// scoreComponent is one signal's contribution to a lead score
+type scoreComponent struct {
+	Points float64
+	Reason string
+}
+
+// ScoreLead computes a business's 0–100 lead score and the signals that produced it
+func ScoreLead(w ScoreWeights, b *Business) (int, []scoreComponent) {
+	var components []scoreComponent
+	add := func(points float64, reason string) {
+		if points != 0 {
+			components = append(components, scoreComponent{Points: points, Reason: reason})
+		}
+	}
+
+	switch websiteState(b) {
+	case websiteNone:
+		add(w.NoWebsite, "no website")
+	case websiteBroken:
+		add(w.BrokenWebsite, "broken website")
+	case websitePresent:
+		if !b.HTTPS {
+			add(w.NoSSL, "website without SSL")
+		}
+		if b.Email == "" {
+			add(w.NoEmail, "no email on website")
+		}
+	}
+
+	if b.ReviewCount > 0 {
+		scale := math.Min(1, math.Log10(float64(b.ReviewCount)+1)/2)
+		add(math.Round(w.Reviews*scale), fmt.Sprintf("%d reviews", b.ReviewCount))
+		if b.Rating > 3 {
+			add(math.Round(w.Rating*math.Min(1, (b.Rating-3)/2)), fmt.Sprintf("rating %.1f", b.Rating))
+		}
+	}
+	if b.RatingChange < 0 {
+		add(math.Round(w.RatingDrop*math.Min(1, -b.RatingChange/0.5)), fmt.Sprintf("rating dropped %.1f", -b.RatingChange))
+	}
+	if b.ReviewGrowth > 0 {
+		add(math.Round(w.ReviewGrowth*math.Min(1, float64(b.ReviewGrowth)/20)), fmt.Sprintf("%d new reviews", b.ReviewGrowth))
+	}
+	if !b.HoursListed {
+		add(w.NoHours, "no opening hours listed")
+	}
+	if b.Facebook == "" && b.Instagram == "" && b.LinkedIn == "" && b.X == "" {
+		add(w.NoSocials, "no social profiles")
+	}
+	for _, t := range b.Type {
+		if points, ok := w.Categories[t]; ok {
+			add(points, "category "+t)
+		}
+	}
+
+	total := 0.0
+	for _, c := range components {
+		total += c.Points
+	}
+	return int(math.Round(math.Max(0, math.Min(100, total)))), components
+}
+
+// explainScore renders score components as e.g. "+50 no website, +15 120 reviews, +10 rating 3.9"
+func explainScore(components []scoreComponent) string {
+	parts := make([]string, len(components))
+	for i, c := range components {
+		parts[i] = fmt.Sprintf("%+g %s", c.Points, c.Reason)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// scoreBlocks explain a lead score in a page body: a heading with the score, then one bullet
+// per signal
+func scoreBlocks(heading string, components []scoreComponent) []notionapi.Block {
+	blocks := []notionapi.Block{notionapi.Heading3Block{
+		BasicBlock: notionapi.BasicBlock{Object: notionapi.ObjectTypeBlock, Type: notionapi.BlockTypeHeading3},
+		Heading3:   notionapi.Heading{RichText: richText(heading)},
+	}}
+	for _, c := range components {
+		blocks = append(blocks, notionapi.BulletedListItemBlock{
+			BasicBlock:       notionapi.BasicBlock{Object: notionapi.ObjectTypeBlock, Type: notionapi.BlockTypeBulletedListItem},
+			BulletedListItem: notionapi.ListItem{RichText: richText(fmt.Sprintf("%+g %s", c.Points, c.Reason))},
+		})
+	}
+	return blocks
+}
+
+// AppendScoreExplanation adds a dated explanation of a new score to a lead's page, below the
+// earlier ones, so the page keeps the history of its scores
+func (nc *NotionClient) AppendScoreExplanation(ctx context.Context, pageID string, score int, components []scoreComponent) error {
+	heading := fmt.Sprintf("Lead score on %s: %d", time.Now().Format(time.DateOnly), score)
+	_, err := nc.client.Block.AppendChildren(ctx, notionapi.BlockID(pageID), &notionapi.AppendBlockChildrenRequest{
+		Children: scoreBlocks(heading, components),
+	})
+	return err
+}
@@ -0,0 +1,259 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// defaultChainLocations is how many places one name may be listed at in a run before it is taken
+// to be a chain
+const defaultChainLocations = 3
+
+const (
+	// statusOperational is the business status of listings that are open; listings with any
+	// other status are dropped unless closed ones are included
+	statusOperational = "OPERATIONAL"
+	// statusClosedPermanently is the business status of listings closed for good
+	statusClosedPermanently = "CLOSED_PERMANENTLY"
+)
+
+// knownChains are chains and franchises that never buy a website from a local agency, dropped
+// unless chains are kept
+var knownChains = []string{
+	"Starbucks", "Costa Coffee", "Caffè Nero", "Pret A Manger", "Greggs", "McDonald's", "Burger King",
+	"KFC", "Subway", "Domino's", "Pizza Hut", "Nando's", "Wetherspoon", "Tesco", "Sainsbury's", "Asda",
+	"Morrisons", "Aldi", "Lidl", "Waitrose", "Marks & Spencer", "Boots", "Superdrug", "Specsavers",
+	"Timpson", "WHSmith", "Halfords", "Screwfix", "Toolstation", "Argos", "Currys", "Travelodge",
+	"Premier Inn", "Holiday Inn", "Barclays", "Lloyds Bank", "HSBC", "NatWest", "Santander",
+}
+
+// Filters configure which listings are dropped before they are enriched and written
+type Filters struct {
+	// Chains are names of chains and franchises to drop besides the known ones, matched as whole
+	// words of a listing's name in any case, so "Tesco" drops "Tesco Express"
+	Chains []string `json:"chains,omitempty"`
+	// ChainLocations is how many places one name may be listed at by a source in a run before
+	// the rest of its listings are dropped as a chain's; 0 uses the default of 3
+	ChainLocations int `json:"chain_locations,omitempty"`
+	// MinRating and MaxRating drop listings rated outside them, out of 5; a 0 MaxRating sets no
+	// maximum. Listings without reviews have no rating to judge and are only dropped by MinReviews.
+	MinRating float64 `json:"min_rating,omitempty"`
+	MaxRating float64 `json:"max_rating,omitempty"`
+	// MinReviews drops listings with fewer reviews, such as brand-new or dormant ones
+	MinReviews int `json:"min_reviews,omitempty"`
+	// IncludeClosed keeps listings of businesses closed temporarily or for good, which are
+	// otherwise dropped; listings whose source doesn't say are always kept
+	IncludeClosed bool `json:"include_closed,omitempty"`
+	// Keep is an expression listings must satisfy to be written, e.g.
+	// `rating >= 4.0 && reviews > 25 && website == ""`. It is checked before a listing is
+	// enriched, so its email and SSL aren't known yet.
+	Keep string `json:"keep,omitempty"`
+}
+
+// unratedSources are the sources whose listings carry no ratings or reviews, or were picked by
+// hand, so the rating filters don't drop them; they still merge into rated listings of the same place
+var unratedSources = map[string]bool{"osm": true, importSourceName: true}
+
+// Validate checks the filters for impossible settings
+func (f Filters) Validate() error {
+	if f.ChainLocations < 0 {
+		return fmt.Errorf("chain_locations must not be negative")
+	}
+	if f.MinRating < 0 || f.MinRating > 5 || f.MaxRating < 0 || f.MaxRating > 5 {
+		return fmt.Errorf("ratings must be between 0 and 5")
+	}
+	if f.MaxRating > 0 && f.MinRating > f.MaxRating {
+		return fmt.Errorf("min_rating %.1f is above max_rating %.1f", f.MinRating, f.MaxRating)
+	}
+	if f.MinReviews < 0 {
+		return fmt.Errorf("min_reviews must not be negative")
+	}
+	for _, chain := range f.Chains {
+		if normalizeName(chain) == "" {
+			return fmt.Errorf("chain %q has no name to match", chain)
+		}
+	}
+	if f.Keep != "" {
+		if _, err := compileExpression(f.Keep); err != nil {
+			return fmt.Errorf("keep: %w", err)
+		}
+	}
+	return nil
+}
+
+// ListingFilter drops listings that aren't worth turning into leads, counting what it dropped
+// and why. The Google source asks it before fetching a place's details, from the search result
+// alone, and the Finder again once any source's listing is complete.
+type ListingFilter struct {
+	// chains are the normalized chain names, or nil when chains are kept
+	chains         []string
+	chainLocations int
+	minRating      float64
+	maxRating      float64
+	minReviews     int
+	includeClosed  bool
+	// keep is the expression complete listings must satisfy, or ""
+	keep string
+
+	mu sync.Mutex
+	// locations are the places each name was listed at this run, keyed by source and normalized
+	// name, with whether each was kept
+	locations map[string]map[string]bool
+	dropped   map[string]int
+}
+
+// NewListingFilter initializes a ListingFilter; with keepChains, chains aren't dropped at all
+func NewListingFilter(filters Filters, keepChains bool) *ListingFilter {
+	lf := &ListingFilter{
+		chainLocations: filters.ChainLocations,
+		minRating:      filters.MinRating,
+		maxRating:      filters.MaxRating,
+		minReviews:     filters.MinReviews,
+		includeClosed:  filters.IncludeClosed,
+		keep:           filters.Keep,
+	}
+	if lf.chainLocations == 0 {
+		lf.chainLocations = defaultChainLocations
+	}
+	if !keepChains {
+		for _, chain := range slices.Concat(knownChains, filters.Chains) {
+			lf.chains = append(lf.chains, normalizeName(chain))
+		}
+	}
+	lf.Reset()
+	return lf
+}
+
+// Reset forgets the names counted and listings dropped, for the next run
+func (lf *ListingFilter) Reset() {
+	if lf == nil {
+		return
+	}
+	lf.mu.Lock()
+	defer lf.mu.Unlock()
+	lf.locations = make(map[string]map[string]bool)
+	lf.dropped = make(map[string]int)
+}
+
+// Drop returns why a source's listing is dropped, or "" to keep it. A listing may be asked about
+// more than once; each place only counts once towards its name's locations.
+func (lf *ListingFilter) Drop(source string, b *Business) string {
+	return lf.drop(source, b, !unratedSources[source])
+}
+
+// DropComplete is Drop for a listing with everything its source says of it, which must also
+// satisfy the keep expression
+func (lf *ListingFilter) DropComplete(source string, b *Business) string {
+	reason := lf.Drop(source, b)
+	if reason != "" || lf == nil || lf.keep == "" || matchExpression(lf.keep, b) {
+		return reason
+	}
+	lf.mu.Lock()
+	defer lf.mu.Unlock()
+	lf.dropped["keep expression"]++
+	return "keep expression"
+}
+
+// DropUnrated is Drop for a listing whose ratings aren't known yet, leaving the rating filters
+// to the check once they are
+func (lf *ListingFilter) DropUnrated(source string, b *Business) string {
+	return lf.drop(source, b, false)
+}
+
+// RatesListings reports whether any rating filter is set
+func (lf *ListingFilter) RatesListings() bool {
+	return lf != nil && (lf.minRating > 0 || lf.maxRating > 0 || lf.minReviews > 0)
+}
+
+func (lf *ListingFilter) drop(source string, b *Business, rated bool) string {
+	if lf == nil {
+		return ""
+	}
+	lf.mu.Lock()
+	defer lf.mu.Unlock()
+	reason := lf.reason(b, rated)
+	if reason == "" {
+		reason = lf.chainReason(source, b)
+	}
+	if reason != "" {
+		lf.dropped[reason]++
+	}
+	return reason
+}
+
+// reason checks a listing against its business status, and the rating filters when its ratings
+// are known
+func (lf *ListingFilter) reason(b *Business, rated bool) string {
+	switch {
+	case !lf.includeClosed && b.BusinessStatus != "" && b.BusinessStatus != statusOperational:
+		return "closed"
+	case !rated:
+		return ""
+	case b.ReviewCount < lf.minReviews:
+		return "too few reviews"
+	case b.ReviewCount > 0 && b.Rating < lf.minRating:
+		return "rating too low"
+	case b.ReviewCount > 0 && lf.maxRating > 0 && b.Rating > lf.maxRating:
+		return "rating too high"
+	}
+	return ""
+}
+
+// chainReason checks a listing's name against the chains, and how many places list it; lf.mu
+// must be held
+func (lf *ListingFilter) chainReason(source string, b *Business) string {
+	if lf.chains == nil {
+		return ""
+	}
+	name := normalizeName(b.Name)
+	for _, chain := range lf.chains {
+		if strings.Contains(" "+name+" ", " "+chain+" ") {
+			return "chain"
+		}
+	}
+	// The first listings of a chain get through, as it isn't known to be one until then; adding
+	// it to chains drops them all. Places that got through keep doing so when listed again.
+	key := source + "\x00" + name
+	places := lf.locations[key]
+	if places == nil {
+		places = make(map[string]bool)
+		lf.locations[key] = places
+	}
+	if _, ok := places[b.PlaceID]; ok {
+		if places[b.PlaceID] {
+			return ""
+		}
+		return "chain"
+	}
+	kept := len(places) < lf.chainLocations
+	places[b.PlaceID] = kept
+	if !kept {
+		return "chain"
+	}
+	return ""
+}
+
+// Summary reports how many listings each filter dropped this run, or "" if none
+func (lf *ListingFilter) Summary() string {
+	if lf == nil {
+		return ""
+	}
+	lf.mu.Lock()
+	defer lf.mu.Unlock()
+	if len(lf.dropped) == 0 {
+		return ""
+	}
+	reasons := make([]string, 0, len(lf.dropped))
+	for reason := range lf.dropped {
+		reasons = append(reasons, reason)
+	}
+	sort.Strings(reasons)
+	parts := make([]string, len(reasons))
+	for i, reason := range reasons {
+		parts[i] = fmt.Sprintf("%d %s", lf.dropped[reason], reason)
+	}
+	return fmt.Sprintf("Filtered out listings: %s\n", strings.Join(parts, ", "))
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+// logLevel is the level set with --log-level, kept so the terminal UI can log at it too
+var logLevel = new(slog.LevelVar)
+
+// setupLogging sends logs, including those of the log package, through slog at the given level,
+// as "text" or "json" lines
+func setupLogging(level, format string) error {
+	var lvl slog.Level
+	if err := lvl.UnmarshalText([]byte(level)); err != nil {
+		return fmt.Errorf("invalid log level %q, expected debug, info, warn or error", level)
+	}
+	logLevel.Set(lvl)
+	opts := &slog.HandlerOptions{Level: logLevel}
+	out := progress.LogWriter(os.Stderr)
+	var handler slog.Handler
+	switch strings.ToLower(format) {
+	case "text":
+		handler = slog.NewTextHandler(out, opts)
+	case "json":
+		handler = slog.NewJSONHandler(out, opts)
+	default:
+		return fmt.Errorf("invalid log format %q, expected text or json", format)
+	}
+	slog.SetDefault(slog.New(handler))
+	return nil
+}
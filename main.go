@@ -1,26 +1,128 @@
 package main
 
 import (
+	"cmp"
 	"context"
+	"errors"
+	"flag"
 	"fmt"
 	"github.com/joho/godotenv"
 	"github.com/jomei/notionapi"
+	"golang.org/x/time/rate"
 	"googlemaps.github.io/maps"
 	"log"
+	"log/slog"
+	"net/http"
 	"os"
+	"slices"
+	"strings"
+	"sync"
 	"time"
 )
 
 // Business represents a business entity
 type Business struct {
-	Name          string
-	Address       string
+	Name    string
+	Address string
+	// Street, City, Postcode and Country are the parts of the address, as the source split it,
+	// for filtering and grouping leads by town or postcode district
+	Street        string
+	City          string
+	Postcode      string
+	Country       string
 	PlaceID       string
 	Type          []string
 	WebsiteStatus string
 	Urgency       string
 	Contacted     string
 	URL           string
+	Email         string
+	// EmailConfidence is how sure the email is, from 0 to 100: scrapedEmailConfidence for one
+	// found on the site, less for one guessed from the domain's mail servers
+	EmailConfidence int
+	Phone           string
+	// ListedPhone is the phone number as the source listed it, before normalization
+	ListedPhone     string
+	SuggestedDomain string
+	// DomainRegistered and Registrar are looked up over RDAP for the website's domain; both very
+	// old, neglected domains and brand-new ones are outreach signals
+	DomainRegistered time.Time
+	Registrar        string
+	OpeningHours     string
+	HoursListed      bool
+	// UnclaimedSignals are the signs that nobody manages the business's Google profile, e.g. "no
+	// website, no hours"; with enough of them it is LikelyUnclaimed, a lead for setting one up
+	UnclaimedSignals []string
+	LikelyUnclaimed  bool
+	// BusinessStatus is whether the source lists the business as open: statusOperational, or
+	// closed temporarily or for good; empty when the source doesn't say
+	BusinessStatus string
+	Rating         float64
+	ReviewCount    int
+	ReviewThemes   string
+	HTTPS          bool
+	LeadScore      int
+	// Trend summarizes how the rating, review count and website status changed over the trend
+	// window of the local history, e.g. "rating dropped 0.4 in 3 months"; RatingChange and
+	// ReviewGrowth are its numbers
+	Trend        string
+	RatingChange float64
+	ReviewGrowth int
+	// ScoreBreakdown is set when the business is scored this run and explained on its page
+	ScoreBreakdown []scoreComponent
+	Photos         []PlacePhoto
+	// ReviewSnippets are quoted on the lead's page
+	ReviewSnippets []reviewSnippet
+	Facebook       string
+	Instagram      string
+	LinkedIn       string
+	X              string
+	LeadNumber     string
+	AssignedTo     string
+	AssignedDate   time.Time
+	// CadenceStep counts the outreach cadence steps done, the last on LastActionDate
+	CadenceStep    int
+	LastActionDate time.Time
+	NextAction     string
+	NextActionDate time.Time
+	// LastContacted is the day the lead was last reached out to, and NextFollowUp the day the next
+	// outreach step is due, empty once the cadence is done or stopped
+	LastContacted time.Time
+	NextFollowUp  time.Time
+	SearchArea    string
+	// Campaign, SearchedType, SearchKeyword and SearchCell record the search that found the
+	// business, so lead quality can be attributed to search strategies
+	Campaign      string
+	SearchedType  string
+	SearchKeyword string
+	SearchCell    string
+	// RunID identifies the search run that wrote the business, to audit or delete a run's leads
+	RunID string
+	// Sources are the providers that listed the business, starting with FirstSource, which found it
+	Sources     []string
+	FirstSource string
+	// Provenance names the source of each field a later source filled in or replaced
+	Provenance map[string]string
+	// Set from the Companies House register for UK businesses that are registered companies
+	CompanyNumber     string
+	CompanyStatus     string
+	IncorporationDate time.Time
+	RegisteredAddress string
+	// Location is where the source places the business; used to match listings across sources
+	Location maps.LatLng
+	// DistanceKm is how far the business is from the centre of its search area, for working
+	// leads in person; 0 when the source doesn't place it
+	DistanceKm float64
+	// DuplicateOf names the lead, by number or name, that this one looks like under another
+	// PlaceID, e.g. after the business was re-listed; empty unless flagged on insert
+	DuplicateOf string
+
+	// Set when the business was read back from Notion, or inserted into it
+	PageID  string
+	PageURL string
+	Created time.Time
+	// Contacts are the page IDs of the lead's records in the Contacts database
+	Contacts []string
 }
 
 // NotionClient handles interactions with the Notion API
@@ -28,28 +130,59 @@ type NotionClient struct {
 	client     *notionapi.Client
 	databaseID notionapi.DatabaseID
 	pageID     notionapi.PageID
+	// contactsID is the Contacts database that records are created in for scraped emails and
+	// phones, linked from the lead's Contacts relation; none when empty
+	contactsID notionapi.DatabaseID
+	options    *OptionLimiter
+	// routes send the leads matching them to other databases instead; see route
+	routes []notionRoute
+	// pages are the routed databases of the pages read or written, so updates reach them
+	pagesMu sync.Mutex
+	pages   map[string]*NotionClient
 }
 
-// NewNotionClient initializes a new NotionClient
+// notionTransports are the rate-limited transports of the Notion tokens in use, so clients of
+// databases shared by one integration are held to its rate limit together
+var (
+	notionTransportsMu sync.Mutex
+	notionTransports   = make(map[string]*limitedTransport)
+)
+
+// notionTransport returns the rate-limited transport of a Notion token
+func notionTransport(apiKey string) *limitedTransport {
+	notionTransportsMu.Lock()
+	defer notionTransportsMu.Unlock()
+	transport, ok := notionTransports[apiKey]
+	if !ok {
+		transport = &limitedTransport{base: http.DefaultTransport, limiter: rate.NewLimiter(notionRequestsPerSecond, notionRequestsPerSecond)}
+		notionTransports[apiKey] = transport
+	}
+	return transport
+}
+
+// NewNotionClient initializes a new NotionClient. Its requests are held to Notion's rate limit
+// however many goroutines, and clients with the same token, share it.
 func NewNotionClient(apiKey, databaseID string, pageID string) *NotionClient {
-	client := notionapi.NewClient(notionapi.Token(apiKey))
+	transport := notionTransport(apiKey)
+	client := notionapi.NewClient(notionapi.Token(apiKey), notionapi.WithHTTPClient(&http.Client{Transport: transport, Timeout: requestTimeout}))
 	return &NotionClient{
 		client:     client,
 		databaseID: notionapi.DatabaseID(databaseID),
 		pageID:     notionapi.PageID(pageID),
+		options:    NewOptionLimiter(maxSelectOptions),
 	}
 }
 
 // CheckDatabaseExists checks if the Notion database exists
-func (nc *NotionClient) CheckDatabaseExists() bool {
-	res, err := nc.client.Database.Get(context.Background(), nc.databaseID)
+func (nc *NotionClient) CheckDatabaseExists(ctx context.Context) bool {
+	res, err := nc.client.Database.Get(ctx, nc.databaseID)
 	fmt.Println(res)
 	return err == nil
 }
 
-// CreateDatabase creates a Notion database
-func (nc *NotionClient) CreateDatabase() error {
-	properties := notionapi.PropertyConfigs{
+// databaseProperties is the schema of the leads database: every property the finder writes
+func databaseProperties() notionapi.PropertyConfigs {
+	return notionapi.PropertyConfigs{
 		"Name": notionapi.TitlePropertyConfig{
 			Type: notionapi.PropertyConfigTypeTitle,
 		},
@@ -59,6 +192,31 @@ func (nc *NotionClient) CreateDatabase() error {
 		"PlaceID": notionapi.RichTextPropertyConfig{
 			Type: notionapi.PropertyConfigTypeRichText,
 		},
+		"Street": notionapi.RichTextPropertyConfig{
+			Type: notionapi.PropertyConfigTypeRichText,
+		},
+		"City": notionapi.SelectPropertyConfig{
+			Type: notionapi.PropertyConfigTypeSelect,
+		},
+		"Postcode": notionapi.RichTextPropertyConfig{
+			Type: notionapi.PropertyConfigTypeRichText,
+		},
+		"PostcodeDistrict": notionapi.SelectPropertyConfig{
+			Type: notionapi.PropertyConfigTypeSelect,
+		},
+		"Country": notionapi.SelectPropertyConfig{
+			Type: notionapi.PropertyConfigTypeSelect,
+		},
+		"BusinessStatus": notionapi.SelectPropertyConfig{
+			Type: notionapi.PropertyConfigTypeSelect,
+			Select: notionapi.Select{
+				Options: []notionapi.Option{
+					{Name: statusOperational},
+					{Name: "CLOSED_TEMPORARILY"},
+					{Name: statusClosedPermanently},
+				},
+			},
+		},
 		"Type": notionapi.MultiSelectPropertyConfig{
 			Type: notionapi.PropertyConfigTypeMultiSelect,
 			MultiSelect: notionapi.Select{
@@ -75,6 +233,8 @@ func (nc *NotionClient) CreateDatabase() error {
 				Options: []notionapi.Option{
 					{Name: "Has Website"},
 					{Name: "No Website"},
+					{Name: "Broken Website"},
+					{Name: "Unknown Website"},
 				},
 			},
 		},
@@ -93,29 +253,185 @@ func (nc *NotionClient) CreateDatabase() error {
 			Select: notionapi.Select{
 				Options: []notionapi.Option{
 					{Name: "Not Contacted"},
+					{Name: readyToContact},
 					{Name: "Contacted"},
+					{Name: followedUp},
+					{Name: replied},
+					{Name: doNotContact},
 				},
 			},
 		},
 		"URL": notionapi.URLPropertyConfig{
 			Type: notionapi.PropertyConfigTypeURL,
 		},
+		"Email": notionapi.EmailPropertyConfig{
+			Type: notionapi.PropertyConfigTypeEmail,
+		},
+		"OpeningHours": notionapi.RichTextPropertyConfig{
+			Type: notionapi.PropertyConfigTypeRichText,
+		},
+		"SSL": notionapi.CheckboxPropertyConfig{
+			Type: notionapi.PropertyConfigTypeCheckbox,
+		},
+		"HoursListed": notionapi.CheckboxPropertyConfig{
+			Type: notionapi.PropertyConfigTypeCheckbox,
+		},
+		"Rating": notionapi.NumberPropertyConfig{
+			Type:   notionapi.PropertyConfigTypeNumber,
+			Number: notionapi.NumberFormat{Format: notionapi.FormatNumber},
+		},
+		"ReviewCount": notionapi.NumberPropertyConfig{
+			Type:   notionapi.PropertyConfigTypeNumber,
+			Number: notionapi.NumberFormat{Format: notionapi.FormatNumber},
+		},
+		"Latitude": notionapi.NumberPropertyConfig{
+			Type: notionapi.PropertyConfigTypeNumber,
+		},
+		"Longitude": notionapi.NumberPropertyConfig{
+			Type: notionapi.PropertyConfigTypeNumber,
+		},
+		"DistanceKm": notionapi.NumberPropertyConfig{
+			Type:   notionapi.PropertyConfigTypeNumber,
+			Number: notionapi.NumberFormat{Format: notionapi.FormatNumber},
+		},
+		"EmailConfidence": notionapi.NumberPropertyConfig{
+			Type: notionapi.PropertyConfigTypeNumber,
+		},
+		"LeadScore": notionapi.NumberPropertyConfig{
+			Type:   notionapi.PropertyConfigTypeNumber,
+			Number: notionapi.NumberFormat{Format: notionapi.FormatNumber},
+		},
+		"ReviewThemes": notionapi.RichTextPropertyConfig{
+			Type: notionapi.PropertyConfigTypeRichText,
+		},
+		"SuggestedDomain": notionapi.RichTextPropertyConfig{
+			Type: notionapi.PropertyConfigTypeRichText,
+		},
+		"DomainRegistered": notionapi.DatePropertyConfig{
+			Type: notionapi.PropertyConfigTypeDate,
+		},
+		"Registrar": notionapi.RichTextPropertyConfig{
+			Type: notionapi.PropertyConfigTypeRichText,
+		},
+		// Domain Age is worked out by Notion, so it stays current without rewriting every lead
+		"Domain Age": notionapi.FormulaPropertyConfig{
+			Type:    notionapi.PropertyConfigTypeFormula,
+			Formula: notionapi.FormulaConfig{Expression: `dateBetween(now(), prop("DomainRegistered"), "years")`},
+		},
+		"Phone": notionapi.PhoneNumberPropertyConfig{
+			Type: notionapi.PropertyConfigTypePhoneNumber,
+		},
+		"Facebook": notionapi.URLPropertyConfig{
+			Type: notionapi.PropertyConfigTypeURL,
+		},
+		"Instagram": notionapi.URLPropertyConfig{
+			Type: notionapi.PropertyConfigTypeURL,
+		},
+		"LinkedIn": notionapi.URLPropertyConfig{
+			Type: notionapi.PropertyConfigTypeURL,
+		},
+		"X": notionapi.URLPropertyConfig{
+			Type: notionapi.PropertyConfigTypeURL,
+		},
+		"SearchArea": notionapi.SelectPropertyConfig{
+			Type: notionapi.PropertyConfigTypeSelect,
+		},
+		"Campaign": notionapi.SelectPropertyConfig{
+			Type: notionapi.PropertyConfigTypeSelect,
+		},
+		"SearchedType": notionapi.SelectPropertyConfig{
+			Type: notionapi.PropertyConfigTypeSelect,
+		},
+		"SearchKeyword": notionapi.RichTextPropertyConfig{
+			Type: notionapi.PropertyConfigTypeRichText,
+		},
+		"SearchCell": notionapi.RichTextPropertyConfig{
+			Type: notionapi.PropertyConfigTypeRichText,
+		},
+		"RunID": notionapi.RichTextPropertyConfig{
+			Type: notionapi.PropertyConfigTypeRichText,
+		},
+		"Sources": notionapi.MultiSelectPropertyConfig{
+			Type: notionapi.PropertyConfigTypeMultiSelect,
+		},
+		"FirstSource": notionapi.SelectPropertyConfig{
+			Type: notionapi.PropertyConfigTypeSelect,
+		},
+		"Provenance": notionapi.RichTextPropertyConfig{
+			Type: notionapi.PropertyConfigTypeRichText,
+		},
+		"CompanyNumber": notionapi.RichTextPropertyConfig{
+			Type: notionapi.PropertyConfigTypeRichText,
+		},
+		"CompanyStatus": notionapi.SelectPropertyConfig{
+			Type: notionapi.PropertyConfigTypeSelect,
+		},
+		"IncorporationDate": notionapi.DatePropertyConfig{
+			Type: notionapi.PropertyConfigTypeDate,
+		},
+		"RegisteredAddress": notionapi.RichTextPropertyConfig{
+			Type: notionapi.PropertyConfigTypeRichText,
+		},
+		"Trend": notionapi.RichTextPropertyConfig{
+			Type: notionapi.PropertyConfigTypeRichText,
+		},
+		"LikelyUnclaimed": notionapi.CheckboxPropertyConfig{
+			Type: notionapi.PropertyConfigTypeCheckbox,
+		},
+		"UnclaimedSignals": notionapi.RichTextPropertyConfig{
+			Type: notionapi.PropertyConfigTypeRichText,
+		},
+		"DuplicateOf": notionapi.RichTextPropertyConfig{
+			Type: notionapi.PropertyConfigTypeRichText,
+		},
+		"AssignedTo": notionapi.SelectPropertyConfig{
+			Type: notionapi.PropertyConfigTypeSelect,
+		},
+		"AssignedDate": notionapi.DatePropertyConfig{
+			Type: notionapi.PropertyConfigTypeDate,
+		},
+		"CadenceStep": notionapi.NumberPropertyConfig{
+			Type:   notionapi.PropertyConfigTypeNumber,
+			Number: notionapi.NumberFormat{Format: notionapi.FormatNumber},
+		},
+		"LastActionDate": notionapi.DatePropertyConfig{
+			Type: notionapi.PropertyConfigTypeDate,
+		},
+		"NextAction": notionapi.SelectPropertyConfig{
+			Type: notionapi.PropertyConfigTypeSelect,
+		},
+		"NextActionDate": notionapi.DatePropertyConfig{
+			Type: notionapi.PropertyConfigTypeDate,
+		},
+		"LastContacted": notionapi.DatePropertyConfig{
+			Type: notionapi.PropertyConfigTypeDate,
+		},
+		"NextFollowUp": notionapi.DatePropertyConfig{
+			Type: notionapi.PropertyConfigTypeDate,
+		},
+		"Lead": notionapi.UniqueIDPropertyConfig{
+			Type:     notionapi.PropertyConfigUniqueID,
+			UniqueID: notionapi.UniqueIDConfig{Prefix: "LEAD"},
+		},
 	}
+}
 
+// CreateDatabase creates a Notion database
+func (nc *NotionClient) CreateDatabase(ctx context.Context) error {
 	dbCreateRequest := notionapi.DatabaseCreateRequest{
 		Parent:     notionapi.Parent{Type: notionapi.ParentTypePageID, PageID: nc.pageID},
 		Title:      []notionapi.RichText{{Text: &notionapi.Text{Content: "Businesses"}}},
-		Properties: properties,
+		Properties: databaseProperties(),
 		IsInline:   false,
 	}
 
-	db, err := nc.client.Database.Create(context.Background(), &dbCreateRequest)
+	db, err := nc.client.Database.Create(ctx, &dbCreateRequest)
 	nc.databaseID = notionapi.DatabaseID(db.ID)
 	return err
 }
 
 // Add a method to check if a business already exists in the Notion database
-func (nc *NotionClient) BusinessExists(placeID string) (bool, error) {
+func (nc *NotionClient) BusinessExists(ctx context.Context, placeID string) (bool, error) {
 	query := &notionapi.DatabaseQueryRequest{
 		Filter: &notionapi.PropertyFilter{
 			Property: "PlaceID",
@@ -125,7 +441,7 @@ func (nc *NotionClient) BusinessExists(placeID string) (bool, error) {
 		},
 	}
 
-	res, err := nc.client.Database.Query(context.Background(), nc.databaseID, query)
+	res, err := nc.client.Database.Query(ctx, nc.databaseID, query)
 	if err != nil {
 		return false, err
 	}
@@ -133,248 +449,600 @@ func (nc *NotionClient) BusinessExists(placeID string) (bool, error) {
 	return len(res.Results) > 0, nil
 }
 
-func (nc *NotionClient) InsertBusiness(business Business) error {
-	exists, err := nc.BusinessExists(business.PlaceID)
+// ExistingPlaceIDs looks up which of the PlaceIDs are in the database, in one query per hundred
+func (nc *NotionClient) ExistingPlaceIDs(ctx context.Context, placeIDs []string) (map[string]bool, error) {
+	existing := make(map[string]bool)
+	for len(placeIDs) > 0 {
+		batch := placeIDs[:min(len(placeIDs), 100)]
+		placeIDs = placeIDs[len(batch):]
+		filter := make(notionapi.OrCompoundFilter, len(batch))
+		for i, placeID := range batch {
+			filter[i] = notionapi.PropertyFilter{
+				Property: "PlaceID",
+				RichText: &notionapi.TextFilterCondition{Equals: placeID},
+			}
+		}
+		err := nc.EachBusiness(ctx, filter, func(b Business) error {
+			existing[b.PlaceID] = true
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return existing, nil
+}
+
+// errBusinessExists is returned by InsertBusiness when the place is already in the database
+var errBusinessExists = errors.New("business already exists")
+
+// InsertBusiness creates a page for the business with the selected fields and records its lead number
+func (nc *NotionClient) InsertBusiness(ctx context.Context, business *Business, fields FieldSelector) error {
+	exists, err := nc.BusinessExists(ctx, business.PlaceID)
 	if err != nil {
 		return err
 	}
 
 	if exists {
-		fmt.Printf("Business with PlaceID %s already exists, skipping...\n", business.PlaceID)
-		return nil
+		return errBusinessExists
 	}
+	return nc.createPage(ctx, business, fields)
+}
 
-	var multiSelectOptions []notionapi.Option
-	for _, t := range business.Type {
-		multiSelectOptions = append(multiSelectOptions, notionapi.Option{Name: t})
+// createPage creates the page of a business known not to be in the database yet
+func (nc *NotionClient) createPage(ctx context.Context, business *Business, fields FieldSelector) error {
+	properties, err := nc.businessProperties(business).Build()
+	if err != nil {
+		return err
+	}
+	// Name is Notion's title and PlaceID is how existing leads are found, so both are always kept
+	for name := range properties {
+		if name != "Name" && name != "PlaceID" && !fields.Allows(propertyField(name)) {
+			delete(properties, name)
+		}
+	}
+	// A contact that can't be created is logged rather than losing the lead; its email and phone
+	// are on the lead too
+	if nc.contactsID != "" && fields.Allows("Contacts") {
+		contactID, err := nc.createContact(ctx, business, fields)
+		if err != nil {
+			slog.Warn("Failed to create contact", "operation", "contacts", "place_id", business.PlaceID, "name", business.Name, "err", err)
+		} else if contactID != "" {
+			business.Contacts = []string{contactID}
+			properties["Contacts"] = notionapi.RelationProperty{Relation: []notionapi.Relation{{ID: notionapi.PageID(contactID)}}}
+		}
 	}
 
 	page := notionapi.PageCreateRequest{
 		Parent: notionapi.Parent{
 			DatabaseID: nc.databaseID,
 		},
-		Properties: notionapi.Properties{
-			"Name": notionapi.TitleProperty{
-				Title: []notionapi.RichText{
-					{
-						Text: &notionapi.Text{
-							Content: business.Name,
-						},
-					},
-				},
-			},
-			"Address": notionapi.RichTextProperty{
-				RichText: []notionapi.RichText{
-					{
-						Text: &notionapi.Text{
-							Content: business.Address,
-						},
-					},
-				},
-			},
-			"PlaceID": notionapi.RichTextProperty{
-				RichText: []notionapi.RichText{
-					{
-						Text: &notionapi.Text{
-							Content: business.PlaceID,
-						},
-					},
-				},
-			},
-			"Type": notionapi.MultiSelectProperty{
-				MultiSelect: multiSelectOptions,
-			},
-			"WebsiteStatus": notionapi.SelectProperty{
-				Select: notionapi.Option{
-					Name: business.WebsiteStatus,
-				},
-			},
-			"Urgency": notionapi.SelectProperty{
-				Select: notionapi.Option{
-					Name: business.Urgency,
-				},
-			},
-			"Contacted": notionapi.SelectProperty{
-				Select: notionapi.Option{
-					Name: business.Contacted,
-				},
-			},
-			"URL": notionapi.URLProperty{
-				URL: business.URL,
-			},
-		},
+		Properties: properties,
+		Children:   detailsBlocks(business, fields),
+	}
+	if len(business.ScoreBreakdown) > 0 && fields.Allows("LeadScore") {
+		page.Children = append(page.Children, scoreBlocks(fmt.Sprintf("Lead score: %d", business.LeadScore), business.ScoreBreakdown)...)
+	}
+	page.Children = append(page.Children, outreachBlocks()...)
+	if len(business.Photos) > 0 && fields.Allows("Photos") {
+		page.Cover = &notionapi.Image{
+			Type:     notionapi.FileTypeExternal,
+			External: &notionapi.FileObject{URL: business.Photos[0].URL},
+		}
+		page.Children = append(page.Children, photoBlocks(business.Photos)...)
 	}
 
-	_, err = nc.client.Page.Create(context.Background(), &page)
-	return err
+	created, err := nc.client.Page.Create(ctx, &page)
+	if err != nil {
+		return err
+	}
+	business.PageID = created.ID.String()
+	business.PageURL = created.URL
+	business.LeadNumber = leadNumber(created)
+	return nil
+}
+
+// formatOpeningHours renders the weekly opening hours one day per line
+func formatOpeningHours(hours *maps.OpeningHours) string {
+	if hours == nil {
+		return ""
+	}
+	return strings.Join(hours.WeekdayText, "\n")
+}
+
+// leadNumber returns the human-friendly unique ID (e.g. LEAD-123) of a page, if the database has one
+func leadNumber(page *notionapi.Page) string {
+	if page == nil {
+		return ""
+	}
+	if prop, ok := page.Properties["Lead"].(*notionapi.UniqueIDProperty); ok {
+		return prop.UniqueID.String()
+	}
+	return ""
 }
 
 func main() {
+	configPath := flag.String("config", defaultConfigPath, "path to the JSON config file")
+	areaName := flag.String("area", "", "bundled area preset to search (use --area=list to see them)")
+	location := flag.String("location", "", "place name or address to search around, e.g. \"Falmouth, UK\" (geocoded; overrides --area)")
+	source := flag.String("source", defaultSources, "comma-separated sources to search, in order, overriding the config's sources: google, yelp (needs YELP_API_KEY), foursquare (needs FOURSQUARE_API_KEY), osm, fixture (canned Places data from --fixtures)")
+	fixtures := flag.String("fixtures", defaultFixturePath, "canned Places search results and details the fixture source searches, in the Places API's JSON")
+	mockNotion := flag.Bool("mock-notion", false, "write leads to an in-memory stand-in for Notion instead, without NOTION_API_KEY, leaving local state files as they are; for demos and tests with --source fixture")
+	query := flag.String("query", "", "run a keyword text search, e.g. \"independent coffee shops in Cornwall\", instead of the type searches")
+	campaign := flag.String("campaign", "", "campaign from the config whose lead score weights to use")
+	types := flag.String("types", "", "comma-separated Google place types to search instead of the area's, e.g. dentist,car_repair (any place type works)")
+	keepChains := flag.Bool("keep-chains", false, "keep listings of chains and franchises, which are otherwise dropped by name or by being listed at many places")
+	minRating := flag.Float64("min-rating", 0, "drop listings rated below this, out of 5, overriding the config's filters (0 for no minimum)")
+	maxRating := flag.Float64("max-rating", 0, "drop listings rated above this, out of 5, overriding the config's filters (0 for no maximum)")
+	includeClosed := flag.Bool("include-closed", false, "keep listings of temporarily or permanently closed businesses, which are otherwise dropped")
+	typeConcurrency := flag.Int("type-concurrency", 0, "search Google for this many place types at once, overriding the config's type_concurrency")
+	keep := flag.String("keep", "", `only write listings satisfying this expression, e.g. 'rating >= 4.0 && website == ""', overriding the config's filters`)
+	minReviews := flag.Int("min-reviews", 0, "drop listings with fewer reviews than this, such as brand-new or dormant ones, overriding the config's filters")
+	excludeTypes := flag.String("exclude-types", "", "comma-separated place types not to search, e.g. bank,library")
+	gridCell := flag.Uint("grid-cell", 0, "tile the area into overlapping search cells of this radius in metres (0 uses the area's setting)")
+	boundary := flag.String("boundary", "", "GeoJSON file of polygons (e.g. a council boundary); results outside it are skipped")
+	mapsProxy := flag.String("maps-proxy", "", "base URL of a shared proxy command to send legacy Maps API requests through, e.g. http://127.0.0.1:8089")
+	cpuProfile := flag.String("cpuprofile", "", "write a CPU profile of the run to this file")
+	memProfile := flag.String("memprofile", "", "write a heap profile at the end of the run to this file")
+	memStats := flag.Duration("mem-stats", 0, "log heap usage at this interval, e.g. 1m")
+	quotaPause := flag.Duration("quota-pause", 30*time.Minute, "how long a Places request keeps pausing and retrying through quota errors before it is given up on")
+	maxBudget := flag.Float64("max-budget", 0, "stop searching once the run's estimated Google API spend would pass this many US dollars (0 for no limit)")
+	sinceLastRun := flag.Bool("since-last-run", false, "only process businesses that no previous run listed")
+	statePath := flag.String("state-file", defaultStatePath, "file recording when searches ran and the PlaceIDs they listed")
+	flag.DurationVar(&requestTimeout, "request-timeout", defaultRequestTimeout, "give up on a Notion, Google, Yelp, Foursquare or Companies House request after this long")
+	maxRuntime := flag.Duration("max-runtime", 0, "stop searching after this long, writing the leads in flight, e.g. 2h (0 for no limit)")
+	resume := flag.Bool("resume", false, "skip the areas an interrupted run searched to the end; the interrupted search is printed with this flag added")
+	diffPath := flag.String("diff-file", "", "append a JSON line per field changed on existing leads (field, old, new, reason) to this file")
+	usagePath := flag.String("usage-file", defaultUsagePath, "ledger of estimated Google spend per campaign and month, checked against monthly budgets")
+	schemaCheckOnly := flag.Bool("schema-check-only", false, "fail with a report of the properties the Notion database is missing instead of adding them")
+	detailsCachePath := flag.String("details-cache", defaultDetailsCachePath, `file caching the place details of earlier runs; "" to fetch every place's details`)
+	detailsCacheDays := flag.Int("details-cache-days", defaultDetailsCacheDays, "use cached place details for this many days before fetching them again")
+	deadLetterPath := flag.String("dead-letters", defaultDeadLetterPath, "file keeping the leads sinks failed to write, for the retry-failed command")
+	historyPath := flag.String("history-file", defaultHistoryPath, "file of daily rating, review and website snapshots per listing, used for trends")
+	tuiMode := flag.Bool("tui", false, "show an interactive terminal UI during the search, with progress per stage, pausing, skipping stages and browsing errors")
+	verbose := flag.Bool("verbose", false, "print every search page and skipped listing instead of a progress line")
+	logLevel := flag.String("log-level", "info", "minimum level of log records: debug, info, warn or error")
+	logFormat := flag.String("log-format", "text", "log record format: text, or json for log aggregators")
+	qualityReport := flag.String("quality-report", "", "write the run's data quality report to this file instead of printing it")
+	guessEmails := flag.Bool("guess-emails", false, "guess the email of businesses whose site lists none from their domain's mail servers, checking guesses over SMTP without sending")
+	companiesHouse := flag.Bool("companies-house", false, "look UK businesses up in the Companies House register (needs COMPANIES_HOUSE_API_KEY)")
+	llmReviews := flag.Bool("llm-review-summary", false, "summarize review themes with an LLM (needs LLM_API_KEY) instead of keyword matching")
+	flag.BoolVar(&stableOrder, "stable-order", false, "sort exports and reports by PlaceID so successive runs diff cleanly")
+	flag.Parse()
+	progress.SetVerbose(*verbose)
+	if err := setupLogging(*logLevel, *logFormat); err != nil {
+		log.Fatal(err)
+	}
+
+	// Subcommands follow the global flags, e.g. business-finder --config x.json sample --reps a,b
+	command, commandArgs := flag.Arg(0), flag.Args()[min(1, flag.NArg()):]
+	if command == "template" {
+		runTemplate(commandArgs)
+		return
+	}
+	if command == "service" {
+		runService(os.Args[1:len(os.Args)-flag.NArg()], commandArgs)
+		return
+	}
+
+	// Without a .env file the keys can still come from the environment; each is checked where needed
 	err := godotenv.Load()
-	if err != nil {
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
 		log.Fatal("Error loading .env file")
 	}
-	apiKey := os.Getenv("GOOGLE_PLACES_API_KEY")
-	if apiKey == "" {
-		log.Fatal("GOOGLE_PLACES_API_KEY must be set")
-	}
-	notionAPIKey := os.Getenv("NOTION_API_KEY")
-	notionDatabaseID := os.Getenv("NOTION_DATABASE_ID")
-	if notionAPIKey == "" || notionDatabaseID == "" {
-		log.Fatal("NOTION_API_KEY and NOTION_DATABASE_ID must be set")
+
+	configSet, sourceSet := false, false
+	flag.Visit(func(f *flag.Flag) {
+		configSet = configSet || f.Name == "config"
+		sourceSet = sourceSet || f.Name == "source"
+	})
+	cfg, err := LoadConfig(*configPath, configSet)
+	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
 	}
-	notionPageID := os.Getenv("NOTION_PAGE_ID")
 
-	// Initialize Notion client
-	notionClient := NewNotionClient(notionAPIKey, notionDatabaseID, notionPageID)
+	weights, err := cfg.Weights(*campaign)
+	if err != nil {
+		log.Fatal(err)
+	}
 
-	// Check if the Notion database exists
-	if !notionClient.CheckDatabaseExists() {
-		fmt.Println("Database does not exist, creating it...")
-		err := notionClient.CreateDatabase()
+	if *areaName == "list" {
+		printPresets()
+		return
+	}
+	if *areaName == "" {
+		*areaName = cfg.Area
+	}
+	var areas []*SearchArea
+	switch {
+	case *areaName != "":
+		area, err := LoadPreset(*areaName)
 		if err != nil {
-			log.Fatalf("Failed to create Notion database: %v", err)
+			log.Fatal(err)
+		}
+		areas = append(areas, area)
+	case len(cfg.Locations) > 0:
+		for _, location := range cfg.Locations {
+			areas = append(areas, location.withDefaults())
 		}
+	default:
+		areas = append(areas, defaultSearchArea())
 	}
-
-	// Initialize Google Maps client
-	mapsClient, err := maps.NewClient(maps.WithAPIKey(apiKey))
+	if command == "proxy" {
+		runProxy(commandArgs)
+		return
+	}
+	usage, err := LoadUsageLedger(*usagePath)
 	if err != nil {
-		log.Fatalf("Failed to create Google Maps client: %v", err)
-	}
-
-	placeTypes := []maps.PlaceType{
-		maps.PlaceTypeArtGallery,
-		maps.PlaceTypeBakery,
-		maps.PlaceTypeBank,
-		maps.PlaceTypeBar,
-		maps.PlaceTypeBeautySalon,
-		maps.PlaceTypeBicycleStore,
-		maps.PlaceTypeBookStore,
-		maps.PlaceTypeBowlingAlley,
-		maps.PlaceTypeCafe,
-		maps.PlaceTypeCampground,
-		maps.PlaceTypeClothingStore,
-		maps.PlaceTypeConvenienceStore,
-		maps.PlaceTypeDepartmentStore,
-		maps.PlaceTypeElectrician,
-		maps.PlaceTypeElectronicsStore,
-		maps.PlaceTypeFlorist,
-		maps.PlaceTypeFuneralHome,
-		maps.PlaceTypeGym,
-		maps.PlaceTypeHairCare,
-		maps.PlaceTypeHomeGoodsStore,
-		maps.PlaceTypeJewelryStore,
-		maps.PlaceTypeLaundry,
-		maps.PlaceTypeLibrary,
-		maps.PlaceTypeLiquorStore,
-		maps.PlaceTypeLocksmith,
-		maps.PlaceTypeLodging,
-		maps.PlaceTypeMealDelivery,
-		maps.PlaceTypeMealTakeaway,
-		maps.PlaceTypeMovieRental,
-		maps.PlaceTypeMovingCompany,
-		maps.PlaceTypeMuseum,
-		maps.PlaceTypeNightClub,
-		maps.PlaceTypePainter,
-		maps.PlaceTypePetStore,
-		maps.PlaceTypePhysiotherapist,
-		maps.PlaceTypePlumber,
-		maps.PlaceTypeRestaurant,
-		maps.PlaceTypeRoofingContractor,
-		maps.PlaceTypeRvPark,
-		maps.PlaceTypeShoeStore,
-		maps.PlaceTypeShoppingMall,
-		maps.PlaceTypeSpa,
-		maps.PlaceTypeStorage,
-		maps.PlaceTypeStore,
-		maps.PlaceTypeSupermarket,
-		maps.PlaceTypeTravelAgency,
-		maps.PlaceTypeVeterinaryCare,
-	}
-
-	for _, placeType := range placeTypes {
-		fmt.Printf("Searching for places of type: %s\n", placeType)
-
-		req := &maps.NearbySearchRequest{
-			Location: &maps.LatLng{
-				Lat: 50.152573,
-				Lng: -5.066270,
-			},
-			Radius: 50000,
-			Type:   placeType,
+		log.Fatalf("Failed to load usage ledger: %v", err)
+	}
+	if command == "usage" {
+		runUsage(cfg, usage, commandArgs)
+		return
+	}
+
+	var notionClient *NotionClient
+	if *mockNotion {
+		if command != "" && command != "import" {
+			log.Fatalf("--mock-notion only works for search runs and imports, not %s", command)
 		}
+	} else {
+		notionAPIKey := os.Getenv("NOTION_API_KEY")
+		notionDatabaseID := os.Getenv("NOTION_DATABASE_ID")
+		if notionAPIKey == "" || notionDatabaseID == "" {
+			log.Fatal("NOTION_API_KEY and NOTION_DATABASE_ID must be set")
+		}
+		notionPageID := os.Getenv("NOTION_PAGE_ID")
 
-		pageCount := 0
-		for {
-			pageCount++
-			fmt.Printf("Fetching page %d for %s\n", pageCount, placeType)
+		// Initialize Notion client
+		notionClient = NewNotionClient(notionAPIKey, notionDatabaseID, notionPageID)
+		notionClient.contactsID = notionapi.DatabaseID(os.Getenv("NOTION_CONTACTS_DATABASE_ID"))
 
-			places, err := mapsClient.NearbySearch(context.Background(), req)
+		// Check if the Notion database exists
+		if !notionClient.CheckDatabaseExists(context.Background()) {
+			fmt.Println("Database does not exist, creating it...")
+			err := notionClient.CreateDatabase(context.Background())
 			if err != nil {
-				log.Printf("Failed to perform nearby search for %s: %v", placeType, err)
-				break
+				log.Fatalf("Failed to create Notion database: %v", err)
+			}
+			if err := notionClient.CreateSetupPage(context.Background()); err != nil {
+				slog.Warn("Failed to create the database setup page", "operation", "create-database", "err", err)
 			}
+		}
 
-			fmt.Printf("Found %d results on this page\n", len(places.Results))
+		if err := notionClient.ReconcileSchema(context.Background(), !*schemaCheckOnly); err != nil {
+			log.Fatalf("Failed to check the Notion database schema: %v", err)
+		}
 
-			for _, place := range places.Results {
-				placeDetailsReq := &maps.PlaceDetailsRequest{
-					PlaceID: place.PlaceID,
-				}
+		if err := notionClient.LoadOptions(context.Background()); err != nil {
+			slog.Warn("Failed to load existing Notion select options", "operation", "load-options", "err", err)
+		}
+		if err := notionClient.OpenRoutes(context.Background(), cfg.NotionRoutes, !*schemaCheckOnly); err != nil {
+			log.Fatalf("Failed to open the routed Notion databases: %v", err)
+		}
+	}
 
-				details, err := mapsClient.PlaceDetails(context.Background(), placeDetailsReq)
-				if err != nil {
-					log.Printf("Failed to get place details for %s: %v", place.Name, err)
-					continue
-				}
+	history, err := LoadHistory(*historyPath)
+	if err != nil {
+		log.Fatalf("Failed to load lead history: %v", err)
+	}
+	deadLetters, err := LoadDeadLetters(*deadLetterPath)
+	if err != nil {
+		log.Fatalf("Failed to load failed writes: %v", err)
+	}
+	if *diffPath != "" {
+		diffLog, err = OpenDiffLog(*diffPath)
+		if err != nil {
+			log.Fatalf("Failed to open diff file: %v", err)
+		}
+		defer diffLog.Close()
+	}
 
-				websiteStatus := "No Website"
-				urgency := "High"
-				url := ""
+	switch command {
+	case "", "serve", "import", "recheck":
+	case "sample":
+		runSample(notionClient, commandArgs)
+		return
+	case "verify":
+		runVerify(notionClient, commandArgs)
+		return
+	case "purge":
+		withSinks(cfg.Sinks, notionClient, func(sinks []Sink) {
+			runPurge(notionClient, cfg.Policy, sinks, deadLetters, commandArgs)
+		})
+		return
+	case "migrate-db":
+		runMigrateDB(notionClient, commandArgs)
+		return
+	case "bulk-set":
+		withSinks(cfg.Sinks, notionClient, func(sinks []Sink) {
+			runBulkSet(notionClient, sinks, commandArgs)
+		})
+		return
+	case "stats":
+		runStats(notionClient, commandArgs)
+		return
+	case "cadence":
+		withSinks(cfg.Sinks, notionClient, func(sinks []Sink) {
+			runCadence(notionClient, cfg, sinks, commandArgs)
+		})
+		return
+	case "send":
+		withSinks(cfg.Sinks, notionClient, func(sinks []Sink) {
+			runSend(notionClient, cfg, sinks, commandArgs)
+		})
+		return
+	case "export":
+		runExport(notionClient, commandArgs)
+		return
+	case "rescore":
+		runRescore(notionClient, cfg, weights, history, commandArgs)
+		return
+	case "delete-run":
+		runDeleteRun(notionClient, commandArgs)
+		return
+	case "retry-failed":
+		withSinks(cfg.Sinks, notionClient, func(sinks []Sink) {
+			runRetryFailed(notionClient, cfg.Policy, deadLetters, sinks, commandArgs)
+		})
+		return
+	default:
+		log.Fatalf("Unknown command %q", command)
+	}
 
-				if details.Website != "" {
-					websiteStatus = "Has Website"
-					url = details.Website
-					urgency = "Medium"
-				}
+	sourceNames := splitList(*source)
+	if !sourceSet && len(cfg.Sources) > 0 {
+		sourceNames = cfg.Sources
+	}
+	profile := cmp.Or(*campaign, defaultProfile)
+	budget := NewBudget(*maxBudget, usage, profile, cfg.ProfileBudget(profile))
+	var mapsClient *maps.Client
+	var places PlacesProvider
+	fixture := slices.Contains(sourceNames, fixtureSourceName)
+	if fixture {
+		if sourceNeedsGoogle(sourceNames) || *location != "" {
+			log.Fatal("The fixture source stands in for Google; it can't be searched with google or --location")
+		}
+		if *companiesHouse || *guessEmails || *llmReviews {
+			log.Fatal("The fixture source runs offline; it can't be used with --companies-house, --guess-emails or --llm-reviews")
+		}
+		// Nothing is billed, so nothing is metered against the budget
+		places, err = LoadFixturePlaces(*fixtures)
+		if err != nil {
+			log.Fatalf("Failed to load fixtures: %v", err)
+		}
+	} else if sourceNeedsGoogle(sourceNames) || *location != "" || command == "import" || command == "recheck" {
+		apiKey := os.Getenv("GOOGLE_PLACES_API_KEY")
+		if apiKey == "" {
+			log.Fatal("GOOGLE_PLACES_API_KEY must be set")
+		}
 
-				businessType := []string{"Other"}
-				if len(place.Types) > 0 {
-					businessType = place.Types
-				}
+		// Initialize Google Maps client
+		mapsBaseURL := mapsAPIBaseURL
+		if *mapsProxy != "" {
+			mapsBaseURL = *mapsProxy
+		}
+		mapsClient, err = maps.NewClient(maps.WithAPIKey(apiKey), maps.WithBaseURL(mapsBaseURL), maps.WithHTTPClient(&http.Client{Timeout: requestTimeout}))
+		if err != nil {
+			log.Fatalf("Failed to create Google Maps client: %v", err)
+		}
 
-				business := Business{
-					Name:          place.Name,
-					Address:       place.FormattedAddress,
-					PlaceID:       place.PlaceID,
-					Type:          businessType,
-					WebsiteStatus: websiteStatus,
-					Urgency:       urgency,
-					Contacted:     "Not Contacted",
-					URL:           url,
-				}
-				if business.WebsiteStatus == "No Website" {
-					business.URL = "https://www.google.com/maps/search/?api=1&query=" + business.Address
-				}
+		places, err = newPlacesProvider(cfg.PlacesAPI, apiKey, mapsClient, mapsBaseURL)
+		if err != nil {
+			log.Fatal(err)
+		}
+		// Requests refused on quota aren't billed, so retries happen inside the budget's count
+		places = throttledPlaces{PlacesProvider: places, throttle: NewQuotaThrottle(*quotaPause)}
+		places = meteredPlaces{PlacesProvider: places, budget: budget}
+	}
+
+	if command == "recheck" {
+		withSinks(cfg.Sinks, notionClient, func(sinks []Sink) {
+			runRecheck(notionClient, cfg, places, budget, weights, history, sinks, commandArgs)
+		})
+		return
+	}
 
-				// Insert into Notion
-				err = notionClient.InsertBusiness(business)
-				if err != nil {
-					log.Printf("Failed to insert into Notion: %v", err)
-				} else {
-					fmt.Printf("Inserted: Name: %s, Address: %s, Types: %v, WebsiteStatus: %s, Urgency: %s\n", place.Name, place.FormattedAddress, businessType, websiteStatus, urgency)
+	if *location != "" {
+		area, err := geocodeArea(context.Background(), mapsClient, *location)
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("Geocoded %q to %s (%s)\n", *location, area.Description, area.Location.String())
+		areas = []*SearchArea{area}
+	}
+
+	var llm *LLMClient
+	if *llmReviews {
+		llm, err = NewLLMClientFromEnv()
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	var companies *CompaniesHouse
+	if *companiesHouse {
+		apiKey := os.Getenv("COMPANIES_HOUSE_API_KEY")
+		if apiKey == "" {
+			log.Fatal("COMPANIES_HOUSE_API_KEY must be set to use --companies-house")
+		}
+		companies = NewCompaniesHouse(apiKey)
+	}
+
+	var emails *EmailGuesser
+	if *guessEmails {
+		emails = NewEmailGuesser()
+	}
+
+	state, err := LoadRunState(*statePath)
+	if err != nil {
+		log.Fatalf("Failed to load run state: %v", err)
+	}
+
+	filters := cfg.Filters
+	if *minRating != 0 {
+		filters.MinRating = *minRating
+	}
+	if *maxRating != 0 {
+		filters.MaxRating = *maxRating
+	}
+	if *keep != "" {
+		filters.Keep = *keep
+	}
+	if *minReviews != 0 {
+		filters.MinReviews = *minReviews
+	}
+	if *includeClosed {
+		filters.IncludeClosed = true
+	}
+	if err := filters.Validate(); err != nil {
+		log.Fatalf("Invalid filters: %v", err)
+	}
+	filter := NewListingFilter(filters, *keepChains)
+	if filter.RatesListings() && cfg.PlacesAPI == "new" && !slices.Contains(cfg.DetailsFieldMask(), maps.PlaceDetailsFieldMaskUserRatingsTotal) {
+		// The new API's searches don't return ratings either, so every listing would look unreviewed
+		log.Fatal(`Rating filters need "rating" in details_fields with places_api "new"`)
+	}
+	var detailsCache *DetailsCache
+	detailsPlaces := places
+	if *detailsCachePath != "" && *detailsCacheDays > 0 && places != nil && !fixture {
+		detailsCache, err = LoadDetailsCache(*detailsCachePath, time.Duration(*detailsCacheDays)*24*time.Hour)
+		if err != nil {
+			log.Fatalf("Failed to load place details cache: %v", err)
+		}
+		detailsPlaces = cachedPlaces{PlacesProvider: places, cache: detailsCache}
+	}
+	newGoogle := func() *GoogleSource {
+		google := NewGoogleSource(detailsPlaces, NewReviewSummarizer(llm), cfg.DetailsFieldMask())
+		google.filter = filter
+		google.searchRatings = cfg.PlacesAPI != "new"
+		google.typeConcurrency = max(cmp.Or(*typeConcurrency, cfg.TypeConcurrency), 1)
+		if *sinceLastRun {
+			google.skip = func(placeID string) bool {
+				skip, first := state.Skip(placeID)
+				if first {
+					progress.Found()
+					progress.Skipped()
 				}
+				return skip
 			}
+		}
+		return google
+	}
+	sources, err := openSources(sourceNames, newGoogle)
+	if err != nil {
+		log.Fatal(err)
+	}
 
-			if places.NextPageToken == "" {
-				fmt.Printf("No more pages for %s\n", placeType)
-				break
-			}
+	searchTypes := cfg.Types
+	if *types != "" {
+		placeTypes, err := parsePlaceTypes(splitList(*types))
+		if err != nil {
+			log.Fatalf("--types: %v", err)
+		}
+		searchTypes = make([]SearchType, len(placeTypes))
+		for i, placeType := range placeTypes {
+			searchTypes[i] = SearchType{Type: placeType}
+		}
+	}
+	excluded, _ := cfg.ExcludedTypes()
+	if *excludeTypes != "" {
+		excluded, err = parsePlaceTypes(splitList(*excludeTypes))
+		if err != nil {
+			log.Fatalf("--exclude-types: %v", err)
+		}
+	}
+
+	for _, area := range areas {
+		area.selectTypes(searchTypes, excluded)
+		if *gridCell > 0 {
+			area.GridCellRadius = *gridCell
+		}
+		if *boundary != "" {
+			area.Boundary = *boundary
+		}
+		if *query != "" {
+			area.Types, area.Queries = nil, []string{*query}
+		}
+		if len(area.searchTerms()) == 0 {
+			log.Fatalf("No place types left to search in %s", area.Name)
+		}
+		if err := area.LoadBoundary(); err != nil {
+			log.Fatalf("Failed to load boundary for %s: %v", area.Name, err)
+		}
+	}
+
+	// Imports only list the CSV's businesses, recorded under an area of their own
+	if command == "import" {
+		area, seeds := importArea(commandArgs)
+		areas, sources = []*SearchArea{area}, []Source{NewImportSource(newGoogle(), seeds)}
+	}
+
+	run := &searchRun{
+		notionClient:  notionClient,
+		cfg:           cfg,
+		sources:       sources,
+		companies:     companies,
+		emails:        emails,
+		weights:       weights,
+		areas:         areas,
+		qualityReport: *qualityReport,
+		state:         state,
+		sinceLastRun:  *sinceLastRun,
+		resume:        *resume,
+		maxRuntime:    *maxRuntime,
+		notify:        NewNotifications(cfg.Notifiers, cfg.SMTP),
+		history:       history,
+		detailsCache:  detailsCache,
+		deadLetters:   deadLetters,
+		fixture:       fixture,
+		budget:        budget,
+		campaign:      *campaign,
+		filter:        filter,
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	defer cancelOnSignal(cancel)()
+	if command == "serve" {
+		runServe(ctx, run, commandArgs)
+		return
+	}
+
+	if *cpuProfile != "" {
+		stop, err := startCPUProfile(*cpuProfile)
+		if err != nil {
+			log.Fatalf("Failed to start CPU profile: %v", err)
+		}
+		defer stop()
+	}
+	if *memStats > 0 {
+		go logMemStats(ctx, *memStats)
+	}
+
+	var tui *TUI
+	if *tuiMode {
+		if tui, err = StartTUI(cancel); err != nil {
+			log.Fatalf("Failed to start the terminal UI: %v", err)
+		}
+	}
+	summary, _, _ := run.Run(ctx)
+	if tui != nil {
+		tui.Finish()
+		tui.Wait()
+		tui.Stop()
+		// What the run printed went to the UI; the summary is kept on screen
+		fmt.Print(summary)
+	}
+
+	if *memProfile != "" {
+		if err := writeHeapProfile(*memProfile); err != nil {
+			slog.Error("Failed to write heap profile", "operation", "profile", "err", err)
+		}
+	}
 
-			fmt.Printf("Waiting before fetching next page...\n")
-			time.Sleep(5 * time.Second) // Increased delay to avoid rate limiting
-			req.PageToken = places.NextPageToken
+	if notionClient != nil {
+		if report := notionClient.options.Report(); report != "" {
+			fmt.Print(report)
 		}
 	}
 }
@@ -0,0 +1,293 @@
+package main
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"encoding/xml"
+	"flag"
+	"fmt"
+	"html/template"
+	"io"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//go:embed mapreport/index.html
+var mapReportPage string
+
+// mapReportTemplate renders the HTML map report; html/template escapes the leads it embeds as
+// JavaScript
+var mapReportTemplate = template.Must(template.New("map").Parse(mapReportPage))
+
+// urgencies are the urgency levels, most urgent first
+var urgencies = []string{"High", "Medium", "Low"}
+
+// urgencyColors are the marker colours of each urgency, as #RRGGBB; leads without one are grey
+var urgencyColors = map[string]string{
+	"High":   "#d7191c",
+	"Medium": "#fdae61",
+	"Low":    "#1a9641",
+}
+
+// unratedColor is the marker colour of leads without an urgency
+const unratedColor = "#7f7f7f"
+
+// urgencyColor returns the marker colour of a lead's urgency
+func urgencyColor(urgency string) string {
+	if color, ok := urgencyColors[urgency]; ok {
+		return color
+	}
+	return unratedColor
+}
+
+// runExportMap writes the leads with a location to a map with markers coloured by urgency: KML or
+// GeoJSON for loading into Google My Maps or QGIS to plan routes, or an HTML report to show a
+// client the market's coverage
+func runExportMap(notionClient *NotionClient, format string, args []string) {
+	fs := flag.NewFlagSet("export "+format, flag.ExitOnError)
+	out := fs.String("out", "", fmt.Sprintf("file to write (default leads-YYYY-MM-DD.%s)", format))
+	area := fs.String("area", "", "only export leads found searching this area")
+	contacted := fs.String("contacted", "", `only export leads with this Contacted status; "" for every lead not marked Do Not Contact`)
+	title := fs.String("title", "Market coverage", "title of the HTML report")
+	fs.Parse(args)
+
+	if *out == "" {
+		*out = fmt.Sprintf("leads-%s.%s", time.Now().Format("2006-01-02"), format)
+	}
+	leads, err := exportLeads(context.Background(), notionClient, *contacted, *area)
+	if err != nil {
+		log.Fatalf("Failed to list leads: %v", err)
+	}
+	var located []Business
+	for _, lead := range leads {
+		if lead.located() {
+			located = append(located, lead)
+		}
+	}
+	sortBusinesses(located)
+
+	file, err := os.Create(*out)
+	if err != nil {
+		log.Fatalf("export: %v", err)
+	}
+	defer file.Close()
+	switch format {
+	case "kml":
+		err = writeKML(file, located)
+	case "geojson":
+		err = writeGeoJSON(file, located)
+	case "html":
+		err = writeHTMLMap(file, *title, located, time.Now())
+	}
+	if err != nil {
+		log.Fatalf("export: %v", err)
+	}
+	fmt.Printf("Exported %d of %d leads to %s\n", len(located), len(leads), *out)
+	if len(located) < len(leads) {
+		fmt.Println("Leads without a location were left out; leads found before locations were stored have none")
+	}
+}
+
+// markerDetails are the lines of a lead's marker popup
+func markerDetails(b Business) []string {
+	var details []string
+	add := func(label, value string) {
+		if value != "" {
+			details = append(details, label+": "+value)
+		}
+	}
+	add("Lead", b.LeadNumber)
+	add("Address", b.Address)
+	add("Urgency", b.Urgency)
+	add("Website", b.WebsiteStatus)
+	add("Phone", b.Phone)
+	add("Email", b.Email)
+	add("Contacted", b.Contacted)
+	add("Notion", b.PageURL)
+	return details
+}
+
+// kmlStyle is the marker style shared by the placemarks of one urgency
+type kmlStyle struct {
+	XMLName xml.Name `xml:"Style"`
+	ID      string   `xml:"id,attr"`
+	Color   string   `xml:"IconStyle>color"`
+	Icon    string   `xml:"IconStyle>Icon>href"`
+}
+
+// kmlPlacemark is a lead's marker
+type kmlPlacemark struct {
+	XMLName     xml.Name `xml:"Placemark"`
+	Name        string   `xml:"name"`
+	Description string   `xml:"description"`
+	StyleURL    string   `xml:"styleUrl"`
+	Coordinates string   `xml:"Point>coordinates"`
+}
+
+// kmlColor converts a #RRGGBB colour to KML's opaque aabbggrr
+func kmlColor(color string) string {
+	rgb := strings.TrimPrefix(color, "#")
+	return "ff" + rgb[4:6] + rgb[2:4] + rgb[0:2]
+}
+
+// writeKML writes leads as KML placemarks, styled by urgency. Google My Maps keeps the colours of
+// imported styles.
+func writeKML(w io.Writer, leads []Business) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	kml := xml.StartElement{Name: xml.Name{Local: "kml"}, Attr: []xml.Attr{{Name: xml.Name{Local: "xmlns"}, Value: "http://www.opengis.net/kml/2.2"}}}
+	document := xml.StartElement{Name: xml.Name{Local: "Document"}}
+	if err := enc.EncodeToken(kml); err != nil {
+		return err
+	}
+	if err := enc.EncodeToken(document); err != nil {
+		return err
+	}
+	if err := enc.EncodeElement("Leads", xml.StartElement{Name: xml.Name{Local: "name"}}); err != nil {
+		return err
+	}
+	for _, urgency := range append(urgencies, "") {
+		style := kmlStyle{
+			ID:    kmlStyleID(urgency),
+			Color: kmlColor(urgencyColor(urgency)),
+			Icon:  "https://maps.google.com/mapfiles/kml/paddle/wht-blank.png",
+		}
+		if err := enc.Encode(style); err != nil {
+			return err
+		}
+	}
+	for _, b := range leads {
+		mark := kmlPlacemark{
+			Name:        b.Name,
+			Description: strings.Join(markerDetails(b), "\n"),
+			StyleURL:    "#" + kmlStyleID(b.Urgency),
+			Coordinates: fmt.Sprintf("%f,%f", b.Location.Lng, b.Location.Lat),
+		}
+		if err := enc.Encode(mark); err != nil {
+			return err
+		}
+	}
+	if err := enc.EncodeToken(document.End()); err != nil {
+		return err
+	}
+	if err := enc.EncodeToken(kml.End()); err != nil {
+		return err
+	}
+	return enc.Flush()
+}
+
+// kmlStyleID names the style of an urgency's markers
+func kmlStyleID(urgency string) string {
+	if urgency == "" {
+		return "urgency-none"
+	}
+	return "urgency-" + strings.ToLower(urgency)
+}
+
+// writeGeoJSON writes leads as a GeoJSON feature collection of points. Each carries its
+// urgency, to style by in QGIS, and a simplestyle marker-color most web viewers show as is.
+func writeGeoJSON(w io.Writer, leads []Business) error {
+	type feature struct {
+		Type     string `json:"type"`
+		Geometry struct {
+			Type        string     `json:"type"`
+			Coordinates [2]float64 `json:"coordinates"`
+		} `json:"geometry"`
+		Properties map[string]any `json:"properties"`
+	}
+	collection := struct {
+		Type     string    `json:"type"`
+		Features []feature `json:"features"`
+	}{Type: "FeatureCollection", Features: []feature{}}
+	for _, b := range leads {
+		f := feature{Type: "Feature"}
+		f.Geometry.Type = "Point"
+		f.Geometry.Coordinates = [2]float64{b.Location.Lng, b.Location.Lat}
+		f.Properties = map[string]any{
+			"name":          b.Name,
+			"lead":          b.LeadNumber,
+			"address":       b.Address,
+			"urgency":       b.Urgency,
+			"lead_score":    b.LeadScore,
+			"website":       b.WebsiteStatus,
+			"phone":         b.Phone,
+			"email":         b.Email,
+			"contacted":     b.Contacted,
+			"notion_url":    b.PageURL,
+			"marker-color":  urgencyColor(b.Urgency),
+			"marker-symbol": "circle",
+		}
+		collection.Features = append(collection.Features, f)
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(collection)
+}
+
+// mapMarker is a lead as the HTML map report shows it
+type mapMarker struct {
+	Name    string  `json:"name"`
+	Lead    string  `json:"lead"`
+	Address string  `json:"address"`
+	Types   string  `json:"types"`
+	Urgency string  `json:"urgency"`
+	Website string  `json:"website"`
+	URL     string  `json:"url"`
+	Rating  string  `json:"rating"`
+	Phone   string  `json:"phone"`
+	Email   string  `json:"email"`
+	Notion  string  `json:"notion"`
+	Lat     float64 `json:"lat"`
+	Lng     float64 `json:"lng"`
+}
+
+// writeHTMLMap writes leads as a standalone HTML page with a Leaflet map of clustered markers,
+// whose popups give each lead's details and a link to its Notion page. The data is in the page;
+// only Leaflet and the map tiles are loaded from the web.
+func writeHTMLMap(w io.Writer, title string, leads []Business, now time.Time) error {
+	type legendEntry struct {
+		Name  string `json:"name"`
+		Color string `json:"color"`
+	}
+	data := struct {
+		Title         string
+		Generated     string
+		Leads         []mapMarker
+		Urgencies     []legendEntry
+		FallbackColor string
+	}{Title: title, Generated: now.Format("2 January 2006"), Leads: []mapMarker{}, FallbackColor: unratedColor}
+	for _, urgency := range urgencies {
+		data.Urgencies = append(data.Urgencies, legendEntry{Name: urgency, Color: urgencyColors[urgency]})
+	}
+	for _, b := range leads {
+		marker := mapMarker{
+			Name:    b.Name,
+			Lead:    b.LeadNumber,
+			Address: b.Address,
+			Types:   strings.Join(b.Type, ", "),
+			Urgency: b.Urgency,
+			Website: b.WebsiteStatus,
+			Phone:   b.Phone,
+			Email:   b.Email,
+			Notion:  b.PageURL,
+			Lat:     b.Location.Lat,
+			Lng:     b.Location.Lng,
+		}
+		// Leads without a website have their Maps search as URL
+		if b.WebsiteStatus == "Has Website" || b.WebsiteStatus == "Broken Website" {
+			marker.URL = b.URL
+		}
+		if rating := formatRating(b); rating != "" {
+			marker.Rating = rating + " (" + strconv.Itoa(b.ReviewCount) + " reviews)"
+		}
+		data.Leads = append(data.Leads, marker)
+	}
+	return mapReportTemplate.Execute(w, data)
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+
+	"googlemaps.github.io/maps"
+)
+
+// ukPostcodePattern matches a full UK postcode such as TR11 3AB
+var ukPostcodePattern = regexp.MustCompile(`(?i)\b([A-Z]{1,2}[0-9][A-Z0-9]?)\s*([0-9][A-Z]{2})\b`)
+
+// nameNoiseWords are dropped when comparing business names
+var nameNoiseWords = map[string]bool{
+	"the": true, "and": true, "ltd": true, "limited": true, "llp": true, "plc": true,
+	"inc": true, "llc": true, "co": true, "company": true,
+}
+
+// nameTokens lowercases a business name and splits it into comparable words
+func nameTokens(name string) []string {
+	name = strings.ToLower(strings.ReplaceAll(name, "&", " and "))
+	name = strings.NewReplacer("'", "", "’", "").Replace(name)
+	var tokens []string
+	for _, token := range strings.FieldsFunc(name, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		if !nameNoiseWords[token] {
+			tokens = append(tokens, token)
+		}
+	}
+	return tokens
+}
+
+// normalizeName reduces a business name to a canonical comparison key
+func normalizeName(name string) string {
+	return strings.Join(nameTokens(name), " ")
+}
+
+// tokenSetSimilarity is the Jaccard similarity of the word sets of two names, from 0 to 1
+func tokenSetSimilarity(a, b string) float64 {
+	setA := make(map[string]bool)
+	for _, t := range nameTokens(a) {
+		setA[t] = true
+	}
+	setB := make(map[string]bool)
+	for _, t := range nameTokens(b) {
+		setB[t] = true
+	}
+	if len(setA) == 0 || len(setB) == 0 {
+		return 0
+	}
+	shared := 0
+	for t := range setA {
+		if setB[t] {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(setA)+len(setB)-shared)
+}
+
+// extractPostcode returns the normalized UK postcode in an address, or ""
+func extractPostcode(address string) string {
+	match := ukPostcodePattern.FindStringSubmatch(address)
+	if match == nil {
+		return ""
+	}
+	return strings.ToUpper(match[1] + " " + match[2])
+}
+
+// sameBusinessDistance is how far apart two sources may place the same business, in metres
+const sameBusinessDistance = 250
+
+// sameBusiness reports whether two listings, usually from different sources, are the same business:
+// similar names, and locations or postcodes that agree when both sides have them
+func sameBusiness(a, b *Business) bool {
+	if normalizeName(a.Name) != normalizeName(b.Name) && tokenSetSimilarity(a.Name, b.Name) < verifyMatchThreshold {
+		return false
+	}
+	var zero maps.LatLng
+	if a.Location != zero && b.Location != zero {
+		return distanceMetres(a.Location, b.Location) <= sameBusinessDistance
+	}
+	postcodeA, postcodeB := extractPostcode(a.Address), extractPostcode(b.Address)
+	if postcodeA != "" && postcodeB != "" {
+		return postcodeA == postcodeB
+	}
+	// With nothing to place either listing, only identical names are trusted
+	return normalizeName(a.Name) == normalizeName(b.Name)
+}
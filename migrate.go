@@ -0,0 +1,314 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"log/slog"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/jomei/notionapi"
+	"golang.org/x/time/rate"
+)
+
+// blockMetadata are the read-only keys of a block that must be dropped before it is created elsewhere
+var blockMetadata = []string{"id", "created_time", "last_edited_time", "created_by", "last_edited_by",
+	"has_children", "archived", "in_trash", "parent"}
+
+// uncopyableBlocks are block types the API returns but won't create
+var uncopyableBlocks = map[string]bool{
+	"child_page": true, "child_database": true, "link_preview": true, "unsupported": true,
+	"synced_block": true, "template": true, "breadcrumb": true, "table_of_contents": true,
+}
+
+// copiedBlock is a block read from one page, re-encoded without its metadata so it can be created on another
+type copiedBlock struct {
+	notionapi.BasicBlock
+	raw json.RawMessage
+}
+
+func (cb copiedBlock) MarshalJSON() ([]byte, error) { return cb.raw, nil }
+
+// migration copies leads between two Notion databases
+type migration struct {
+	from, to *NotionClient
+	// mapping renames source properties; a mapping to "" drops the property
+	mapping map[string]string
+	// targetTypes are the property types of the destination database
+	targetTypes map[string]notionapi.PropertyConfigType
+	limiter     *rate.Limiter
+	nested      int
+}
+
+// runMigrateDB copies every lead of the configured database into another one, preserving page content
+func runMigrateDB(notionClient *NotionClient, args []string) {
+	fs := flag.NewFlagSet("migrate-db", flag.ExitOnError)
+	to := fs.String("to", "", "ID of the Notion database to copy the leads into")
+	dryRun := fs.Bool("dry-run", false, "show the schema differences and which leads would be copied, without copying")
+	mapping := make(map[string]string)
+	fs.Func("map", "rename a property while copying, e.g. Urgency=Priority, or drop it with Urgency= (repeatable)", func(value string) error {
+		from, to, ok := strings.Cut(value, "=")
+		if !ok || strings.TrimSpace(from) == "" {
+			return fmt.Errorf("expected Old=New, got %q", value)
+		}
+		mapping[strings.TrimSpace(from)] = strings.TrimSpace(to)
+		return nil
+	})
+	fs.Parse(args)
+
+	if *to == "" {
+		log.Fatal("migrate-db: --to is required")
+	}
+	if notionapi.DatabaseID(*to) == notionClient.databaseID {
+		log.Fatal("migrate-db: --to is the database being copied from")
+	}
+
+	ctx := context.Background()
+	target := NewNotionClient(os.Getenv("NOTION_API_KEY"), *to, "")
+	m := &migration{
+		from:    notionClient,
+		to:      target,
+		mapping: mapping,
+		limiter: rate.NewLimiter(notionRequestsPerSecond, 1),
+	}
+	if err := m.compareSchemas(ctx); err != nil {
+		log.Fatalf("Failed to compare database schemas: %v", err)
+	}
+
+	existing := make(map[string]bool)
+	if _, ok := m.targetTypes[m.targetName("PlaceID")]; ok {
+		err := target.EachBusiness(ctx, nil, func(b Business) error {
+			existing[b.PlaceID] = true
+			return nil
+		})
+		if err != nil {
+			log.Fatalf("Failed to list leads in the destination database: %v", err)
+		}
+	}
+
+	copied, skipped := 0, 0
+	err := notionClient.eachPage(ctx, nil, func(page *notionapi.Page) error {
+		b := businessFromPage(page)
+		if b.PlaceID != "" && existing[b.PlaceID] {
+			skipped++
+			return nil
+		}
+		if *dryRun {
+			fmt.Printf("+ %s (%s)\n", b.Name, b.LeadNumber)
+			copied++
+			return nil
+		}
+		if err := m.copyPage(ctx, page); err != nil {
+			slog.Error("Failed to copy lead", "operation", "migrate-db", "place_id", b.PlaceID, "name", b.Name, "err", err)
+			return nil
+		}
+		copied++
+		return nil
+	})
+	if err != nil {
+		log.Fatalf("Failed to list leads: %v", err)
+	}
+
+	verb := "Copied"
+	if *dryRun {
+		verb = "Would copy"
+	}
+	fmt.Printf("%s %d leads, skipped %d already in the destination\n", verb, copied, skipped)
+	if m.nested > 0 {
+		fmt.Printf("%d blocks had nested content, which was not copied\n", m.nested)
+	}
+}
+
+// targetName returns the destination name of a source property, or "" if it is dropped
+func (m *migration) targetName(name string) string {
+	if to, ok := m.mapping[name]; ok {
+		return to
+	}
+	return name
+}
+
+// compareSchemas loads the destination schema and prints how source properties map onto it
+func (m *migration) compareSchemas(ctx context.Context) error {
+	source, err := m.from.client.Database.Get(ctx, m.from.databaseID)
+	if err != nil {
+		return fmt.Errorf("source database: %w", err)
+	}
+	target, err := m.to.client.Database.Get(ctx, m.to.databaseID)
+	if err != nil {
+		return fmt.Errorf("destination database: %w", err)
+	}
+
+	m.targetTypes = make(map[string]notionapi.PropertyConfigType, len(target.Properties))
+	for name, config := range target.Properties {
+		m.targetTypes[name] = config.GetType()
+	}
+
+	names := make([]string, 0, len(source.Properties))
+	for name := range source.Properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	mapped := make(map[string]bool)
+	for _, name := range names {
+		sourceType := source.Properties[name].GetType()
+		to := m.targetName(name)
+		targetType, ok := m.targetTypes[to]
+		switch {
+		case to == "":
+			fmt.Printf("- %s: dropped by --map\n", name)
+		case !ok:
+			fmt.Printf("- %s: no %q property in the destination, not copied\n", name, to)
+		case targetType != sourceType:
+			fmt.Printf("- %s: %s in the source but %s in the destination, not copied\n", name, sourceType, targetType)
+		case to != name:
+			mapped[to] = true
+			fmt.Printf("~ %s -> %s\n", name, to)
+		default:
+			mapped[to] = true
+		}
+	}
+	for name := range m.targetTypes {
+		if !mapped[name] {
+			fmt.Printf("? %s: only in the destination, left empty\n", name)
+		}
+	}
+	return nil
+}
+
+// copyPage creates a copy of a source page, with its cover, icon and content, in the destination
+func (m *migration) copyPage(ctx context.Context, page *notionapi.Page) error {
+	props := make(notionapi.Properties)
+	for name, prop := range page.Properties {
+		to := m.targetName(name)
+		if to == "" || string(m.targetTypes[to]) != string(prop.GetType()) {
+			continue
+		}
+		if value, ok := writableProperty(prop); ok {
+			props[to] = value
+		}
+	}
+
+	children, err := m.copyBlocks(ctx, page.ID.String())
+	if err != nil {
+		return fmt.Errorf("reading page content: %w", err)
+	}
+
+	req := &notionapi.PageCreateRequest{
+		Parent:     notionapi.Parent{DatabaseID: m.to.databaseID},
+		Properties: props,
+		Children:   children,
+		Icon:       page.Icon,
+	}
+	// Notion-hosted files have expiring URLs, so only external covers survive the move
+	if page.Cover != nil && page.Cover.External != nil {
+		req.Cover = &notionapi.Image{Type: notionapi.FileTypeExternal, External: page.Cover.External}
+	}
+	if err := m.limiter.Wait(ctx); err != nil {
+		return err
+	}
+	_, err = m.to.client.Page.Create(ctx, req)
+	return err
+}
+
+// copyBlocks reads the top-level blocks of a page and strips them for re-creation
+func (m *migration) copyBlocks(ctx context.Context, pageID string) ([]notionapi.Block, error) {
+	var blocks []notionapi.Block
+	pagination := &notionapi.Pagination{PageSize: 100}
+	for {
+		if err := m.limiter.Wait(ctx); err != nil {
+			return nil, err
+		}
+		res, err := m.from.client.Block.GetChildren(ctx, notionapi.BlockID(pageID), pagination)
+		if err != nil {
+			return nil, err
+		}
+		for _, block := range res.Results {
+			if uncopyableBlocks[string(block.GetType())] {
+				continue
+			}
+			if block.GetHasChildren() {
+				m.nested++
+			}
+			copied, err := stripBlock(block)
+			if err != nil {
+				return nil, err
+			}
+			blocks = append(blocks, copied)
+		}
+		if !res.HasMore {
+			return blocks, nil
+		}
+		pagination.StartCursor = notionapi.Cursor(res.NextCursor)
+	}
+}
+
+// stripBlock removes the metadata of a block read from the API
+func stripBlock(block notionapi.Block) (notionapi.Block, error) {
+	data, err := json.Marshal(block)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	for _, key := range blockMetadata {
+		delete(fields, key)
+	}
+	raw, err := json.Marshal(fields)
+	if err != nil {
+		return nil, err
+	}
+	return copiedBlock{BasicBlock: notionapi.BasicBlock{Type: block.GetType()}, raw: raw}, nil
+}
+
+// writableProperty converts a property value read from a page into one that can be written to
+// another database. Computed properties, relations and empty values are not copied.
+func writableProperty(prop notionapi.Property) (notionapi.Property, bool) {
+	switch p := prop.(type) {
+	case *notionapi.TitleProperty:
+		return notionapi.TitleProperty{Title: writableRichText(p.Title)}, len(p.Title) > 0
+	case *notionapi.RichTextProperty:
+		return notionapi.RichTextProperty{RichText: writableRichText(p.RichText)}, len(p.RichText) > 0
+	case *notionapi.NumberProperty:
+		return notionapi.NumberProperty{Number: p.Number}, true
+	case *notionapi.CheckboxProperty:
+		return notionapi.CheckboxProperty{Checkbox: p.Checkbox}, true
+	case *notionapi.SelectProperty:
+		return notionapi.SelectProperty{Select: notionapi.Option{Name: p.Select.Name}}, p.Select.Name != ""
+	case *notionapi.MultiSelectProperty:
+		var options []notionapi.Option
+		for _, option := range p.MultiSelect {
+			options = append(options, notionapi.Option{Name: option.Name})
+		}
+		return notionapi.MultiSelectProperty{MultiSelect: options}, len(options) > 0
+	case *notionapi.DateProperty:
+		return notionapi.DateProperty{Date: p.Date}, p.Date != nil
+	case *notionapi.URLProperty:
+		return notionapi.URLProperty{URL: p.URL}, p.URL != ""
+	case *notionapi.EmailProperty:
+		return notionapi.EmailProperty{Email: p.Email}, p.Email != ""
+	case *notionapi.PhoneNumberProperty:
+		return notionapi.PhoneNumberProperty{PhoneNumber: p.PhoneNumber}, p.PhoneNumber != ""
+	}
+	return nil, false
+}
+
+// writableRichText keeps the text and formatting of rich text, turning mentions into plain text
+// since the pages they point at may not exist in the destination workspace
+func writableRichText(rich []notionapi.RichText) []notionapi.RichText {
+	out := make([]notionapi.RichText, 0, len(rich))
+	for _, rt := range rich {
+		text := rt.Text
+		if text == nil {
+			text = &notionapi.Text{Content: rt.PlainText}
+		}
+		out = append(out, notionapi.RichText{Text: text, Annotations: rt.Annotations})
+	}
+	return out
+}
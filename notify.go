@@ -0,0 +1,175 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"time"
+)
+
+// Notification event types
+const (
+	// eventRunFinished is sent when a search run completes, with its summary
+	eventRunFinished = "run_finished"
+	// eventHotLead is sent for every High urgency lead inserted
+	eventHotLead = "hot_lead"
+	// eventError is sent when a run hits an error that loses results, e.g. a failed source
+	eventError = "error"
+)
+
+var eventTypes = []string{eventRunFinished, eventHotLead, eventError}
+
+// Event is something a run tells its notifiers about
+type Event struct {
+	Type string
+	// Summary is a one-line description of the event
+	Summary string
+	// Lead is set for hot_lead events
+	Lead *Business
+	// Digest is set for run_finished events
+	Digest *RunDigest
+}
+
+// Notifier delivers events to one channel
+type Notifier interface {
+	Name() string
+	Notify(ctx context.Context, event Event) error
+}
+
+// NotifierConfig declares one notification channel and the events it receives
+type NotifierConfig struct {
+	// Type is "webhook", "slack", "discord", "email" or "desktop"
+	Type string `json:"type"`
+	// URL is where webhook notifications are posted, or the Slack or Discord webhook URL
+	URL string `json:"url,omitempty"`
+	// To are the recipients of email notifications, sent through the config's smtp server
+	To []string `json:"to,omitempty"`
+	// Events are the event types sent to the channel; all of them when omitted
+	Events []string `json:"events,omitempty"`
+}
+
+// Validate checks that the notifier type is known and its events exist
+func (nc NotifierConfig) Validate() error {
+	switch nc.Type {
+	case "webhook", "slack", "discord":
+		if nc.URL == "" {
+			return fmt.Errorf("%s notifier needs a url", nc.Type)
+		}
+	case "email":
+		if len(nc.To) == 0 {
+			return fmt.Errorf("email notifier needs recipients in to")
+		}
+	case "desktop":
+	default:
+		return fmt.Errorf("unknown notifier type %q", nc.Type)
+	}
+	for _, event := range nc.Events {
+		if !slices.Contains(eventTypes, event) {
+			return fmt.Errorf("unknown event %q, expected one of %v", event, eventTypes)
+		}
+	}
+	return nil
+}
+
+// Notifications routes events to the notifiers configured for them
+type Notifications struct {
+	routes []notifierRoute
+}
+
+type notifierRoute struct {
+	notifier Notifier
+	events   []string
+}
+
+// NewNotifications creates the configured notifiers; email notifiers send through smtp
+func NewNotifications(configs []NotifierConfig, smtp *SMTPConfig) *Notifications {
+	n := &Notifications{}
+	for _, config := range configs {
+		var notifier Notifier
+		switch config.Type {
+		case "webhook":
+			notifier = NewWebhookNotifier(config.URL)
+		case "slack":
+			notifier = NewSlackNotifier(config.URL)
+		case "discord":
+			notifier = NewDiscordNotifier(config.URL)
+		case "email":
+			notifier = NewEmailNotifier(smtp, config.To)
+		case "desktop":
+			notifier = DesktopNotifier{}
+		}
+		events := config.Events
+		if len(events) == 0 {
+			events = eventTypes
+		}
+		n.routes = append(n.routes, notifierRoute{notifier: notifier, events: events})
+	}
+	return n
+}
+
+// Send delivers an event to every notifier that wants it. Failed deliveries are logged and don't
+// stop the run; a nil Notifications sends nothing.
+func (n *Notifications) Send(ctx context.Context, event Event) {
+	if n == nil {
+		return
+	}
+	for _, route := range n.routes {
+		if !slices.Contains(route.events, event.Type) {
+			continue
+		}
+		if err := route.notifier.Notify(ctx, event); err != nil {
+			slog.Error("Failed to send notification", "operation", "notify", "event", event.Type, "notifier", route.notifier.Name(), "err", err)
+		}
+	}
+}
+
+// WebhookNotifier posts events as JSON to a URL
+type WebhookNotifier struct {
+	url    string
+	client *http.Client
+}
+
+// NewWebhookNotifier initializes a new WebhookNotifier
+func NewWebhookNotifier(url string) *WebhookNotifier {
+	return &WebhookNotifier{url: url, client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+func (wn *WebhookNotifier) Name() string { return "webhook" }
+
+// Notify posts the event, with the lead's merge variables for hot_lead events
+func (wn *WebhookNotifier) Notify(ctx context.Context, event Event) error {
+	payload := map[string]any{
+		"event":   event.Type,
+		"summary": event.Summary,
+	}
+	if event.Lead != nil {
+		payload["lead"] = templateData(*event.Lead)
+	}
+	return postJSON(ctx, wn.client, wn.url, payload)
+}
+
+// postJSON posts a JSON body, treating any non-2xx response as an error
+func postJSON(ctx context.Context, client *http.Client, url string, body any) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,240 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/jomei/notionapi"
+)
+
+// ListBusinesses returns every business in the Notion database matching the filter, following pagination
+func (nc *NotionClient) ListBusinesses(ctx context.Context, filter notionapi.Filter) ([]Business, error) {
+	var businesses []Business
+	err := nc.EachBusiness(ctx, filter, func(b Business) error {
+		businesses = append(businesses, b)
+		return nil
+	})
+	return businesses, err
+}
+
+// EachBusiness calls fn for every business matching the filter, one result page at a time, so
+// callers that don't need the whole database in memory can stream through it
+func (nc *NotionClient) EachBusiness(ctx context.Context, filter notionapi.Filter, fn func(Business) error) error {
+	return nc.eachPage(ctx, filter, func(page *notionapi.Page) error {
+		return fn(businessFromPage(page))
+	})
+}
+
+// eachPage calls fn for every database page matching the filter, one result page at a time
+func (nc *NotionClient) eachPage(ctx context.Context, filter notionapi.Filter, fn func(*notionapi.Page) error) error {
+	query := &notionapi.DatabaseQueryRequest{
+		Filter:   filter,
+		PageSize: 100,
+	}
+	for {
+		res, err := nc.client.Database.Query(ctx, nc.databaseID, query)
+		if err != nil {
+			return err
+		}
+		for i := range res.Results {
+			if err := fn(&res.Results[i]); err != nil {
+				return err
+			}
+		}
+		if !res.HasMore {
+			return nil
+		}
+		query.StartCursor = res.NextCursor
+	}
+}
+
+// UpdateBusiness writes the given properties to an existing business page
+func (nc *NotionClient) UpdateBusiness(ctx context.Context, pageID string, properties notionapi.Properties) error {
+	_, err := nc.client.Page.Update(ctx, notionapi.PageID(pageID), &notionapi.PageUpdateRequest{
+		Properties: properties,
+	})
+	return err
+}
+
+// businessFromPage converts a database page back into a Business
+func businessFromPage(page *notionapi.Page) Business {
+	business := Business{
+		PageID:     page.ID.String(),
+		PageURL:    page.URL,
+		Created:    page.CreatedTime,
+		LeadNumber: leadNumber(page),
+	}
+	for name, prop := range page.Properties {
+		switch p := prop.(type) {
+		case *notionapi.TitleProperty:
+			if name == "Name" {
+				business.Name = plainText(p.Title)
+			}
+		case *notionapi.RichTextProperty:
+			switch name {
+			case "Address":
+				business.Address = plainText(p.RichText)
+			case "PlaceID":
+				business.PlaceID = plainText(p.RichText)
+			case "Street":
+				business.Street = plainText(p.RichText)
+			case "Postcode":
+				business.Postcode = plainText(p.RichText)
+			case "OpeningHours":
+				business.OpeningHours = plainText(p.RichText)
+			case "ReviewThemes":
+				business.ReviewThemes = plainText(p.RichText)
+			case "CompanyNumber":
+				business.CompanyNumber = plainText(p.RichText)
+			case "RegisteredAddress":
+				business.RegisteredAddress = plainText(p.RichText)
+			case "Trend":
+				business.Trend = plainText(p.RichText)
+			case "DuplicateOf":
+				business.DuplicateOf = plainText(p.RichText)
+			case "Provenance":
+				business.Provenance = parseProvenance(plainText(p.RichText))
+			case "SuggestedDomain":
+				business.SuggestedDomain = plainText(p.RichText)
+			case "Registrar":
+				business.Registrar = plainText(p.RichText)
+			case "UnclaimedSignals":
+				business.UnclaimedSignals = splitList(plainText(p.RichText))
+			case "SearchKeyword":
+				business.SearchKeyword = plainText(p.RichText)
+			case "SearchCell":
+				business.SearchCell = plainText(p.RichText)
+			case "RunID":
+				business.RunID = plainText(p.RichText)
+			}
+		case *notionapi.MultiSelectProperty:
+			for _, option := range p.MultiSelect {
+				switch name {
+				case "Type":
+					business.Type = append(business.Type, option.Name)
+				case "Sources":
+					business.Sources = append(business.Sources, option.Name)
+				}
+			}
+		case *notionapi.SelectProperty:
+			switch name {
+			case "WebsiteStatus":
+				business.WebsiteStatus = p.Select.Name
+			case "BusinessStatus":
+				business.BusinessStatus = p.Select.Name
+			case "City":
+				business.City = p.Select.Name
+			case "Country":
+				business.Country = p.Select.Name
+			case "Urgency":
+				business.Urgency = p.Select.Name
+			case "Contacted":
+				business.Contacted = p.Select.Name
+			case "AssignedTo":
+				business.AssignedTo = p.Select.Name
+			case "SearchArea":
+				business.SearchArea = p.Select.Name
+			case "Campaign":
+				business.Campaign = p.Select.Name
+			case "SearchedType":
+				business.SearchedType = p.Select.Name
+			case "CompanyStatus":
+				business.CompanyStatus = p.Select.Name
+			case "NextAction":
+				business.NextAction = p.Select.Name
+			case "FirstSource":
+				business.FirstSource = p.Select.Name
+			}
+		case *notionapi.URLProperty:
+			switch name {
+			case "URL":
+				business.URL = p.URL
+			case "Facebook":
+				business.Facebook = p.URL
+			case "Instagram":
+				business.Instagram = p.URL
+			case "LinkedIn":
+				business.LinkedIn = p.URL
+			case "X":
+				business.X = p.URL
+			}
+		case *notionapi.EmailProperty:
+			if name == "Email" {
+				business.Email = p.Email
+			}
+		case *notionapi.PhoneNumberProperty:
+			if name == "Phone" {
+				business.Phone = p.PhoneNumber
+			}
+		case *notionapi.RelationProperty:
+			if name == "Contacts" {
+				for _, relation := range p.Relation {
+					business.Contacts = append(business.Contacts, relation.ID.String())
+				}
+			}
+		case *notionapi.NumberProperty:
+			switch name {
+			case "Rating":
+				business.Rating = p.Number
+			case "ReviewCount":
+				business.ReviewCount = int(p.Number)
+			case "LeadScore":
+				business.LeadScore = int(p.Number)
+			case "EmailConfidence":
+				business.EmailConfidence = int(p.Number)
+			case "Latitude":
+				business.Location.Lat = p.Number
+			case "Longitude":
+				business.Location.Lng = p.Number
+			case "DistanceKm":
+				business.DistanceKm = p.Number
+			case "CadenceStep":
+				business.CadenceStep = int(p.Number)
+			}
+		case *notionapi.CheckboxProperty:
+			switch name {
+			case "HoursListed":
+				business.HoursListed = p.Checkbox
+			case "LikelyUnclaimed":
+				business.LikelyUnclaimed = p.Checkbox
+			case "SSL":
+				business.HTTPS = p.Checkbox
+			}
+		case *notionapi.DateProperty:
+			if p.Date == nil || p.Date.Start == nil {
+				break
+			}
+			switch name {
+			case "AssignedDate":
+				business.AssignedDate = time.Time(*p.Date.Start)
+			case "IncorporationDate":
+				business.IncorporationDate = time.Time(*p.Date.Start)
+			case "DomainRegistered":
+				business.DomainRegistered = time.Time(*p.Date.Start)
+			case "LastActionDate":
+				business.LastActionDate = time.Time(*p.Date.Start)
+			case "NextActionDate":
+				business.NextActionDate = time.Time(*p.Date.Start)
+			case "LastContacted":
+				business.LastContacted = time.Time(*p.Date.Start)
+			case "NextFollowUp":
+				business.NextFollowUp = time.Time(*p.Date.Start)
+			}
+		}
+	}
+	return business
+}
+
+// plainText joins the plain text of a rich text array
+func plainText(rich []notionapi.RichText) string {
+	var sb strings.Builder
+	for _, r := range rich {
+		if r.PlainText != "" {
+			sb.WriteString(r.PlainText)
+		} else if r.Text != nil {
+			sb.WriteString(r.Text.Content)
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/jomei/notionapi"
+)
+
+const (
+	// maxSelectOptions is how many options a select or multi-select property may accumulate before
+	// new values are coalesced. Notion degrades and eventually rejects writes well before it stops
+	// accepting options, so we stay comfortably under its limit.
+	maxSelectOptions = 100
+	// maxOptionNameLength is Notion's limit on the length of an option name
+	maxOptionNameLength = 100
+	// overflowOption is the option that values are coalesced into once a property is full
+	overflowOption = "Other"
+)
+
+// OptionLimiter tracks select and multi-select options per property, capping how many distinct values
+// are written and recording which values were coalesced into overflowOption.
+type OptionLimiter struct {
+	mu        sync.Mutex
+	limit     int
+	known     map[string]map[string]bool
+	coalesced map[string]map[string]int
+}
+
+// NewOptionLimiter initializes an OptionLimiter that allows up to limit options per property
+func NewOptionLimiter(limit int) *OptionLimiter {
+	return &OptionLimiter{
+		limit:     limit,
+		known:     make(map[string]map[string]bool),
+		coalesced: make(map[string]map[string]int),
+	}
+}
+
+// Seed registers options that already exist on a property
+func (ol *OptionLimiter) Seed(property string, options []notionapi.Option) {
+	ol.mu.Lock()
+	defer ol.mu.Unlock()
+	for _, option := range options {
+		ol.knownFor(property)[option.Name] = true
+	}
+}
+
+// Normalize cleans values for a property and coalesces any that would push it past the option limit
+func (ol *OptionLimiter) Normalize(property string, values []string) []string {
+	ol.mu.Lock()
+	defer ol.mu.Unlock()
+
+	known := ol.knownFor(property)
+	seen := make(map[string]bool)
+	var result []string
+	for _, raw := range values {
+		value := cleanOptionName(raw)
+		if value == "" {
+			continue
+		}
+		if !known[value] {
+			if len(known) >= ol.limit && value != overflowOption {
+				if ol.coalesced[property] == nil {
+					ol.coalesced[property] = make(map[string]int)
+				}
+				ol.coalesced[property][value]++
+				value = overflowOption
+			}
+			known[value] = true
+		}
+		if !seen[value] {
+			seen[value] = true
+			result = append(result, value)
+		}
+	}
+	return result
+}
+
+// NormalizeOne is Normalize for a single select value
+func (ol *OptionLimiter) NormalizeOne(property, value string) string {
+	values := ol.Normalize(property, []string{value})
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
+
+// Report describes every value that was coalesced into overflowOption, or "" if none were
+func (ol *OptionLimiter) Report() string {
+	ol.mu.Lock()
+	defer ol.mu.Unlock()
+
+	if len(ol.coalesced) == 0 {
+		return ""
+	}
+	var properties []string
+	for property := range ol.coalesced {
+		properties = append(properties, property)
+	}
+	sort.Strings(properties)
+
+	var sb strings.Builder
+	for _, property := range properties {
+		var values []string
+		for value, count := range ol.coalesced[property] {
+			values = append(values, fmt.Sprintf("%s (%d)", value, count))
+		}
+		sort.Strings(values)
+		fmt.Fprintf(&sb, "%s: %d option limit reached, coalesced into %q: %s\n", property, ol.limit, overflowOption, strings.Join(values, ", "))
+	}
+	return sb.String()
+}
+
+// knownFor returns the known options of a property; ol.mu must be held
+func (ol *OptionLimiter) knownFor(property string) map[string]bool {
+	if ol.known[property] == nil {
+		ol.known[property] = make(map[string]bool)
+	}
+	return ol.known[property]
+}
+
+// cleanOptionName makes a value acceptable as a Notion option name, which may not contain commas
+func cleanOptionName(value string) string {
+	value = strings.TrimSpace(strings.ReplaceAll(value, ",", " "))
+	if runes := []rune(value); len(runes) > maxOptionNameLength {
+		value = strings.TrimSpace(string(runes[:maxOptionNameLength]))
+	}
+	return value
+}
+
+// LoadOptions seeds the option limiter with the options already defined on the database's select properties
+func (nc *NotionClient) LoadOptions(ctx context.Context) error {
+	db, err := nc.client.Database.Get(ctx, nc.databaseID)
+	if err != nil {
+		return err
+	}
+	for name, config := range db.Properties {
+		switch c := config.(type) {
+		case *notionapi.SelectPropertyConfig:
+			nc.options.Seed(name, c.Select.Options)
+		case *notionapi.MultiSelectPropertyConfig:
+			nc.options.Seed(name, c.MultiSelect.Options)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,19 @@
+package main
+
+import (
+	"sort"
+	"strings"
+)
+
+// stableOrder makes exports and reports list records in a deterministic order (set by --stable-order)
+var stableOrder bool
+
+// sortBusinesses orders businesses by PlaceID, falling back to name for records without one
+func sortBusinesses(businesses []Business) {
+	sort.SliceStable(businesses, func(i, j int) bool {
+		if businesses[i].PlaceID != businesses[j].PlaceID {
+			return businesses[i].PlaceID < businesses[j].PlaceID
+		}
+		return strings.ToLower(businesses[i].Name) < strings.ToLower(businesses[j].Name)
+	})
+}
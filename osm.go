@@ -0,0 +1,250 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"googlemaps.github.io/maps"
+)
+
+// overpassURL is the public Overpass API instance OSM data is queried from
+const overpassURL = "https://overpass-api.de/api/interpreter"
+
+// osmTags map Google place types onto the OpenStreetMap tags that describe the same businesses.
+// Types can also be given as raw tags, e.g. "shop=surf".
+var osmTags = map[maps.PlaceType][]string{
+	maps.PlaceTypeArtGallery:        {"tourism=gallery", "shop=art"},
+	maps.PlaceTypeBakery:            {"shop=bakery"},
+	maps.PlaceTypeBank:              {"amenity=bank"},
+	maps.PlaceTypeBar:               {"amenity=bar", "amenity=pub"},
+	maps.PlaceTypeBeautySalon:       {"shop=beauty"},
+	maps.PlaceTypeBicycleStore:      {"shop=bicycle"},
+	maps.PlaceTypeBookStore:         {"shop=books"},
+	maps.PlaceTypeBowlingAlley:      {"leisure=bowling_alley"},
+	maps.PlaceTypeCafe:              {"amenity=cafe"},
+	maps.PlaceTypeCampground:        {"tourism=camp_site"},
+	maps.PlaceTypeClothingStore:     {"shop=clothes"},
+	maps.PlaceTypeConvenienceStore:  {"shop=convenience"},
+	maps.PlaceTypeDepartmentStore:   {"shop=department_store"},
+	maps.PlaceTypeElectrician:       {"craft=electrician"},
+	maps.PlaceTypeElectronicsStore:  {"shop=electronics"},
+	maps.PlaceTypeFlorist:           {"shop=florist"},
+	maps.PlaceTypeFuneralHome:       {"shop=funeral_directors"},
+	maps.PlaceTypeGym:               {"leisure=fitness_centre"},
+	maps.PlaceTypeHairCare:          {"shop=hairdresser"},
+	maps.PlaceTypeHomeGoodsStore:    {"shop=houseware", "shop=furniture"},
+	maps.PlaceTypeJewelryStore:      {"shop=jewelry"},
+	maps.PlaceTypeLaundry:           {"shop=laundry", "shop=dry_cleaning"},
+	maps.PlaceTypeLiquorStore:       {"shop=alcohol"},
+	maps.PlaceTypeLocksmith:         {"shop=locksmith", "craft=locksmith"},
+	maps.PlaceTypeLodging:           {"tourism=hotel", "tourism=guest_house"},
+	maps.PlaceTypeMealTakeaway:      {"amenity=fast_food"},
+	maps.PlaceTypeMuseum:            {"tourism=museum"},
+	maps.PlaceTypeNightClub:         {"amenity=nightclub"},
+	maps.PlaceTypePainter:           {"craft=painter"},
+	maps.PlaceTypePetStore:          {"shop=pet"},
+	maps.PlaceTypePhysiotherapist:   {"healthcare=physiotherapist"},
+	maps.PlaceTypePlumber:           {"craft=plumber"},
+	maps.PlaceTypeRestaurant:        {"amenity=restaurant"},
+	maps.PlaceTypeRoofingContractor: {"craft=roofer"},
+	maps.PlaceTypeShoeStore:         {"shop=shoes"},
+	maps.PlaceTypeSpa:               {"leisure=spa", "shop=massage"},
+	maps.PlaceTypeSupermarket:       {"shop=supermarket"},
+	maps.PlaceTypeTravelAgency:      {"shop=travel_agency"},
+	maps.PlaceTypeVeterinaryCare:    {"amenity=veterinary"},
+	maps.PlaceTypeStore:             {"shop"},
+	maps.PlaceTypeMovingCompany:     {"office=moving_company"},
+	maps.PlaceTypeStorage:           {"shop=storage_rental"},
+	maps.PlaceTypeRvPark:            {"tourism=caravan_site"},
+	maps.PlaceTypeShoppingMall:      {"shop=mall"},
+	maps.PlaceTypeMealDelivery:      {"amenity=fast_food"},
+	maps.PlaceTypeLibrary:           {"amenity=library"},
+	maps.PlaceTypeMovieRental:       {"shop=video"},
+}
+
+// OSMSource finds businesses in OpenStreetMap through the Overpass API, which is free and needs
+// no key. OSM often lists websites, emails and opening hours, but its coverage is uneven, so a
+// missing website is recorded as "Unknown Website" rather than "No Website".
+type OSMSource struct {
+	endpoint string
+	client   *http.Client
+}
+
+// NewOSMSource initializes a new OSMSource
+func NewOSMSource() *OSMSource {
+	return &OSMSource{
+		endpoint: overpassURL,
+		client:   &http.Client{Timeout: 3 * time.Minute},
+	}
+}
+
+func (osm *OSMSource) Name() string { return "osm" }
+
+// osmElement is a node, way or relation returned by Overpass
+type osmElement struct {
+	Type   string  `json:"type"`
+	ID     int64   `json:"id"`
+	Lat    float64 `json:"lat"`
+	Lon    float64 `json:"lon"`
+	Center *struct {
+		Lat float64 `json:"lat"`
+		Lon float64 `json:"lon"`
+	} `json:"center"`
+	Tags map[string]string `json:"tags"`
+}
+
+// Search queries the whole area once per search type; Overpass has no result cap, so grid cells
+// aren't needed
+func (osm *OSMSource) Search(ctx context.Context, area *SearchArea, fn func(*Business)) error {
+	progress.Verbosef("Searching %s on OpenStreetMap\n", area.Name)
+	seen := make(map[string]struct{})
+	for _, searchType := range area.Types {
+		tags := osmTags[searchType.Type]
+		if strings.Contains(string(searchType.Type), "=") {
+			tags = []string{string(searchType.Type)}
+		}
+		if len(tags) == 0 {
+			slog.Warn("No OpenStreetMap tags for place type, skipping", "operation", "search", "source", "osm", "place_type", searchType.String())
+			continue
+		}
+		progress.Stage(fmt.Sprintf("osm %s", searchType), 1)
+		if !progress.Proceed(ctx) {
+			continue
+		}
+
+		typeArea := area.forType(searchType)
+		elements, err := osm.query(ctx, overpassQuery(typeArea, tags, searchType.Keyword))
+		if err != nil {
+			return fmt.Errorf("searching for %s: %w", searchType, err)
+		}
+		for _, el := range elements {
+			id := el.Type + "/" + fmt.Sprint(el.ID)
+			if _, ok := seen[id]; ok || el.Tags["name"] == "" {
+				continue
+			}
+			seen[id] = struct{}{}
+			business := el.business(area, id, searchType)
+			if !area.InBoundary(business.Location) {
+				continue
+			}
+			business.tagSearch(searchType, SearchCell{Center: typeArea.Location, Radius: typeArea.Radius})
+			fn(business)
+		}
+		progress.Step()
+	}
+	return nil
+}
+
+// overpassQuery builds a query for named nodes and ways with any of the tags around the area's
+// centre. A keyword narrows the results to names containing it.
+func overpassQuery(area *SearchArea, tags []string, keyword string) string {
+	var sb strings.Builder
+	sb.WriteString("[out:json][timeout:120];(")
+	for _, tag := range tags {
+		key, value, hasValue := strings.Cut(tag, "=")
+		filter := fmt.Sprintf("[%q]", key)
+		if hasValue {
+			filter = fmt.Sprintf("[%q=%q]", key, value)
+		}
+		filter += `["name"]`
+		if keyword != "" {
+			filter += fmt.Sprintf(`["name"~%q,i]`, keyword)
+		}
+		around := fmt.Sprintf("(around:%d,%f,%f)", area.Radius, area.Location.Lat, area.Location.Lng)
+		fmt.Fprintf(&sb, "node%s%s;way%s%s;", filter, around, filter, around)
+	}
+	sb.WriteString(");out center tags;")
+	return sb.String()
+}
+
+func (osm *OSMSource) query(ctx context.Context, query string) ([]osmElement, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, osm.endpoint, strings.NewReader(url.Values{"data": {query}}.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("User-Agent", "business-finder (+https://github.com/bognar-dev/business-finder)")
+	resp, err := osm.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("overpass: unexpected status %d", resp.StatusCode)
+	}
+	var res struct {
+		Elements []osmElement `json:"elements"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&res); err != nil {
+		return nil, err
+	}
+	return res.Elements, nil
+}
+
+// tag returns the first of the given tags that is set
+func (el osmElement) tag(keys ...string) string {
+	for _, key := range keys {
+		if value := strings.TrimSpace(el.Tags[key]); value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// business converts an OSM element to a Business
+func (el osmElement) business(area *SearchArea, id string, searchType SearchType) *Business {
+	location := maps.LatLng{Lat: el.Lat, Lng: el.Lon}
+	if el.Center != nil {
+		location = maps.LatLng{Lat: el.Center.Lat, Lng: el.Center.Lon}
+	}
+
+	var address []string
+	street := strings.TrimSpace(el.tag("addr:housenumber") + " " + el.tag("addr:street"))
+	for _, part := range []string{street, el.tag("addr:city"), el.tag("addr:postcode")} {
+		if part != "" {
+			address = append(address, part)
+		}
+	}
+
+	business := &Business{
+		Name:          el.tag("name"),
+		Address:       strings.Join(address, ", "),
+		PlaceID:       "osm:" + id,
+		Type:          []string{string(searchType.Type)},
+		WebsiteStatus: "Unknown Website",
+		Email:         el.tag("email", "contact:email"),
+		Phone:         normalizePhone("", el.tag("phone", "contact:phone")),
+		ListedPhone:   el.tag("phone", "contact:phone"),
+		OpeningHours:  el.tag("opening_hours"),
+		HoursListed:   el.tag("opening_hours") != "",
+		Street:        street,
+		City:          el.tag("addr:city"),
+		Postcode:      el.tag("addr:postcode"),
+		Country:       el.tag("addr:country"),
+		SearchArea:    area.Name,
+		Location:      location,
+	}
+	business.Email, _ = validateEmail(business.Email)
+	for _, link := range []string{el.tag("contact:facebook", "facebook"), el.tag("contact:instagram", "instagram")} {
+		business.SetSocials(classifySocialURL(link))
+	}
+	if website := el.tag("website", "contact:website", "url"); website != "" {
+		if !strings.Contains(website, "://") {
+			website = "https://" + website
+		}
+		// Some businesses list a social profile as their website
+		if profile := classifySocialURL(website); !profile.IsEmpty() {
+			business.SetSocials(profile)
+		} else {
+			business.WebsiteStatus = "Has Website"
+			business.URL = website
+		}
+	}
+	return business
+}
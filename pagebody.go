@@ -0,0 +1,108 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/jomei/notionapi"
+	"googlemaps.github.io/maps"
+)
+
+const (
+	// maxReviewSnippets is how many reviews are quoted on a lead's page
+	maxReviewSnippets = 3
+	// maxSnippetLength caps a quoted review, in characters
+	maxSnippetLength = 300
+)
+
+// reviewSnippet is an excerpt of one review, quoted on the lead's page
+type reviewSnippet struct {
+	Rating int
+	Text   string
+}
+
+// mapsURL links to the business on Google Maps, by its Place ID when Google listed it
+func mapsURL(b *Business) string {
+	query := url.Values{"api": {"1"}, "query": {strings.Trim(b.Name+", "+b.Address, ", ")}}
+	if b.PlaceID != "" && !strings.Contains(b.PlaceID, ":") {
+		query.Set("query_place_id", b.PlaceID)
+	}
+	return "https://www.google.com/maps/search/?" + query.Encode()
+}
+
+// detailsBlocks lay the business out at the top of its page, so the page is useful on its own:
+// its name, the details the selector allows, a map and what reviewers said
+func detailsBlocks(b *Business, fields FieldSelector) []notionapi.Block {
+	blocks := []notionapi.Block{notionapi.Heading2Block{
+		BasicBlock: notionapi.BasicBlock{Object: notionapi.ObjectTypeBlock, Type: notionapi.BlockTypeHeading2},
+		Heading2:   notionapi.Heading{RichText: richText(b.Name)},
+	}}
+	detail := func(field, label, value string) {
+		if value == "" || !fields.Allows(field) {
+			return
+		}
+		text := append([]notionapi.RichText{{
+			Text:        &notionapi.Text{Content: label + ": "},
+			Annotations: &notionapi.Annotations{Bold: true},
+		}}, richText(value)...)
+		blocks = append(blocks, notionapi.BulletedListItemBlock{
+			BasicBlock:       notionapi.BasicBlock{Object: notionapi.ObjectTypeBlock, Type: notionapi.BlockTypeBulletedListItem},
+			BulletedListItem: notionapi.ListItem{RichText: text},
+		})
+	}
+	detail("Address", "Address", b.Address)
+	detail("Phone", "Phone", b.Phone)
+	if b.Rating > 0 {
+		detail("Rating", "Rating", fmt.Sprintf("%.1f from %d reviews", b.Rating, b.ReviewCount))
+	}
+	// Leads without a website have their Maps search as URL, which the embed below already shows
+	if b.WebsiteStatus == "No Website" {
+		detail("WebsiteStatus", "Website", b.WebsiteStatus)
+	} else {
+		detail("WebsiteStatus", "Website", strings.TrimSpace(b.WebsiteStatus+" "+b.URL))
+	}
+	detail("OpeningHours", "Opening hours", strings.ReplaceAll(b.OpeningHours, "\n", "; "))
+
+	blocks = append(blocks, notionapi.EmbedBlock{
+		BasicBlock: notionapi.BasicBlock{Object: notionapi.ObjectTypeBlock, Type: notionapi.BlockTypeEmbed},
+		Embed:      notionapi.Embed{URL: mapsURL(b), Caption: richText("Google Maps")},
+	})
+
+	if len(b.ReviewSnippets) > 0 && fields.Allows("ReviewSnippets") {
+		blocks = append(blocks, notionapi.Heading3Block{
+			BasicBlock: notionapi.BasicBlock{Object: notionapi.ObjectTypeBlock, Type: notionapi.BlockTypeHeading3},
+			Heading3:   notionapi.Heading{RichText: richText("Reviews")},
+		})
+		for _, snippet := range b.ReviewSnippets {
+			blocks = append(blocks, notionapi.QuoteBlock{
+				BasicBlock: notionapi.BasicBlock{Object: notionapi.ObjectTypeBlock, Type: notionapi.BlockQuote},
+				Quote:      notionapi.Quote{RichText: richText(fmt.Sprintf("%s %s", strings.Repeat("★", snippet.Rating), snippet.Text))},
+			})
+		}
+	}
+	return blocks
+}
+
+// reviewSnippets excerpts the first reviews with text, cut at a word near maxSnippetLength
+func reviewSnippets(reviews []maps.PlaceReview) []reviewSnippet {
+	var snippets []reviewSnippet
+	for _, review := range reviews {
+		text := strings.Join(strings.Fields(review.Text), " ")
+		if text == "" {
+			continue
+		}
+		if runes := []rune(text); len(runes) > maxSnippetLength {
+			text = string(runes[:maxSnippetLength])
+			if i := strings.LastIndex(text, " "); i > maxSnippetLength/2 {
+				text = text[:i]
+			}
+			text += "…"
+		}
+		snippets = append(snippets, reviewSnippet{Rating: review.Rating, Text: text})
+		if len(snippets) == maxReviewSnippets {
+			break
+		}
+	}
+	return snippets
+}
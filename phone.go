@@ -0,0 +1,60 @@
+package main
+
+import "strings"
+
+// defaultCallingCode is the country calling code assumed for national-format numbers
+const defaultCallingCode = "44"
+
+// normalizePhone converts a Place Details phone number to E.164, preferring the international format.
+// It returns an empty string when neither number can be normalized.
+func normalizePhone(international, national string) string {
+	if e164 := digitsE164(international); e164 != "" {
+		return e164
+	}
+
+	digits := onlyDigits(national)
+	switch {
+	case strings.HasPrefix(strings.TrimSpace(national), "+"):
+		return digitsE164(national)
+	case strings.HasPrefix(digits, "00"):
+		digits = digits[2:]
+	case strings.HasPrefix(digits, "0"):
+		digits = defaultCallingCode + digits[1:]
+	case digits != "":
+		digits = defaultCallingCode + digits
+	}
+	if !validE164Length(digits) {
+		return ""
+	}
+	return "+" + digits
+}
+
+// digitsE164 normalizes a number that already carries a leading + and country code
+func digitsE164(number string) string {
+	number = strings.TrimSpace(number)
+	if !strings.HasPrefix(number, "+") {
+		return ""
+	}
+	// Drop the trunk prefix some listings keep in brackets, e.g. +44 (0)1326 123456
+	digits := onlyDigits(strings.Replace(number, "(0)", "", 1))
+	if !validE164Length(digits) {
+		return ""
+	}
+	return "+" + digits
+}
+
+// validE164Length reports whether digits has a plausible E.164 length
+func validE164Length(digits string) bool {
+	return len(digits) >= 8 && len(digits) <= 15 && digits[0] != '0'
+}
+
+// onlyDigits strips everything but ASCII digits
+func onlyDigits(s string) string {
+	var sb strings.Builder
+	for _, r := range s {
+		if r >= '0' && r <= '9' {
+			sb.WriteRune(r)
+		}
+	}
+	return sb.String()
+}
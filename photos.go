@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"regexp"
+	"strings"
+
+	"github.com/jomei/notionapi"
+	"googlemaps.github.io/maps"
+)
+
+const (
+	// maxPhotos is how many place photos are attached to each Notion page
+	maxPhotos = 2
+	// photoMaxWidth is the width in pixels requested from the Place Photos API
+	photoMaxWidth = 1600
+)
+
+var htmlTagPattern = regexp.MustCompile(`<[^>]*>`)
+
+// PlacePhoto is a place photo resolved to a URL that does not embed the API key
+type PlacePhoto struct {
+	URL         string
+	Attribution string
+}
+
+// PhotoResolver turns Place photo references into externally hostable image URLs
+type PhotoResolver struct {
+	places PlacesProvider
+}
+
+// NewPhotoResolver initializes a new PhotoResolver fetching photos from the given Places API
+func NewPhotoResolver(places PlacesProvider) *PhotoResolver {
+	return &PhotoResolver{places: places}
+}
+
+// Resolve returns up to maxPhotos photos for a place. The API key is never part of the returned URLs,
+// since they are stored in Notion.
+func (pr *PhotoResolver) Resolve(ctx context.Context, photos []maps.Photo) ([]PlacePhoto, error) {
+	var resolved []PlacePhoto
+	for _, photo := range photos {
+		if len(resolved) == maxPhotos {
+			break
+		}
+		photoURL, err := pr.places.PhotoURL(ctx, photo.PhotoReference)
+		if err != nil {
+			return resolved, err
+		}
+		resolved = append(resolved, PlacePhoto{
+			URL:         photoURL,
+			Attribution: photoAttribution(photo.HTMLAttributions),
+		})
+	}
+	return resolved, nil
+}
+
+// photoAttribution strips the HTML from Google's required photo attributions
+func photoAttribution(attributions []string) string {
+	var names []string
+	for _, attribution := range attributions {
+		if name := strings.TrimSpace(htmlTagPattern.ReplaceAllString(attribution, "")); name != "" {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return ""
+	}
+	return "Photo: " + strings.Join(names, ", ")
+}
+
+// photoBlocks builds image blocks for a page body, captioned with each photo's attribution
+func photoBlocks(photos []PlacePhoto) []notionapi.Block {
+	var blocks []notionapi.Block
+	for _, photo := range photos {
+		image := notionapi.Image{
+			Type:     notionapi.FileTypeExternal,
+			External: &notionapi.FileObject{URL: photo.URL},
+		}
+		if photo.Attribution != "" {
+			image.Caption = richText(photo.Attribution)
+		}
+		blocks = append(blocks, notionapi.ImageBlock{
+			BasicBlock: notionapi.BasicBlock{
+				Object: notionapi.ObjectTypeBlock,
+				Type:   notionapi.BlockTypeImage,
+			},
+			Image: image,
+		})
+	}
+	return blocks
+}
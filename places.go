@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"googlemaps.github.io/maps"
+)
+
+// PlacesProvider is the Places API that searches, details and photos come from. Both providers
+// return the legacy API's types, which the rest of the pipeline is written against.
+type PlacesProvider interface {
+	NearbySearch(ctx context.Context, r *maps.NearbySearchRequest) (maps.PlacesSearchResponse, error)
+	TextSearch(ctx context.Context, r *maps.TextSearchRequest) (maps.PlacesSearchResponse, error)
+	PlaceDetails(ctx context.Context, r *maps.PlaceDetailsRequest) (maps.PlaceDetailsResult, error)
+	// FindPlaceFromText resolves a business's name and address to its place
+	FindPlaceFromText(ctx context.Context, r *maps.FindPlaceFromTextRequest) (maps.FindPlaceFromTextResponse, error)
+	// PhotoURL resolves a photo reference to an image URL that doesn't contain the API key
+	PhotoURL(ctx context.Context, reference string) (string, error)
+}
+
+// newPlacesProvider returns the provider named in the config: "legacy" (the default) or "new"
+func newPlacesProvider(name, apiKey string, mapsClient *maps.Client, mapsBaseURL string) (PlacesProvider, error) {
+	switch name {
+	case "", "legacy":
+		return newLegacyPlaces(mapsClient, apiKey, mapsBaseURL), nil
+	case "new":
+		return NewPlacesV1(apiKey), nil
+	}
+	return nil, fmt.Errorf("unknown places_api %q", name)
+}
+
+// placePhotoPath is the legacy Place Photos endpoint, which redirects to the image itself
+const placePhotoPath = "/maps/api/place/photo"
+
+// legacyPlaces is the legacy Places API, served by the Maps client
+type legacyPlaces struct {
+	*maps.Client
+	apiKey  string
+	baseURL string
+	http    *http.Client
+}
+
+func newLegacyPlaces(client *maps.Client, apiKey, baseURL string) *legacyPlaces {
+	return &legacyPlaces{
+		Client:  client,
+		apiKey:  apiKey,
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		http: &http.Client{
+			Timeout: 10 * time.Second,
+			// The redirect target is the image URL we want; don't download the image itself
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+	}
+}
+
+// PhotoURL requests a photo and returns the location it redirects to
+func (lp *legacyPlaces) PhotoURL(ctx context.Context, reference string) (string, error) {
+	query := url.Values{}
+	query.Set("photo_reference", reference)
+	query.Set("maxwidth", strconv.Itoa(photoMaxWidth))
+	query.Set("key", lp.apiKey)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, lp.baseURL+placePhotoPath+"?"+query.Encode(), nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := lp.http.Do(req)
+	if err != nil {
+		return "", err
+	}
+	resp.Body.Close()
+
+	location := resp.Header.Get("Location")
+	if resp.StatusCode < 300 || resp.StatusCode >= 400 || location == "" {
+		return "", fmt.Errorf("place photo: unexpected status %d", resp.StatusCode)
+	}
+	return location, nil
+}
+
+// detailsFields map the names accepted in the config's details_fields onto the Place Details
+// fields they request
+var detailsFields = map[string][]maps.PlaceDetailsFieldMask{
+	"address": {maps.PlaceDetailsFieldMaskFormattedAddress, maps.PlaceDetailsFieldMaskAddressComponent},
+	"website": {maps.PlaceDetailsFieldMaskWebsite},
+	"phone":   {maps.PlaceDetailsFieldMaskInternationalPhoneNumber, maps.PlaceDetailsFieldMaskFormattedPhoneNumber},
+	"status":  {maps.PlaceDetailsFieldMaskBusinessStatus},
+	"hours":   {maps.PlaceDetailsFieldMaskOpeningHours},
+	"rating":  {maps.PlaceDetailsFieldMaskRatings, maps.PlaceDetailsFieldMaskUserRatingsTotal},
+	"reviews": {maps.PlaceDetailsFieldMaskReviews},
+	"photos":  {maps.PlaceDetailsFieldMaskPhotos},
+}
+
+// defaultDetailsFields are requested when the config lists none. Hours, ratings, reviews and
+// photos bill at higher rates, so leads go without them unless they are asked for.
+var defaultDetailsFields = []string{"website", "phone", "status", "address"}
+
+// detailsFieldMask returns the Place Details fields to request for the named fields
+func detailsFieldMask(names []string) ([]maps.PlaceDetailsFieldMask, error) {
+	if len(names) == 0 {
+		names = defaultDetailsFields
+	}
+	mask := []maps.PlaceDetailsFieldMask{maps.PlaceDetailsFieldMaskPlaceID}
+	for _, name := range names {
+		fields, ok := detailsFields[strings.ToLower(name)]
+		if !ok {
+			return nil, fmt.Errorf("unknown details field %q", name)
+		}
+		mask = append(mask, fields...)
+	}
+	return mask, nil
+}
@@ -0,0 +1,349 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"googlemaps.github.io/maps"
+)
+
+const (
+	// placesV1BaseURL is the Places API (New) endpoint
+	placesV1BaseURL = "https://places.googleapis.com/v1"
+	// placesV1PageSize is the most results the new API returns per search request
+	placesV1PageSize = 20
+	// placesV1SearchFields are requested from searches. The new API bills by the fields asked for,
+	// so searches stay on the cheaper tier and everything else comes from details; the business
+	// status bills on the same tier as the display name.
+	placesV1SearchFields = "places.id,places.displayName,places.formattedAddress,places.types,places.location," +
+		"places.businessStatus,nextPageToken"
+	// placesV1DetailsFields are requested from place details when the request lists no fields
+	placesV1DetailsFields = "id,displayName,formattedAddress,addressComponents,types,location,websiteUri,internationalPhoneNumber," +
+		"nationalPhoneNumber,businessStatus,rating,userRatingCount,regularOpeningHours,reviews,photos"
+)
+
+// placesV1Fields are the new API's names for legacy Place Details fields
+var placesV1Fields = map[maps.PlaceDetailsFieldMask]string{
+	maps.PlaceDetailsFieldMaskPlaceID:                  "id",
+	maps.PlaceDetailsFieldMaskFormattedAddress:         "formattedAddress",
+	maps.PlaceDetailsFieldMaskAddressComponent:         "addressComponents",
+	maps.PlaceDetailsFieldMaskWebsite:                  "websiteUri",
+	maps.PlaceDetailsFieldMaskInternationalPhoneNumber: "internationalPhoneNumber",
+	maps.PlaceDetailsFieldMaskFormattedPhoneNumber:     "nationalPhoneNumber",
+	maps.PlaceDetailsFieldMaskBusinessStatus:           "businessStatus",
+	maps.PlaceDetailsFieldMaskOpeningHours:             "regularOpeningHours",
+	maps.PlaceDetailsFieldMaskRatings:                  "rating",
+	maps.PlaceDetailsFieldMaskUserRatingsTotal:         "userRatingCount",
+	maps.PlaceDetailsFieldMaskReviews:                  "reviews",
+	maps.PlaceDetailsFieldMaskPhotos:                   "photos",
+}
+
+// detailsFieldMaskV1 translates legacy Place Details fields into the new API's field mask
+func detailsFieldMaskV1(fields []maps.PlaceDetailsFieldMask) string {
+	if len(fields) == 0 {
+		return placesV1DetailsFields
+	}
+	names := make([]string, 0, len(fields))
+	for _, field := range fields {
+		if name, ok := placesV1Fields[field]; ok {
+			names = append(names, name)
+		}
+	}
+	return strings.Join(names, ",")
+}
+
+// PlacesV1 is a client for the Places API (New), which replaces the legacy API's query strings with
+// JSON requests and field masks
+type PlacesV1 struct {
+	apiKey  string
+	baseURL string
+	client  *http.Client
+}
+
+// NewPlacesV1 initializes a new PlacesV1 client
+func NewPlacesV1(apiKey string) *PlacesV1 {
+	return &PlacesV1{
+		apiKey:  apiKey,
+		baseURL: placesV1BaseURL,
+		client:  &http.Client{Timeout: requestTimeout},
+	}
+}
+
+// placeV1 is a place as returned by the new API
+type placeV1 struct {
+	ID          string `json:"id"`
+	DisplayName struct {
+		Text string `json:"text"`
+	} `json:"displayName"`
+	FormattedAddress  string `json:"formattedAddress"`
+	AddressComponents []struct {
+		LongText  string   `json:"longText"`
+		ShortText string   `json:"shortText"`
+		Types     []string `json:"types"`
+	} `json:"addressComponents"`
+	Types    []string `json:"types"`
+	Location struct {
+		Latitude  float64 `json:"latitude"`
+		Longitude float64 `json:"longitude"`
+	} `json:"location"`
+	WebsiteURI               string  `json:"websiteUri"`
+	InternationalPhoneNumber string  `json:"internationalPhoneNumber"`
+	NationalPhoneNumber      string  `json:"nationalPhoneNumber"`
+	Rating                   float32 `json:"rating"`
+	UserRatingCount          int     `json:"userRatingCount"`
+	BusinessStatus           string  `json:"businessStatus"`
+	RegularOpeningHours      *struct {
+		WeekdayDescriptions []string `json:"weekdayDescriptions"`
+	} `json:"regularOpeningHours"`
+	Reviews []struct {
+		Rating int `json:"rating"`
+		Text   struct {
+			Text string `json:"text"`
+		} `json:"text"`
+	} `json:"reviews"`
+	Photos []struct {
+		Name               string `json:"name"`
+		WidthPx            int    `json:"widthPx"`
+		HeightPx           int    `json:"heightPx"`
+		AuthorAttributions []struct {
+			DisplayName string `json:"displayName"`
+		} `json:"authorAttributions"`
+	} `json:"photos"`
+}
+
+// searchResult converts a place to the legacy search result type
+func (p placeV1) searchResult() maps.PlacesSearchResult {
+	return maps.PlacesSearchResult{
+		PlaceID:          p.ID,
+		Name:             p.DisplayName.Text,
+		FormattedAddress: p.FormattedAddress,
+		Types:            p.Types,
+		BusinessStatus:   p.BusinessStatus,
+		Geometry: maps.AddressGeometry{
+			Location: maps.LatLng{Lat: p.Location.Latitude, Lng: p.Location.Longitude},
+		},
+	}
+}
+
+// details converts a place to the legacy details type
+func (p placeV1) details() maps.PlaceDetailsResult {
+	details := maps.PlaceDetailsResult{
+		PlaceID:                  p.ID,
+		Name:                     p.DisplayName.Text,
+		FormattedAddress:         p.FormattedAddress,
+		Types:                    p.Types,
+		Website:                  p.WebsiteURI,
+		InternationalPhoneNumber: p.InternationalPhoneNumber,
+		FormattedPhoneNumber:     p.NationalPhoneNumber,
+		Rating:                   p.Rating,
+		UserRatingsTotal:         p.UserRatingCount,
+		BusinessStatus:           p.BusinessStatus,
+		Geometry: maps.AddressGeometry{
+			Location: maps.LatLng{Lat: p.Location.Latitude, Lng: p.Location.Longitude},
+		},
+	}
+	for _, c := range p.AddressComponents {
+		details.AddressComponents = append(details.AddressComponents, maps.AddressComponent{LongName: c.LongText, ShortName: c.ShortText, Types: c.Types})
+	}
+	if p.RegularOpeningHours != nil {
+		details.OpeningHours = &maps.OpeningHours{WeekdayText: p.RegularOpeningHours.WeekdayDescriptions}
+	}
+	for _, review := range p.Reviews {
+		details.Reviews = append(details.Reviews, maps.PlaceReview{Rating: review.Rating, Text: review.Text.Text})
+	}
+	for _, photo := range p.Photos {
+		var attributions []string
+		for _, author := range photo.AuthorAttributions {
+			attributions = append(attributions, author.DisplayName)
+		}
+		details.Photos = append(details.Photos, maps.Photo{
+			// Photo names, e.g. places/ID/photos/REF, take the place of legacy photo references
+			PhotoReference:   photo.Name,
+			Width:            photo.WidthPx,
+			Height:           photo.HeightPx,
+			HTMLAttributions: attributions,
+		})
+	}
+	return details
+}
+
+// circleV1 is a search circle in the new API's request format
+type circleV1 struct {
+	Circle struct {
+		Center struct {
+			Latitude  float64 `json:"latitude"`
+			Longitude float64 `json:"longitude"`
+		} `json:"center"`
+		Radius float64 `json:"radius"`
+	} `json:"circle"`
+}
+
+func newCircleV1(center *maps.LatLng, radius uint) *circleV1 {
+	if center == nil {
+		return nil
+	}
+	c := &circleV1{}
+	c.Circle.Center.Latitude, c.Circle.Center.Longitude = center.Lat, center.Lng
+	c.Circle.Radius = float64(radius)
+	return c
+}
+
+// NearbySearch searches for places of a type within a circle. The new API's nearby search has no
+// keywords or further pages, so keyword searches go through text search restricted to the type.
+func (pv *PlacesV1) NearbySearch(ctx context.Context, r *maps.NearbySearchRequest) (maps.PlacesSearchResponse, error) {
+	if r.Keyword != "" {
+		return pv.searchText(ctx, r.Keyword, string(r.Type), r.Location, r.Radius, r.PageToken)
+	}
+	body := map[string]any{
+		"maxResultCount":      placesV1PageSize,
+		"locationRestriction": newCircleV1(r.Location, r.Radius),
+	}
+	if r.Type != "" {
+		body["includedTypes"] = []string{string(r.Type)}
+	}
+	var res struct {
+		Places []placeV1 `json:"places"`
+	}
+	if err := pv.do(ctx, http.MethodPost, "/places:searchNearby", placesV1SearchFields, body, &res); err != nil {
+		return maps.PlacesSearchResponse{}, err
+	}
+	return searchResponse(res.Places, ""), nil
+}
+
+// TextSearch searches for places matching a keyword query, biased towards a circle
+func (pv *PlacesV1) TextSearch(ctx context.Context, r *maps.TextSearchRequest) (maps.PlacesSearchResponse, error) {
+	return pv.searchText(ctx, r.Query, string(r.Type), r.Location, r.Radius, r.PageToken)
+}
+
+func (pv *PlacesV1) searchText(ctx context.Context, query, placeType string, center *maps.LatLng, radius uint, pageToken string) (maps.PlacesSearchResponse, error) {
+	body := map[string]any{
+		"textQuery": query,
+		"pageSize":  placesV1PageSize,
+	}
+	if placeType != "" {
+		body["includedType"] = placeType
+	}
+	if circle := newCircleV1(center, radius); circle != nil {
+		body["locationBias"] = circle
+	}
+	if pageToken != "" {
+		body["pageToken"] = pageToken
+	}
+	var res struct {
+		Places        []placeV1 `json:"places"`
+		NextPageToken string    `json:"nextPageToken"`
+	}
+	if err := pv.do(ctx, http.MethodPost, "/places:searchText", placesV1SearchFields, body, &res); err != nil {
+		return maps.PlacesSearchResponse{}, err
+	}
+	return searchResponse(res.Places, res.NextPageToken), nil
+}
+
+func searchResponse(places []placeV1, nextPageToken string) maps.PlacesSearchResponse {
+	res := maps.PlacesSearchResponse{NextPageToken: nextPageToken}
+	for _, place := range places {
+		res.Results = append(res.Results, place.searchResult())
+	}
+	return res
+}
+
+// FindPlaceFromText resolves a query to its best matching place. The new API has no Find Place;
+// a text search for one result replaces it.
+func (pv *PlacesV1) FindPlaceFromText(ctx context.Context, r *maps.FindPlaceFromTextRequest) (maps.FindPlaceFromTextResponse, error) {
+	body := map[string]any{
+		"textQuery": r.Input,
+		"pageSize":  1,
+	}
+	if r.LocationBias == maps.FindPlaceFromTextLocationBiasCircular {
+		body["locationBias"] = newCircleV1(r.LocationBiasCenter, uint(r.LocationBiasRadius))
+	}
+	var res struct {
+		Places []placeV1 `json:"places"`
+	}
+	if err := pv.do(ctx, http.MethodPost, "/places:searchText", placesV1SearchFields, body, &res); err != nil {
+		return maps.FindPlaceFromTextResponse{}, err
+	}
+	return maps.FindPlaceFromTextResponse{Candidates: searchResponse(res.Places, "").Results}, nil
+}
+
+// PlaceDetails fetches the fields of a place that enrichment uses
+func (pv *PlacesV1) PlaceDetails(ctx context.Context, r *maps.PlaceDetailsRequest) (maps.PlaceDetailsResult, error) {
+	var place placeV1
+	if err := pv.do(ctx, http.MethodGet, "/places/"+url.PathEscape(r.PlaceID), detailsFieldMaskV1(r.Fields), nil, &place); err != nil {
+		return maps.PlaceDetailsResult{}, err
+	}
+	return place.details(), nil
+}
+
+// PhotoURL asks for a photo's image URL without being redirected to the image itself
+func (pv *PlacesV1) PhotoURL(ctx context.Context, reference string) (string, error) {
+	path := "/" + reference + "/media?skipHttpRedirect=true&maxWidthPx=" + strconv.Itoa(photoMaxWidth)
+	var res struct {
+		PhotoURI string `json:"photoUri"`
+	}
+	if err := pv.do(ctx, http.MethodGet, path, "", nil, &res); err != nil {
+		return "", err
+	}
+	if res.PhotoURI == "" {
+		return "", fmt.Errorf("place photo: no photo URI returned")
+	}
+	return res.PhotoURI, nil
+}
+
+// do sends a request to the new API and decodes the JSON response into out
+func (pv *PlacesV1) do(ctx context.Context, method, path, fieldMask string, body any, out any) error {
+	var reqBody io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reqBody = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, pv.baseURL+path, reqBody)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("X-Goog-Api-Key", pv.apiKey)
+	if fieldMask != "" {
+		req.Header.Set("X-Goog-FieldMask", fieldMask)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := pv.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		var apiErr struct {
+			Error struct {
+				Message string `json:"message"`
+				Status  string `json:"status"`
+			} `json:"error"`
+		}
+		json.Unmarshal(data, &apiErr)
+		message := strings.TrimSpace(apiErr.Error.Message)
+		if message == "" {
+			message = http.StatusText(resp.StatusCode)
+		}
+		if resp.StatusCode == http.StatusTooManyRequests || apiErr.Error.Status == "RESOURCE_EXHAUSTED" {
+			return fmt.Errorf("places API %s: %w: %s", path, errQuotaExceeded, message)
+		}
+		return fmt.Errorf("places API %s: %d %s", path, resp.StatusCode, message)
+	}
+	return json.Unmarshal(data, out)
+}
@@ -0,0 +1,234 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"log/slog"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jomei/notionapi"
+)
+
+// Policy holds the compliance settings that limit what is collected and how long it is kept
+type Policy struct {
+	// Scraping enables the enrichers that fetch business websites (emails and social links); on when unset
+	Scraping *bool `json:"scraping,omitempty"`
+	// AllowedDomains, when set, are the only sites that may be fetched. Subdomains match too.
+	AllowedDomains []string `json:"allowed_domains,omitempty"`
+	// BlockedDomains are never fetched
+	BlockedDomains []string `json:"blocked_domains,omitempty"`
+	// RetentionDays, when set, purges personal contact data from leads created more than this many days ago
+	RetentionDays int `json:"retention_days,omitempty"`
+}
+
+// doNotContact is the Contacted status of leads who opted out; their contact data is purged everywhere
+const doNotContact = "Do Not Contact"
+
+// personalProperty is a property holding personal contact data
+type personalProperty struct {
+	Name string
+	Type notionapi.PropertyType
+}
+
+// personalDataProperties are the properties that can identify or reach a person
+var personalDataProperties = []personalProperty{
+	{"Email", notionapi.PropertyTypeEmail},
+	{"Phone", notionapi.PropertyTypePhoneNumber},
+	{"Facebook", notionapi.PropertyTypeURL},
+	{"Instagram", notionapi.PropertyTypeURL},
+	{"LinkedIn", notionapi.PropertyTypeURL},
+	{"X", notionapi.PropertyTypeURL},
+	// The relation to the archived contact records, so purged leads stop counting as holding data
+	{"Contacts", notionapi.PropertyTypeRelation},
+}
+
+// Validate checks the policy for impossible settings
+func (p Policy) Validate() error {
+	if p.RetentionDays < 0 {
+		return fmt.Errorf("retention_days must not be negative")
+	}
+	for _, domain := range slices.Concat(p.AllowedDomains, p.BlockedDomains) {
+		if domain == "" || strings.Contains(domain, "/") {
+			return fmt.Errorf("invalid domain %q", domain)
+		}
+	}
+	return nil
+}
+
+// ScrapingEnabled reports whether website-fetching enrichers may run
+func (p Policy) ScrapingEnabled() bool {
+	return p.Scraping == nil || *p.Scraping
+}
+
+// AllowsSite reports whether scraping may fetch the given website
+func (p Policy) AllowsSite(website string) bool {
+	if !p.ScrapingEnabled() {
+		return false
+	}
+	u, err := url.Parse(website)
+	if err != nil {
+		return false
+	}
+	host := strings.ToLower(u.Hostname())
+	for _, domain := range p.BlockedDomains {
+		if domainMatches(host, domain) {
+			return false
+		}
+	}
+	if len(p.AllowedDomains) == 0 {
+		return true
+	}
+	for _, domain := range p.AllowedDomains {
+		if domainMatches(host, domain) {
+			return true
+		}
+	}
+	return false
+}
+
+// domainMatches reports whether host is domain or one of its subdomains
+func domainMatches(host, domain string) bool {
+	domain = strings.ToLower(strings.TrimPrefix(domain, "."))
+	return host == domain || strings.HasSuffix(host, "."+domain)
+}
+
+// hasPersonalData reports whether any personal contact data is stored for a business
+func hasPersonalData(b Business) bool {
+	socials := SocialProfiles{Facebook: b.Facebook, Instagram: b.Instagram, LinkedIn: b.LinkedIn, X: b.X}
+	return b.Email != "" || b.Phone != "" || !socials.IsEmpty() || len(b.Contacts) > 0
+}
+
+// clearPersonalFields empties a lead's personal contact data in memory, as clearPersonalData does in
+// Notion
+func clearPersonalFields(b *Business) {
+	b.Email, b.Phone = "", ""
+	b.Facebook, b.Instagram, b.LinkedIn, b.X = "", "", "", ""
+	b.Contacts = nil
+}
+
+// clearPersonalData empties a lead's personal contact data and archives its contact records
+func (nc *NotionClient) clearPersonalData(ctx context.Context, b Business) error {
+	if err := nc.archiveContacts(ctx, b); err != nil {
+		return fmt.Errorf("archiving contacts: %w", err)
+	}
+	pb := NewProperties(nil)
+	for _, prop := range personalDataProperties {
+		// Databases without a Contacts database have no relation to clear
+		if prop.Name == "Contacts" && len(b.Contacts) == 0 {
+			continue
+		}
+		pb.Clear(prop.Name, prop.Type)
+	}
+	props, err := pb.Build()
+	if err != nil {
+		return err
+	}
+	return nc.UpdateBusiness(ctx, b.PageID, props)
+}
+
+// purgeExpired strips personal contact data from leads older than the retention period, and from
+// failed writes kept that long, returning how many were purged
+func purgeExpired(ctx context.Context, nc *NotionClient, deadLetters *DeadLetters, retentionDays int, dryRun bool) (int, error) {
+	cutoff := notionapi.Date(time.Now().AddDate(0, 0, -retentionDays))
+	purged := 0
+	err := nc.EachLead(ctx, &notionapi.TimestampFilter{
+		Timestamp:   notionapi.TimestampCreated,
+		CreatedTime: &notionapi.DateFilterCondition{Before: &cutoff},
+	}, func(b Business) error {
+		if !hasPersonalData(b) {
+			return nil
+		}
+		purged++
+		if dryRun {
+			fmt.Printf("Would purge contact data of %s (%s), created %s\n", b.Name, b.LeadNumber, b.Created.Format("2006-01-02"))
+			return nil
+		}
+		if err := nc.pageClient(b.PageID).clearPersonalData(ctx, b); err != nil {
+			return fmt.Errorf("purging %s: %w", b.Name, err)
+		}
+		return nil
+	})
+	if err != nil || dryRun {
+		return purged, err
+	}
+	purged += deadLetters.Purge(func(entry deadLetter) bool { return entry.Failed.Before(time.Time(cutoff)) })
+	return purged, nil
+}
+
+// purgeDoNotContact strips personal contact data of leads marked Do Not Contact from Notion, from
+// every sink that holds a copy and from the failed writes kept, returning how many records were
+// purged
+func purgeDoNotContact(ctx context.Context, nc *NotionClient, sinks []Sink, deadLetters *DeadLetters, dryRun bool) (int, error) {
+	optedOut := make(map[string]bool)
+	purged := 0
+	err := nc.EachLead(ctx, &notionapi.PropertyFilter{
+		Property: "Contacted",
+		Select:   &notionapi.SelectFilterCondition{Equals: doNotContact},
+	}, func(b Business) error {
+		// Leads already cleared in Notion are still purged from the sinks, which may hold older copies
+		optedOut[b.PlaceID] = true
+		if !hasPersonalData(b) {
+			return nil
+		}
+		purged++
+		if dryRun {
+			fmt.Printf("Would purge contact data of %s (%s), marked %s\n", b.Name, b.LeadNumber, doNotContact)
+			return nil
+		}
+		if err := nc.pageClient(b.PageID).clearPersonalData(ctx, b); err != nil {
+			return fmt.Errorf("purging %s: %w", b.Name, err)
+		}
+		return nil
+	})
+	if err != nil || dryRun || len(optedOut) == 0 {
+		return purged, err
+	}
+	purged += deadLetters.Purge(func(entry deadLetter) bool { return optedOut[entry.Lead.PlaceID] })
+
+	n, err := rewriteSinks(sinks, func(record map[string]string) bool {
+		if !optedOut[record["PlaceID"]] {
+			return false
+		}
+		changed := false
+		for _, prop := range personalDataProperties {
+			changed = setExisting(record, prop.Name, "") || changed
+		}
+		return changed
+	})
+	return purged + n, err
+}
+
+// runPurge strips personal contact data from leads marked Do Not Contact and, when a retention
+// period is set, from leads past it
+func runPurge(notionClient *NotionClient, policy Policy, sinks []Sink, deadLetters *DeadLetters, args []string) {
+	fs := flag.NewFlagSet("purge", flag.ExitOnError)
+	days := fs.Int("days", policy.RetentionDays, "purge leads created more than this many days ago (default from the config's retention_days)")
+	dryRun := fs.Bool("dry-run", false, "list the leads that would be purged without changing them")
+	fs.Parse(args)
+
+	ctx := context.Background()
+	defer func() {
+		if err := deadLetters.Save(); err != nil {
+			slog.Error("Failed to save failed writes", "operation", "purge", "path", deadLetters.path, "err", err)
+		}
+	}()
+	optedOut, err := purgeDoNotContact(ctx, notionClient, sinks, deadLetters, *dryRun)
+	if err != nil {
+		log.Fatalf("Failed to purge %s leads: %v", doNotContact, err)
+	}
+	fmt.Printf("Purged contact data of %d %s records\n", optedOut, doNotContact)
+
+	if *days <= 0 {
+		return
+	}
+	purged, err := purgeExpired(ctx, notionClient, deadLetters, *days, *dryRun)
+	if err != nil {
+		log.Fatalf("Failed to purge expired leads: %v", err)
+	}
+	fmt.Printf("Purged contact data of %d leads older than %d days\n", purged, *days)
+}
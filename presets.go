@@ -0,0 +1,49 @@
+package main
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+)
+
+//go:embed presets/*.json
+var presetFiles embed.FS
+
+// LoadPreset returns the bundled search area preset with the given name
+func LoadPreset(name string) (*SearchArea, error) {
+	data, err := presetFiles.ReadFile(path.Join("presets", strings.ToLower(name)+".json"))
+	if err != nil {
+		return nil, fmt.Errorf("unknown area preset %q (available: %s)", name, strings.Join(presetNames(), ", "))
+	}
+	var area SearchArea
+	if err := json.Unmarshal(data, &area); err != nil {
+		return nil, fmt.Errorf("parsing area preset %q: %w", name, err)
+	}
+	return &area, nil
+}
+
+// presetNames lists the bundled area presets
+func presetNames() []string {
+	entries, _ := presetFiles.ReadDir("presets")
+	var names []string
+	for _, entry := range entries {
+		names = append(names, strings.TrimSuffix(entry.Name(), ".json"))
+	}
+	sort.Strings(names)
+	return names
+}
+
+// printPresets writes a one-line summary of every bundled area preset
+func printPresets() {
+	for _, name := range presetNames() {
+		area, err := LoadPreset(name)
+		if err != nil {
+			fmt.Printf("%s: %v\n", name, err)
+			continue
+		}
+		fmt.Printf("%-12s %s (%.0fkm, %d types)\n", area.Name, area.Description, float64(area.Radius)/1000, len(area.Types))
+	}
+}
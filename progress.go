@@ -0,0 +1,385 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// progress reports the current run; main configures it from the command line
+var progress = NewProgress(os.Stdout, false)
+
+// Progress shows how far a run has got, as a single redrawn status line on a terminal, and
+// summarizes it at the end. Chatty per-page messages are only printed when verbose.
+type Progress struct {
+	mu      sync.Mutex
+	out     io.Writer
+	tty     bool
+	verbose bool
+	started time.Time
+	drawn   bool
+
+	// stage is the current search, e.g. "google cafe", done in steps such as grid cells
+	stage        string
+	step, steps  int
+	stageStarted time.Time
+
+	found, inserted, failed, skipped, merged int
+	// spend is the estimated cost of the run's Google requests so far, in US dollars
+	spend float64
+
+	// stages are the run's searches so far, the current one last, with what each found
+	stages []*stageProgress
+	// resume is open while the run is paused and closed to resume it
+	resume chan struct{}
+}
+
+// stageProgress is how far one search of a run got
+type stageProgress struct {
+	Name                                     string
+	Step, Steps                              int
+	Found, Inserted, Failed, Skipped, Merged int
+	Started, Finished                        time.Time
+	// Skip is set when the stage was skipped before it finished
+	Skip bool
+}
+
+// NewProgress initializes a Progress writing to out
+func NewProgress(out io.Writer, verbose bool) *Progress {
+	tty := false
+	if f, ok := out.(*os.File); ok {
+		if info, err := f.Stat(); err == nil {
+			tty = info.Mode()&os.ModeCharDevice != 0 && enableANSI(f)
+		}
+	}
+	return &Progress{out: out, tty: tty, verbose: verbose, started: time.Now()}
+}
+
+// Reset starts counting a new run
+func (p *Progress) Reset() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.started, p.stage = time.Now(), ""
+	p.found, p.inserted, p.failed, p.skipped, p.merged = 0, 0, 0, 0, 0
+	p.spend = 0
+	p.stages = nil
+	apiCalls.reset()
+}
+
+// progressSnapshot is how far the current run has got, as the REST API reports it
+type progressSnapshot struct {
+	Stage    string  `json:"stage,omitempty"`
+	Step     int     `json:"step"`
+	Steps    int     `json:"steps"`
+	Found    int     `json:"found"`
+	Inserted int     `json:"inserted"`
+	Failed   int     `json:"failed"`
+	Skipped  int     `json:"skipped"`
+	Merged   int     `json:"merged"`
+	Spend    float64 `json:"spend"`
+}
+
+// Snapshot returns the current run's counters
+func (p *Progress) Snapshot() progressSnapshot {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return progressSnapshot{
+		Stage: p.stage, Step: p.step, Steps: p.steps,
+		Found: p.found, Inserted: p.inserted, Failed: p.failed, Skipped: p.skipped, Merged: p.merged,
+		Spend: p.spend,
+	}
+}
+
+// LogWriter returns a writer for the log package that keeps log lines from running into the
+// status line
+func (p *Progress) LogWriter(w io.Writer) io.Writer {
+	return progressLogWriter{p: p, w: w}
+}
+
+type progressLogWriter struct {
+	p *Progress
+	w io.Writer
+}
+
+func (lw progressLogWriter) Write(b []byte) (int, error) {
+	lw.p.mu.Lock()
+	defer lw.p.mu.Unlock()
+	lw.p.clearLocked()
+	n, err := lw.w.Write(b)
+	lw.p.drawLocked()
+	return n, err
+}
+
+// SetVerbose turns the per-page messages on or off
+func (p *Progress) SetVerbose(verbose bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.verbose = verbose
+}
+
+// Stage starts a search made of steps, e.g. the grid cells searched for one place type
+func (p *Progress) Stage(stage string, steps int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.stage, p.step, p.steps, p.stageStarted = stage, 0, steps, time.Now()
+	if current := p.currentLocked(); current != nil {
+		current.Finished = p.stageStarted
+	}
+	p.stages = append(p.stages, &stageProgress{Name: stage, Steps: steps, Started: p.stageStarted})
+	if !p.tty {
+		p.printLocked("%s (%d steps)\n", stage, steps)
+	}
+	p.drawLocked()
+}
+
+// Step records that one step of the current stage is done
+func (p *Progress) Step() {
+	p.update(func() { p.step++ }, func(s *stageProgress) { s.Step++ })
+}
+
+// Found, Inserted, Failed, Skipped and Merged count the run's listings by outcome
+func (p *Progress) Found() { p.update(func() { p.found++ }, func(s *stageProgress) { s.Found++ }) }
+func (p *Progress) Inserted() {
+	p.update(func() { p.inserted++ }, func(s *stageProgress) { s.Inserted++ })
+}
+func (p *Progress) Failed() { p.update(func() { p.failed++ }, func(s *stageProgress) { s.Failed++ }) }
+func (p *Progress) Skipped() {
+	p.update(func() { p.skipped++ }, func(s *stageProgress) { s.Skipped++ })
+}
+func (p *Progress) Merged() { p.update(func() { p.merged++ }, func(s *stageProgress) { s.Merged++ }) }
+
+// SetSpend shows the run's estimated Google spend so far
+func (p *Progress) SetSpend(spend float64) { p.update(func() { p.spend = spend }, nil) }
+
+// update changes the run's counters and, if there is one, the current stage's
+func (p *Progress) update(fn func(), stageFn func(*stageProgress)) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	fn()
+	if current := p.currentLocked(); current != nil && stageFn != nil {
+		stageFn(current)
+	}
+	p.drawLocked()
+}
+
+func (p *Progress) currentLocked() *stageProgress {
+	if len(p.stages) == 0 {
+		return nil
+	}
+	return p.stages[len(p.stages)-1]
+}
+
+// Stages returns copies of the run's stages so far, the current one last
+func (p *Progress) Stages() []stageProgress {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	stages := make([]stageProgress, len(p.stages))
+	for i, s := range p.stages {
+		stages[i] = *s
+	}
+	return stages
+}
+
+// Pause holds the run at the next step of its current stage until Resume
+func (p *Progress) Pause() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.resume == nil {
+		p.resume = make(chan struct{})
+	}
+}
+
+// Resume lets a paused run carry on
+func (p *Progress) Resume() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.resume != nil {
+		close(p.resume)
+		p.resume = nil
+	}
+}
+
+// Paused reports whether the run is paused
+func (p *Progress) Paused() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.resume != nil
+}
+
+// SkipStage ends the current stage at its next step, e.g. to move on from a place type
+func (p *Progress) SkipStage() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if current := p.currentLocked(); current != nil && current.Finished.IsZero() {
+		current.Skip = true
+	}
+}
+
+// Proceed is called by sources before each step: it waits while the run is paused and reports
+// whether to go on with the current stage, which is not the case once it was skipped or ctx is done
+func (p *Progress) Proceed(ctx context.Context) bool {
+	p.mu.Lock()
+	resume := p.resume
+	p.mu.Unlock()
+	if resume != nil {
+		select {
+		case <-resume:
+		case <-ctx.Done():
+		}
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	current := p.currentLocked()
+	return ctx.Err() == nil && (current == nil || !current.Skip)
+}
+
+// SetOutput sends the messages and status line to out, drawing the status line only on a terminal
+func (p *Progress) SetOutput(out io.Writer, tty bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.clearLocked()
+	p.out, p.tty = out, tty
+}
+
+// Printf prints a message that stays on screen above the status line
+func (p *Progress) Printf(format string, args ...any) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.printLocked(format, args...)
+	p.drawLocked()
+}
+
+// Verbosef prints a message only in verbose mode
+func (p *Progress) Verbosef(format string, args ...any) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.verbose {
+		p.printLocked(format, args...)
+		p.drawLocked()
+	}
+}
+
+// Clear removes the status line, e.g. before logging to the same terminal
+func (p *Progress) Clear() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.clearLocked()
+}
+
+func (p *Progress) printLocked(format string, args ...any) {
+	p.clearLocked()
+	fmt.Fprintf(p.out, format, args...)
+}
+
+func (p *Progress) clearLocked() {
+	if p.drawn {
+		fmt.Fprint(p.out, "\r\033[K")
+		p.drawn = false
+	}
+}
+
+// drawLocked redraws the status line on a terminal, estimating the stage's remaining time from
+// its steps so far
+func (p *Progress) drawLocked() {
+	if !p.tty || p.stage == "" {
+		return
+	}
+	line := fmt.Sprintf("%s %d/%d", p.stage, p.step, p.steps)
+	if p.step > 0 && p.step < p.steps {
+		perStep := time.Since(p.stageStarted) / time.Duration(p.step)
+		line += fmt.Sprintf(", ETA %s", (perStep * time.Duration(p.steps-p.step)).Round(time.Second))
+	}
+	line += fmt.Sprintf(" | %d found, %d inserted, %d skipped", p.found, p.inserted, p.skipped)
+	if p.spend > 0 {
+		line += fmt.Sprintf(" | $%.2f", p.spend)
+	}
+	p.clearLocked()
+	fmt.Fprint(p.out, line)
+	p.drawn = true
+}
+
+// Summary describes the whole run: listings by outcome, requests made and elapsed time
+func (p *Progress) Summary() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.clearLocked()
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Run summary: %d places found, %d inserted, %d failed, %d duplicates skipped",
+		p.found, p.inserted, p.failed, p.skipped)
+	if p.merged > 0 {
+		fmt.Fprintf(&sb, ", %d merged across sources", p.merged)
+	}
+	fmt.Fprintf(&sb, "\n  %s, took %s\n", apiCalls.String(), time.Since(p.started).Round(time.Second))
+	return sb.String()
+}
+
+// summaryHosts is how many hosts the run summary names
+const summaryHosts = 5
+
+// apiCalls counts outgoing HTTP requests per host. Every client in the program uses the default
+// transport, so installing it there sees Maps, Notion and source API calls alike.
+var apiCalls = &countingTransport{next: http.DefaultTransport, counts: make(map[string]int)}
+
+func init() {
+	http.DefaultTransport = apiCalls
+}
+
+type countingTransport struct {
+	next   http.RoundTripper
+	mu     sync.Mutex
+	counts map[string]int
+}
+
+func (ct *countingTransport) reset() {
+	ct.mu.Lock()
+	defer ct.mu.Unlock()
+	clear(ct.counts)
+}
+
+func (ct *countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ct.mu.Lock()
+	ct.counts[req.URL.Hostname()]++
+	ct.mu.Unlock()
+	return ct.next.RoundTrip(req)
+}
+
+// String lists request counts, busiest hosts first. Crawled business websites make a long tail,
+// so only the top hosts are named.
+func (ct *countingTransport) String() string {
+	ct.mu.Lock()
+	defer ct.mu.Unlock()
+	total := 0
+	hosts := make([]string, 0, len(ct.counts))
+	for host, n := range ct.counts {
+		total += n
+		hosts = append(hosts, host)
+	}
+	sort.Slice(hosts, func(i, j int) bool {
+		if ct.counts[hosts[i]] != ct.counts[hosts[j]] {
+			return ct.counts[hosts[i]] > ct.counts[hosts[j]]
+		}
+		return hosts[i] < hosts[j]
+	})
+	var parts []string
+	others := 0
+	for i, host := range hosts {
+		if i < summaryHosts {
+			parts = append(parts, fmt.Sprintf("%s %d", host, ct.counts[host]))
+		} else {
+			others += ct.counts[host]
+		}
+	}
+	if others > 0 {
+		parts = append(parts, fmt.Sprintf("%d to %d other hosts", others, len(hosts)-summaryHosts))
+	}
+	if total == 0 {
+		return "0 HTTP requests"
+	}
+	return fmt.Sprintf("%d HTTP requests (%s)", total, strings.Join(parts, ", "))
+}
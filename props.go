@@ -0,0 +1,272 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/mail"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/jomei/notionapi"
+)
+
+const (
+	// maxRichTextLength is Notion's limit on the content of a single rich text object
+	maxRichTextLength = 2000
+	// maxRichTextObjects is Notion's limit on rich text objects in one property value
+	maxRichTextObjects = 100
+)
+
+// richText splits content into as many rich text objects as Notion's per-object length limit requires
+func richText(content string) []notionapi.RichText {
+	runes := []rune(content)
+	var chunks []notionapi.RichText
+	for len(runes) > 0 && len(chunks) < maxRichTextObjects {
+		n := min(len(runes), maxRichTextLength)
+		chunks = append(chunks, notionapi.RichText{
+			Text: &notionapi.Text{Content: string(runes[:n])},
+		})
+		runes = runes[n:]
+	}
+	return chunks
+}
+
+// PropertyBuilder assembles page properties for Notion writes, e.g.
+//
+//	props, err := NewProperties(limiter).Title("Name", b.Name).Select("Urgency", b.Urgency).Build()
+//
+// Empty string values are skipped so the same builder serves create and update paths without
+// blanking existing data. Invalid values are collected and reported by Build.
+type PropertyBuilder struct {
+	props   notionapi.Properties
+	options *OptionLimiter
+	errs    []error
+}
+
+// NewProperties starts a PropertyBuilder; select values are passed through options when it is non-nil
+func NewProperties(options *OptionLimiter) *PropertyBuilder {
+	return &PropertyBuilder{
+		props:   make(notionapi.Properties),
+		options: options,
+	}
+}
+
+// set records a property, rejecting names that were already set
+func (pb *PropertyBuilder) set(name string, prop notionapi.Property) *PropertyBuilder {
+	if _, ok := pb.props[name]; ok {
+		pb.errs = append(pb.errs, fmt.Errorf("property %q set twice", name))
+		return pb
+	}
+	pb.props[name] = prop
+	return pb
+}
+
+// fail records a validation error for a property
+func (pb *PropertyBuilder) fail(name, format string, args ...any) *PropertyBuilder {
+	pb.errs = append(pb.errs, fmt.Errorf("property %q: %s", name, fmt.Sprintf(format, args...)))
+	return pb
+}
+
+// Title sets the title property, which Notion requires to be non-empty
+func (pb *PropertyBuilder) Title(name, value string) *PropertyBuilder {
+	if strings.TrimSpace(value) == "" {
+		return pb.fail(name, "title must not be empty")
+	}
+	return pb.set(name, notionapi.TitleProperty{Title: richText(value)})
+}
+
+// RichText sets a rich text property, chunking long values
+func (pb *PropertyBuilder) RichText(name, value string) *PropertyBuilder {
+	if value == "" {
+		return pb
+	}
+	return pb.set(name, notionapi.RichTextProperty{RichText: richText(value)})
+}
+
+// Select sets a select property
+func (pb *PropertyBuilder) Select(name, value string) *PropertyBuilder {
+	if pb.options != nil {
+		value = pb.options.NormalizeOne(name, value)
+	} else {
+		value = cleanOptionName(value)
+	}
+	if value == "" {
+		return pb
+	}
+	return pb.set(name, notionapi.SelectProperty{Select: notionapi.Option{Name: value}})
+}
+
+// MultiSelect sets a multi-select property
+func (pb *PropertyBuilder) MultiSelect(name string, values []string) *PropertyBuilder {
+	if pb.options != nil {
+		values = pb.options.Normalize(name, values)
+	}
+	var options []notionapi.Option
+	for _, value := range values {
+		if value = cleanOptionName(value); value != "" {
+			options = append(options, notionapi.Option{Name: value})
+		}
+	}
+	if len(options) == 0 {
+		return pb
+	}
+	return pb.set(name, notionapi.MultiSelectProperty{MultiSelect: options})
+}
+
+// URL sets a URL property, which must be an absolute http(s) URL
+func (pb *PropertyBuilder) URL(name, value string) *PropertyBuilder {
+	if value == "" {
+		return pb
+	}
+	u, err := url.Parse(value)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return pb.fail(name, "invalid URL %q", value)
+	}
+	return pb.set(name, notionapi.URLProperty{URL: value})
+}
+
+// Email sets an email property
+func (pb *PropertyBuilder) Email(name, value string) *PropertyBuilder {
+	if value == "" {
+		return pb
+	}
+	if _, err := mail.ParseAddress(value); err != nil {
+		return pb.fail(name, "invalid email %q", value)
+	}
+	return pb.set(name, notionapi.EmailProperty{Email: value})
+}
+
+// Phone sets a phone number property, which must already be in E.164 format
+func (pb *PropertyBuilder) Phone(name, value string) *PropertyBuilder {
+	if value == "" {
+		return pb
+	}
+	if !strings.HasPrefix(value, "+") || onlyDigits(value) != value[1:] || !validE164Length(value[1:]) {
+		return pb.fail(name, "phone %q is not E.164", value)
+	}
+	return pb.set(name, notionapi.PhoneNumberProperty{PhoneNumber: value})
+}
+
+// Number sets a number property
+func (pb *PropertyBuilder) Number(name string, value float64) *PropertyBuilder {
+	return pb.set(name, notionapi.NumberProperty{Number: value})
+}
+
+// Checkbox sets a checkbox property
+func (pb *PropertyBuilder) Checkbox(name string, value bool) *PropertyBuilder {
+	return pb.set(name, notionapi.CheckboxProperty{Checkbox: value})
+}
+
+// Date sets a date property; zero times are skipped
+func (pb *PropertyBuilder) Date(name string, value time.Time) *PropertyBuilder {
+	if value.IsZero() {
+		return pb
+	}
+	date := notionapi.Date(value)
+	return pb.set(name, notionapi.DateProperty{Date: &notionapi.DateObject{Start: &date}})
+}
+
+// clearedProperty is a property value of null, which empties the property in Notion
+type clearedProperty struct {
+	propType notionapi.PropertyType
+}
+
+func (cp clearedProperty) GetID() string                   { return "" }
+func (cp clearedProperty) GetType() notionapi.PropertyType { return cp.propType }
+
+func (cp clearedProperty) MarshalJSON() ([]byte, error) {
+	switch cp.propType {
+	case notionapi.PropertyTypeRichText, notionapi.PropertyTypeMultiSelect, notionapi.PropertyTypeRelation:
+		return []byte(`{"` + string(cp.propType) + `":[]}`), nil
+	}
+	return []byte(`{"` + string(cp.propType) + `":null}`), nil
+}
+
+// Clear empties a property of the given type, which the typed setters can't since they skip empty values
+func (pb *PropertyBuilder) Clear(name string, propType notionapi.PropertyType) *PropertyBuilder {
+	return pb.set(name, clearedProperty{propType: propType})
+}
+
+// Build returns the assembled properties, or the validation errors encountered
+func (pb *PropertyBuilder) Build() (notionapi.Properties, error) {
+	if len(pb.errs) > 0 {
+		return nil, errors.Join(pb.errs...)
+	}
+	return pb.props, nil
+}
+
+// businessProperties maps a business onto its Notion database properties
+func (nc *NotionClient) businessProperties(business *Business) *PropertyBuilder {
+	pb := NewProperties(nc.options).
+		Title("Name", business.Name).
+		RichText("Address", business.Address).
+		RichText("Street", business.Street).
+		Select("City", business.City).
+		RichText("Postcode", business.Postcode).
+		Select("PostcodeDistrict", postcodeDistrict(business.Postcode)).
+		Select("Country", business.Country).
+		RichText("PlaceID", business.PlaceID).
+		MultiSelect("Type", business.Type).
+		Select("WebsiteStatus", business.WebsiteStatus).
+		Select("BusinessStatus", business.BusinessStatus).
+		Select("Urgency", business.Urgency).
+		Select("Contacted", business.Contacted).
+		URL("URL", business.URL).
+		Email("Email", business.Email).
+		Phone("Phone", business.Phone).
+		Number("ReviewCount", float64(business.ReviewCount)).
+		Number("EmailConfidence", float64(business.EmailConfidence)).
+		Number("LeadScore", float64(business.LeadScore)).
+		RichText("Trend", business.Trend).
+		RichText("DuplicateOf", business.DuplicateOf).
+		Checkbox("SSL", business.HTTPS).
+		Checkbox("HoursListed", business.HoursListed).
+		Checkbox("LikelyUnclaimed", business.LikelyUnclaimed).
+		RichText("UnclaimedSignals", strings.Join(business.UnclaimedSignals, ", ")).
+		RichText("OpeningHours", business.OpeningHours).
+		RichText("ReviewThemes", business.ReviewThemes).
+		RichText("SuggestedDomain", business.SuggestedDomain).
+		Date("DomainRegistered", business.DomainRegistered).
+		RichText("Registrar", business.Registrar).
+		URL("Facebook", business.Facebook).
+		URL("Instagram", business.Instagram).
+		URL("LinkedIn", business.LinkedIn).
+		URL("X", business.X).
+		Select("SearchArea", business.SearchArea).
+		Select("Campaign", business.Campaign).
+		Select("SearchedType", business.SearchedType).
+		RichText("SearchKeyword", business.SearchKeyword).
+		RichText("SearchCell", business.SearchCell).
+		RichText("RunID", business.RunID).
+		MultiSelect("Sources", business.Sources).
+		Select("FirstSource", business.FirstSource).
+		RichText("Provenance", formatProvenance(business.Provenance)).
+		RichText("CompanyNumber", business.CompanyNumber).
+		Select("CompanyStatus", business.CompanyStatus).
+		Date("IncorporationDate", business.IncorporationDate).
+		RichText("RegisteredAddress", business.RegisteredAddress).
+		Select("AssignedTo", business.AssignedTo).
+		Date("AssignedDate", business.AssignedDate).
+		Number("CadenceStep", float64(business.CadenceStep)).
+		Date("LastActionDate", business.LastActionDate).
+		Select("NextAction", business.NextAction).
+		Date("NextActionDate", business.NextActionDate).
+		Date("LastContacted", business.LastContacted)
+	if business.NextAction == cadenceDone || business.NextAction == cadenceStopped {
+		// Follow-ups no longer due are emptied, so they drop out of views sorted or filtered by date
+		pb.Clear("NextFollowUp", notionapi.PropertyTypeDate)
+	} else {
+		pb.Date("NextFollowUp", business.NextFollowUp)
+	}
+	if business.ReviewCount > 0 {
+		pb.Number("Rating", business.Rating)
+	}
+	if business.located() {
+		pb.Number("Latitude", business.Location.Lat).Number("Longitude", business.Location.Lng)
+	}
+	if business.DistanceKm > 0 {
+		pb.Number("DistanceKm", business.DistanceKm)
+	}
+	return pb
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/jomei/notionapi"
+)
+
+func TestPropertyBuilderErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		build func(pb *PropertyBuilder) *PropertyBuilder
+		want  string
+	}{
+		{"empty title", func(pb *PropertyBuilder) *PropertyBuilder { return pb.Title("Name", "  ") }, `property "Name": title must not be empty`},
+		{"relative URL", func(pb *PropertyBuilder) *PropertyBuilder { return pb.URL("URL", "example.com") }, `property "URL": invalid URL "example.com"`},
+		{"non-http URL", func(pb *PropertyBuilder) *PropertyBuilder { return pb.URL("URL", "ftp://example.com") }, `invalid URL "ftp://example.com"`},
+		{"bad email", func(pb *PropertyBuilder) *PropertyBuilder { return pb.Email("Email", "info@") }, `property "Email": invalid email "info@"`},
+		{"phone without plus", func(pb *PropertyBuilder) *PropertyBuilder { return pb.Phone("Phone", "01326212345") }, `phone "01326212345" is not E.164`},
+		{"phone with spaces", func(pb *PropertyBuilder) *PropertyBuilder { return pb.Phone("Phone", "+44 1326 212345") }, `phone "+44 1326 212345" is not E.164`},
+		{"phone too short", func(pb *PropertyBuilder) *PropertyBuilder { return pb.Phone("Phone", "+44") }, `phone "+44" is not E.164`},
+		{"duplicate property", func(pb *PropertyBuilder) *PropertyBuilder {
+			return pb.RichText("Address", "1 Church St").RichText("Address", "2 Church St")
+		}, `property "Address" set twice`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			props, err := tt.build(NewProperties(nil)).Build()
+			if err == nil {
+				t.Fatalf("Build() = %v, want error containing %q", props, tt.want)
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Build() error = %q, want it to contain %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestPropertyBuilderJoinsErrors(t *testing.T) {
+	_, err := NewProperties(nil).Title("Name", "").Email("Email", "nope").Build()
+	if err == nil {
+		t.Fatal("Build() succeeded, want both errors")
+	}
+	for _, want := range []string{"title must not be empty", `invalid email "nope"`} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Build() error = %q, want it to contain %q", err, want)
+		}
+	}
+}
+
+func TestPropertyBuilderValid(t *testing.T) {
+	props, err := NewProperties(nil).
+		Title("Name", "Harbour Bakery").
+		URL("URL", "https://harbourbakery.example").
+		Email("Email", "hello@harbourbakery.example").
+		Phone("Phone", "+441326212345").
+		Select("Urgency", "High").
+		RichText("Notes", "").
+		Build()
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+	for _, name := range []string{"Name", "URL", "Email", "Phone", "Urgency"} {
+		if _, ok := props[name]; !ok {
+			t.Errorf("Build() is missing %s", name)
+		}
+	}
+	if _, ok := props["Notes"]; ok {
+		t.Error("Build() set Notes, want empty values skipped")
+	}
+}
+
+func TestRichTextChunking(t *testing.T) {
+	tests := []struct {
+		name   string
+		length int
+		chunks []int
+	}{
+		{"empty", 0, nil},
+		{"at the limit", maxRichTextLength, []int{maxRichTextLength}},
+		{"one over", maxRichTextLength + 1, []int{maxRichTextLength, 1}},
+		{"several", 2*maxRichTextLength + 10, []int{maxRichTextLength, maxRichTextLength, 10}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chunks := richText(strings.Repeat("a", tt.length))
+			if len(chunks) != len(tt.chunks) {
+				t.Fatalf("richText() made %d chunks, want %d", len(chunks), len(tt.chunks))
+			}
+			for i, chunk := range chunks {
+				if got := len(chunk.Text.Content); got != tt.chunks[i] {
+					t.Errorf("chunk %d has %d characters, want %d", i, got, tt.chunks[i])
+				}
+			}
+		})
+	}
+}
+
+func TestRichTextChunksRunes(t *testing.T) {
+	// Notion counts characters, not bytes, so multi-byte text is split on rune boundaries
+	chunks := richText(strings.Repeat("é", maxRichTextLength+1))
+	if len(chunks) != 2 || len([]rune(chunks[0].Text.Content)) != maxRichTextLength || chunks[1].Text.Content != "é" {
+		t.Errorf("richText() split multi-byte text wrongly into %d chunks", len(chunks))
+	}
+}
+
+func TestRichTextObjectLimit(t *testing.T) {
+	chunks := richText(strings.Repeat("a", (maxRichTextObjects+5)*maxRichTextLength))
+	if len(chunks) != maxRichTextObjects {
+		t.Errorf("richText() made %d chunks, want at most %d", len(chunks), maxRichTextObjects)
+	}
+}
+
+func TestClearJSON(t *testing.T) {
+	tests := []struct {
+		propType notionapi.PropertyType
+		want     string
+	}{
+		{notionapi.PropertyTypeRichText, `{"rich_text":[]}`},
+		{notionapi.PropertyTypeMultiSelect, `{"multi_select":[]}`},
+		{notionapi.PropertyTypeRelation, `{"relation":[]}`},
+		{notionapi.PropertyTypeEmail, `{"email":null}`},
+		{notionapi.PropertyTypePhoneNumber, `{"phone_number":null}`},
+		{notionapi.PropertyTypeURL, `{"url":null}`},
+		{notionapi.PropertyTypeSelect, `{"select":null}`},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.propType), func(t *testing.T) {
+			props, err := NewProperties(nil).Clear("Field", tt.propType).Build()
+			if err != nil {
+				t.Fatalf("Build() error = %v", err)
+			}
+			data, err := json.Marshal(props["Field"])
+			if err != nil {
+				t.Fatalf("Marshal() error = %v", err)
+			}
+			if string(data) != tt.want {
+				t.Errorf("Marshal() = %s, want %s", data, tt.want)
+			}
+		})
+	}
+}
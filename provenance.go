@@ -0,0 +1,76 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// mergedFields are the fields a later source's listing of a lead can fill in
+var mergedFields = []string{"Phone", "Email", "OpeningHours", "Facebook", "Instagram", "LinkedIn", "X"}
+
+// FieldSources are per-field provenance rules for merging listings, listing the sources a field
+// may come from, most trusted first, e.g. {"OpeningHours": ["osm", "google"]}. A source earlier in
+// a field's list replaces a value from one later in it or not in it; sources not listed never
+// set the field. Fields without a rule are filled from any source, but only when empty. The
+// source that finds a lead always provides its initial values.
+type FieldSources map[string][]string
+
+// Validate checks that the rules name mergeable fields and known sources
+func (fs FieldSources) Validate() error {
+	for field, sources := range fs {
+		if !slices.Contains(mergedFields, field) {
+			return fmt.Errorf("%q is not a field sources can fill in, expected one of %s", field, strings.Join(mergedFields, ", "))
+		}
+		for _, source := range sources {
+			if !slices.Contains(knownSources, source) {
+				return fmt.Errorf("%s: unknown source %q", field, source)
+			}
+		}
+	}
+	return nil
+}
+
+// allows reports whether source may set a field whose current value came from current, which is
+// "" when the field is empty
+func (fs FieldSources) allows(field, source, current string) bool {
+	rule, ok := fs[field]
+	if !ok {
+		return current == ""
+	}
+	rank := slices.Index(rule, source)
+	if rank < 0 {
+		return false
+	}
+	held := slices.Index(rule, current)
+	return current == "" || held < 0 || rank < held
+}
+
+// formatProvenance renders the sources of merged fields as e.g. "OpeningHours: osm, Phone: yelp"
+func formatProvenance(provenance map[string]string) string {
+	fields := make([]string, 0, len(provenance))
+	for field := range provenance {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	parts := make([]string, len(fields))
+	for i, field := range fields {
+		parts[i] = field + ": " + provenance[field]
+	}
+	return strings.Join(parts, ", ")
+}
+
+// parseProvenance reads back what formatProvenance wrote
+func parseProvenance(s string) map[string]string {
+	if s == "" {
+		return nil
+	}
+	provenance := make(map[string]string)
+	for _, part := range strings.Split(s, ",") {
+		if field, source, ok := strings.Cut(part, ":"); ok {
+			provenance[strings.TrimSpace(field)] = strings.TrimSpace(source)
+		}
+	}
+	return provenance
+}
@@ -0,0 +1,294 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// mapsAPIBaseURL is where Maps web service requests go unless --maps-proxy points elsewhere
+const mapsAPIBaseURL = "https://maps.googleapis.com"
+
+// cachedResponse is an upstream response kept for replay
+type cachedResponse struct {
+	status  int
+	header  http.Header
+	body    []byte
+	expires time.Time
+}
+
+// maxCachedResponses caps the proxy's cache; once full, expired entries are swept and then the
+// ones closest to expiring evicted
+const maxCachedResponses = 10000
+
+// MapsProxy is a caching reverse proxy for the Maps web services. Clients share its cache,
+// its request rate and its daily budget of upstream requests.
+type MapsProxy struct {
+	upstream  *url.URL
+	client    *http.Client
+	apiKey    string
+	ttl       time.Duration
+	limiter   *rate.Limiter
+	maxPerDay int
+
+	mu       sync.Mutex
+	cache    map[string]*cachedResponse
+	day      time.Time
+	spent    int
+	hits     int
+	misses   int
+	rejected int
+}
+
+// NewMapsProxy initializes a new MapsProxy. A non-empty apiKey replaces the key sent by clients;
+// maxPerDay of 0 means no budget.
+func NewMapsProxy(upstream *url.URL, apiKey string, ttl time.Duration, qps float64, maxPerDay int) *MapsProxy {
+	return &MapsProxy{
+		upstream:  upstream,
+		apiKey:    apiKey,
+		ttl:       ttl,
+		limiter:   rate.NewLimiter(rate.Limit(qps), 1),
+		maxPerDay: maxPerDay,
+		cache:     make(map[string]*cachedResponse),
+		client: &http.Client{
+			Timeout: 30 * time.Second,
+			// Photo requests redirect to the image; the redirect itself is what is cached and replayed
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+	}
+}
+
+// cacheKey identifies a request by its path and query, without the API key, so clients using
+// different keys share results
+func cacheKey(u *url.URL) string {
+	query := u.Query()
+	query.Del("key")
+	return u.Path + "?" + query.Encode()
+}
+
+func (p *MapsProxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "only GET requests are proxied", http.StatusMethodNotAllowed)
+		return
+	}
+
+	key := cacheKey(r.URL)
+	if cached := p.lookup(key); cached != nil {
+		writeCached(w, cached, "HIT")
+		return
+	}
+
+	if !p.spend() {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusTooManyRequests)
+		json.NewEncoder(w).Encode(map[string]string{
+			"status":        "OVER_QUERY_LIMIT",
+			"error_message": "proxy daily request budget exhausted",
+		})
+		return
+	}
+	if err := p.limiter.Wait(r.Context()); err != nil {
+		// The client went away or the wait would outlast its deadline; nothing was sent upstream
+		p.refund()
+		http.Error(w, "proxy rate limit wait cancelled", http.StatusServiceUnavailable)
+		return
+	}
+
+	resp, err := p.fetch(r)
+	if err != nil {
+		slog.Error("Proxy request failed", "operation", "proxy", "path", r.URL.Path, "err", err)
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	if cacheable(resp) {
+		p.store(key, resp)
+	}
+	writeCached(w, resp, "MISS")
+}
+
+// lookup returns an unexpired cache entry
+func (p *MapsProxy) lookup(key string) *cachedResponse {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	cached, ok := p.cache[key]
+	if !ok || time.Now().After(cached.expires) {
+		delete(p.cache, key)
+		return nil
+	}
+	p.hits++
+	return cached
+}
+
+// store caches an upstream response for the proxy's TTL
+func (p *MapsProxy) store(key string, resp *cachedResponse) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	now := time.Now()
+	if _, ok := p.cache[key]; !ok && len(p.cache) >= maxCachedResponses {
+		for k, cached := range p.cache {
+			if now.After(cached.expires) {
+				delete(p.cache, k)
+			}
+		}
+		for len(p.cache) >= maxCachedResponses {
+			oldest := ""
+			for k, cached := range p.cache {
+				if oldest == "" || cached.expires.Before(p.cache[oldest].expires) {
+					oldest = k
+				}
+			}
+			delete(p.cache, oldest)
+		}
+	}
+	resp.expires = now.Add(p.ttl)
+	p.cache[key] = resp
+}
+
+// refund returns a request taken by spend that was never sent upstream
+func (p *MapsProxy) refund() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.spent > 0 {
+		p.spent--
+	}
+	p.misses--
+}
+
+// spend takes one upstream request from today's budget, reporting false once it is used up
+func (p *MapsProxy) spend() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if today := startOfDay(time.Now()); !today.Equal(p.day) {
+		p.day, p.spent = today, 0
+	}
+	if p.maxPerDay > 0 && p.spent >= p.maxPerDay {
+		p.rejected++
+		return false
+	}
+	p.spent++
+	p.misses++
+	return true
+}
+
+// fetch forwards a request upstream, substituting the proxy's API key when it has one
+func (p *MapsProxy) fetch(r *http.Request) (*cachedResponse, error) {
+	target := *p.upstream
+	target.Path = r.URL.Path
+	query := r.URL.Query()
+	if p.apiKey != "" {
+		query.Set("key", p.apiKey)
+	}
+	target.RawQuery = query.Encode()
+
+	req, err := http.NewRequestWithContext(r.Context(), http.MethodGet, target.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	header := make(http.Header)
+	for _, name := range []string{"Content-Type", "Location"} {
+		if value := resp.Header.Get(name); value != "" {
+			header.Set(name, value)
+		}
+	}
+	return &cachedResponse{status: resp.StatusCode, header: header, body: body}, nil
+}
+
+// cacheable reports whether a response is a success worth replaying. The Maps APIs report
+// errors such as OVER_QUERY_LIMIT in the body of a 200, so JSON bodies are checked too.
+func cacheable(resp *cachedResponse) bool {
+	if resp.status >= 300 && resp.status < 400 {
+		return resp.header.Get("Location") != ""
+	}
+	if resp.status != http.StatusOK {
+		return false
+	}
+	if !strings.HasPrefix(resp.header.Get("Content-Type"), "application/json") {
+		return true
+	}
+	var body struct {
+		Status string `json:"status"`
+	}
+	if err := json.NewDecoder(bytes.NewReader(resp.body)).Decode(&body); err != nil {
+		return false
+	}
+	return body.Status == "OK" || body.Status == "ZERO_RESULTS"
+}
+
+// writeCached replays a response to the client
+func writeCached(w http.ResponseWriter, resp *cachedResponse, cacheStatus string) {
+	for name, values := range resp.header {
+		w.Header()[name] = values
+	}
+	w.Header().Set("X-Cache", cacheStatus)
+	w.WriteHeader(resp.status)
+	w.Write(resp.body)
+}
+
+// Stats summarizes the proxy's cache and budget use
+func (p *MapsProxy) Stats() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return fmt.Sprintf("%d cached, %d hits, %d upstream requests (%d today), %d over budget",
+		len(p.cache), p.hits, p.misses, p.spent, p.rejected)
+}
+
+// runProxy serves the caching Maps proxy until the process is stopped
+func runProxy(args []string) {
+	fs := flag.NewFlagSet("proxy", flag.ExitOnError)
+	listen := fs.String("listen", "127.0.0.1:8089", "address to listen on")
+	upstream := fs.String("upstream", mapsAPIBaseURL, "Maps web services base URL to forward to")
+	ttl := fs.Duration("cache-ttl", 24*time.Hour, "how long successful responses are replayed from the cache")
+	qps := fs.Float64("qps", 10, "maximum upstream requests per second across all clients")
+	maxPerDay := fs.Int("max-requests", 0, "daily budget of upstream requests; further uncached requests are refused (0 for no limit)")
+	injectKey := fs.Bool("inject-key", false, "send GOOGLE_PLACES_API_KEY upstream instead of the key each client sends")
+	statsEvery := fs.Duration("stats", 5*time.Minute, "log cache statistics at this interval (0 to disable)")
+	fs.Parse(args)
+
+	upstreamURL, err := url.Parse(*upstream)
+	if err != nil || upstreamURL.Host == "" {
+		log.Fatalf("Invalid upstream URL %q", *upstream)
+	}
+	apiKey := ""
+	if *injectKey {
+		apiKey = os.Getenv("GOOGLE_PLACES_API_KEY")
+		if apiKey == "" {
+			log.Fatal("GOOGLE_PLACES_API_KEY must be set to use --inject-key")
+		}
+	}
+
+	proxy := NewMapsProxy(upstreamURL, apiKey, *ttl, *qps, *maxPerDay)
+	if *statsEvery > 0 {
+		go func() {
+			for range time.Tick(*statsEvery) {
+				slog.Info("Proxy stats", "operation", "proxy", "stats", proxy.Stats())
+			}
+		}()
+	}
+
+	fmt.Printf("Proxying %s on http://%s\n", upstreamURL, *listen)
+	log.Fatal(http.ListenAndServe(*listen, proxy))
+}
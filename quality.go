@@ -0,0 +1,92 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Severities of data quality issues, by how much they get in the way of outreach
+const (
+	severityLow = iota + 1
+	severityMedium
+	severityHigh
+)
+
+var severityNames = map[int]string{severityLow: "low", severityMedium: "medium", severityHigh: "high"}
+
+// qualityIssue is a problem with one lead's data
+type qualityIssue struct {
+	severity int
+	kind     string
+	business *Business
+	detail   string
+}
+
+// lead names the lead an issue is about, by its lead number once it has one
+func (qi qualityIssue) lead() string {
+	if qi.business.LeadNumber == "" {
+		return qi.business.Name
+	}
+	return qi.business.LeadNumber + " " + qi.business.Name
+}
+
+// QualityReport collects the data quality issues of a run
+type QualityReport struct {
+	issues []qualityIssue
+}
+
+func (qr *QualityReport) add(severity int, kind string, b *Business, format string, args ...any) {
+	qr.issues = append(qr.issues, qualityIssue{severity: severity, kind: kind, business: b, detail: fmt.Sprintf(format, args...)})
+}
+
+// Merge adds the issues of another report
+func (qr *QualityReport) Merge(other QualityReport) {
+	qr.issues = append(qr.issues, other.issues...)
+}
+
+// CheckLead records the issues visible in a written lead's own data. A lead that can only be
+// reached by phone is hit hardest by an unparseable number.
+func (qr *QualityReport) CheckLead(b *Business) {
+	if b.ListedPhone != "" && b.Phone == "" {
+		severity := severityMedium
+		if b.Email == "" {
+			severity = severityHigh
+		}
+		qr.add(severity, "unparseable phone", b, "%q is not a phone number that could be normalized", b.ListedPhone)
+	}
+	if strings.TrimSpace(b.Address) == "" {
+		qr.add(severityMedium, "missing address", b, "no address listed")
+	}
+}
+
+// Len is the number of issues found
+func (qr *QualityReport) Len() int { return len(qr.issues) }
+
+// String lists the issues, most severe first, with a count per kind
+func (qr *QualityReport) String() string {
+	issues := slices.Clone(qr.issues)
+	slices.SortStableFunc(issues, func(a, b qualityIssue) int {
+		return cmp.Or(b.severity-a.severity, strings.Compare(a.kind, b.kind), strings.Compare(a.lead(), b.lead()))
+	})
+
+	counts := make(map[string]int)
+	var kinds []string
+	for _, issue := range issues {
+		if counts[issue.kind] == 0 {
+			kinds = append(kinds, issue.kind)
+		}
+		counts[issue.kind]++
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Data quality: %d issues\n", len(issues))
+	for _, kind := range kinds {
+		fmt.Fprintf(&sb, "  %s: %d\n", kind, counts[kind])
+	}
+	for _, issue := range issues {
+		fmt.Fprintf(&sb, "[%s] %s: %s - %s\n", severityNames[issue.severity], issue.kind, issue.lead(), issue.detail)
+	}
+	return sb.String()
+}
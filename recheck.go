@@ -0,0 +1,169 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"log/slog"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/jomei/notionapi"
+	"golang.org/x/time/rate"
+	"googlemaps.github.io/maps"
+)
+
+// recheckedFields are the fields a recheck can change
+var recheckedFields = []string{"WebsiteStatus", "URL", "SSL", "BusinessStatus", "Urgency", "LeadScore"}
+
+// recheckDetailsFields are all a recheck asks of place details, keeping it on the contact tier
+var recheckDetailsFields = []maps.PlaceDetailsFieldMask{maps.PlaceDetailsFieldMaskWebsite, maps.PlaceDetailsFieldMaskBusinessStatus}
+
+// runRecheck re-fetches the place details of every stored lead and probes its website again, then
+// updates the leads whose website, business status or urgency changed, so the database stays
+// current between prospecting runs. Leads from other sources only have their website probed.
+func runRecheck(notionClient *NotionClient, cfg *Config, places PlacesProvider, budget *Budget, weights ScoreWeights, history *History, sinks []Sink, args []string) {
+	fs := flag.NewFlagSet("recheck", flag.ExitOnError)
+	area := fs.String("area", "", "only recheck leads found searching this area")
+	limit := fs.Int("limit", 0, "recheck at most this many leads (0 for all)")
+	dryRun := fs.Bool("dry-run", false, "list the leads that would change without changing them")
+	fs.Parse(args)
+
+	ctx := context.Background()
+	filter := notionapi.AndCompoundFilter{notionapi.PropertyFilter{
+		Property: "Contacted",
+		Select:   &notionapi.SelectFilterCondition{DoesNotEqual: doNotContact},
+	}}
+	if *area != "" {
+		filter = append(filter, notionapi.PropertyFilter{
+			Property: "SearchArea",
+			Select:   &notionapi.SelectFilterCondition{Equals: *area},
+		})
+	}
+	leads, err := notionClient.ListBusinesses(ctx, filter)
+	if err != nil {
+		log.Fatalf("Failed to list leads: %v", err)
+	}
+	sortBusinesses(leads)
+	if *limit > 0 && len(leads) > *limit {
+		leads = leads[:*limit]
+	}
+
+	crawler := NewWebsiteCrawler()
+	limiter := rate.NewLimiter(notionRequestsPerSecond, 1)
+	changed, failed := 0, 0
+	for i := range leads {
+		b := &leads[i]
+		before := *b
+		if err := recheck(ctx, places, crawler, cfg.Policy, b); err != nil {
+			if errors.Is(err, errBudgetExhausted) {
+				slog.Warn("Stopped rechecking, Google API budget reached", "operation", "recheck", "err", err)
+				break
+			}
+			slog.Error("Failed to recheck lead", "operation", "recheck", "place_id", b.PlaceID, "name", b.Name, "err", err)
+			failed++
+			continue
+		}
+		now := time.Now()
+		history.Record(b, now)
+		history.Apply(b, now)
+		b.Urgency = EvaluateUrgency(cfg.UrgencyRules, b)
+		b.LeadScore, b.ScoreBreakdown = ScoreLead(weights, b)
+
+		fields := recheckChanges(before, *b)
+		if len(fields) == 0 {
+			continue
+		}
+		changed++
+		fmt.Printf("%s (%s): %s\n", b.Name, b.LeadNumber, describeRecheck(before, *b, fields))
+		if *dryRun {
+			continue
+		}
+		if err := limiter.Wait(ctx); err != nil {
+			log.Fatal(err)
+		}
+		updateSinks(ctx, sinks, before, b, fields, "recheck")
+	}
+
+	verb := "Updated"
+	if *dryRun {
+		verb = "Would update"
+	}
+	fmt.Printf("Rechecked %d leads. %s %d, %d failed\n", len(leads), verb, changed, failed)
+	fmt.Print(budget.String())
+	if err := budget.ledger.Save(); err != nil {
+		slog.Error("Failed to save usage ledger", "operation", "recheck", "path", budget.ledger.path, "err", err)
+	}
+	if !*dryRun {
+		if err := history.Save(); err != nil {
+			slog.Error("Failed to save lead history", "operation", "recheck", "path", history.path, "err", err)
+		}
+	}
+}
+
+// recheck refreshes a lead's website and business status from its place details, then probes its
+// website. Only running out of budget or the details failing is returned as an error.
+func recheck(ctx context.Context, places PlacesProvider, crawler *WebsiteCrawler, policy Policy, b *Business) error {
+	// Other sources' IDs are prefixed, e.g. osm:node/123; Google's never contain a colon
+	if places != nil && !strings.Contains(b.PlaceID, ":") {
+		details, err := places.PlaceDetails(ctx, &maps.PlaceDetailsRequest{PlaceID: b.PlaceID, Fields: recheckDetailsFields})
+		if err != nil {
+			return err
+		}
+		b.BusinessStatus = details.BusinessStatus
+		website := details.Website
+		if socials := classifySocialURL(website); !socials.IsEmpty() {
+			website = ""
+		}
+		switch {
+		case website != "":
+			b.WebsiteStatus, b.URL = "Has Website", website
+		case b.WebsiteStatus != "No Website":
+			b.WebsiteStatus = "No Website"
+			b.URL = "https://www.google.com/maps/search/?api=1&query=" + url.QueryEscape(b.Address)
+			b.HTTPS = false
+		}
+	}
+
+	if b.WebsiteStatus != "Has Website" && b.WebsiteStatus != "Broken Website" {
+		return nil
+	}
+	if !policy.AllowsSite(b.URL) {
+		b.HTTPS = strings.HasPrefix(b.URL, "https://")
+		return nil
+	}
+	site, err := crawler.Crawl(ctx, b.URL)
+	if err != nil {
+		slog.Debug("Website is broken", "operation", "recheck", "place_id", b.PlaceID, "name", b.Name, "url", b.URL, "err", err)
+		b.WebsiteStatus = "Broken Website"
+		return nil
+	}
+	b.WebsiteStatus, b.HTTPS = "Has Website", site.HTTPS
+	return nil
+}
+
+// recheckChanges lists the rechecked fields that differ between the lead as it was and is
+func recheckChanges(before, after Business) []string {
+	old, updated := templateData(before), templateData(after)
+	var fields []string
+	for _, field := range recheckedFields {
+		if old[field] != updated[field] {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+// describeRecheck summarizes what changed, e.g. "WebsiteStatus Has Website -> Broken Website"
+func describeRecheck(before, after Business, fields []string) string {
+	old, updated := templateData(before), templateData(after)
+	parts := make([]string, len(fields))
+	for i, field := range fields {
+		parts[i] = fmt.Sprintf("%s %s -> %s", field, cmp.Or(old[field], "none"), cmp.Or(updated[field], "none"))
+	}
+	return strings.Join(parts, ", ")
+}
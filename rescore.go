@@ -0,0 +1,101 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// rescoredFields are the fields a rescore can change
+var rescoredFields = []string{"Urgency", "LeadScore", "Trend"}
+
+// runRescore re-evaluates the urgency rules, lead score and trend of every stored lead, without any
+// Places requests, and pushes the leads whose values changed to the sinks. Trends come from the
+// snapshots search runs recorded in the history. With --every it repeats on that
+// interval, e.g. nightly between full searches.
+func runRescore(notionClient *NotionClient, cfg *Config, weights ScoreWeights, history *History, args []string) {
+	fs := flag.NewFlagSet("rescore", flag.ExitOnError)
+	dryRun := fs.Bool("dry-run", false, "list the leads whose urgency or score would change without changing them")
+	every := fs.Duration("every", 0, "keep running and rescore at this interval, e.g. 24h (0 to rescore once)")
+	fs.Parse(args)
+
+	for {
+		withSinks(cfg.Sinks, notionClient, func(sinks []Sink) {
+			if err := rescore(context.Background(), notionClient, sinks, cfg.UrgencyRules, weights, history, *dryRun); err != nil {
+				slog.Error("Rescore failed", "operation", "rescore", "err", err)
+			}
+		})
+		if *every <= 0 {
+			return
+		}
+		fmt.Printf("Next rescore at %s\n", time.Now().Add(*every).Format(time.DateTime))
+		time.Sleep(*every)
+	}
+}
+
+// rescore scores every lead and updates the ones whose urgency, score or trend changed
+func rescore(ctx context.Context, notionClient *NotionClient, sinks []Sink, rules []UrgencyRule, weights ScoreWeights, history *History, dryRun bool) error {
+	var changed []Business
+	// before are the changed leads as they were; only leads whose score moved get a new explanation
+	before := make(map[string]Business)
+	total := 0
+	err := notionClient.EachLead(ctx, nil, func(b Business) error {
+		total++
+		original, trend := b, b.Trend
+		history.Apply(&b, time.Now())
+		urgency := EvaluateUrgency(rules, &b)
+		score, components := ScoreLead(weights, &b)
+		// A trend that aged out of the history isn't cleared, so the page keeps the last one seen
+		if urgency == b.Urgency && score == b.LeadScore && (b.Trend == "" || b.Trend == trend) {
+			return nil
+		}
+		fmt.Printf("%s (%s): urgency %s -> %s, score %d -> %d", b.Name, b.LeadNumber,
+			cmp.Or(b.Urgency, "none"), cmp.Or(urgency, "none"), b.LeadScore, score)
+		if b.Trend != "" {
+			fmt.Printf(", %s", b.Trend)
+		}
+		fmt.Println()
+		before[b.PageID] = original
+		b.Urgency, b.LeadScore, b.ScoreBreakdown = urgency, score, components
+		changed = append(changed, b)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("listing leads: %w", err)
+	}
+	if stableOrder {
+		sortBusinesses(changed)
+	}
+
+	if !dryRun {
+		limiter := rate.NewLimiter(notionRequestsPerSecond, 1)
+		for i := range changed {
+			if err := limiter.Wait(ctx); err != nil {
+				return err
+			}
+			b := &changed[i]
+			updateSinks(ctx, sinks, before[b.PageID], b, rescoredFields, "rescore")
+			if b.LeadScore == before[b.PageID].LeadScore {
+				continue
+			}
+			if err := limiter.Wait(ctx); err != nil {
+				return err
+			}
+			if err := notionClient.pageClient(b.PageID).AppendScoreExplanation(ctx, b.PageID, b.LeadScore, b.ScoreBreakdown); err != nil {
+				slog.Error("Failed to explain new score", "operation", "rescore", "place_id", b.PlaceID, "name", b.Name, "err", err)
+			}
+		}
+	}
+
+	verb := "Updated"
+	if dryRun {
+		verb = "Would update"
+	}
+	fmt.Printf("Rescored %d leads. %s %d\n", total, verb, len(changed))
+	return nil
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"sync"
+)
+
+// ReviewQueue holds leads waiting to be reviewed on the dashboard before they are pushed to
+// Notion, kept in a JSON file between runs
+type ReviewQueue struct {
+	path string
+
+	mu    sync.Mutex
+	leads []*Business
+}
+
+var (
+	reviewQueuesMu sync.Mutex
+	// reviewQueues share one queue per file between the review sink of each run and the dashboard
+	reviewQueues = make(map[string]*ReviewQueue)
+)
+
+// openReviewQueue returns the queue kept at path, reading it the first time
+func openReviewQueue(path string) (*ReviewQueue, error) {
+	reviewQueuesMu.Lock()
+	defer reviewQueuesMu.Unlock()
+	if rq, ok := reviewQueues[path]; ok {
+		return rq, nil
+	}
+	rq := &ReviewQueue{path: path}
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	if err == nil {
+		if err := json.Unmarshal(data, &rq.leads); err != nil {
+			return nil, fmt.Errorf("parsing %s: %w", path, err)
+		}
+	}
+	reviewQueues[path] = rq
+	return rq, nil
+}
+
+// Leads returns copies of the queued leads
+func (rq *ReviewQueue) Leads() []Business {
+	rq.mu.Lock()
+	defer rq.mu.Unlock()
+	leads := make([]Business, len(rq.leads))
+	for i, b := range rq.leads {
+		leads[i] = *b
+	}
+	return leads
+}
+
+// Add queues a lead, reporting false if it already is
+func (rq *ReviewQueue) Add(b *Business) bool {
+	rq.mu.Lock()
+	defer rq.mu.Unlock()
+	if rq.indexLocked(b.PlaceID) >= 0 {
+		return false
+	}
+	lead := *b
+	rq.leads = append(rq.leads, &lead)
+	return true
+}
+
+// Replace swaps the queued copy of a lead for b, e.g. after another source's listing was merged in
+func (rq *ReviewQueue) Replace(b *Business) {
+	rq.mu.Lock()
+	defer rq.mu.Unlock()
+	if i := rq.indexLocked(b.PlaceID); i >= 0 {
+		lead := *b
+		rq.leads[i] = &lead
+	}
+}
+
+// Take removes the leads with the given place IDs from the queue and returns them
+func (rq *ReviewQueue) Take(placeIDs []string) []Business {
+	rq.mu.Lock()
+	defer rq.mu.Unlock()
+	var taken []Business
+	rq.leads = slices.DeleteFunc(rq.leads, func(b *Business) bool {
+		if slices.Contains(placeIDs, b.PlaceID) {
+			taken = append(taken, *b)
+			return true
+		}
+		return false
+	})
+	return taken
+}
+
+func (rq *ReviewQueue) indexLocked(placeID string) int {
+	return slices.IndexFunc(rq.leads, func(b *Business) bool { return b.PlaceID == placeID })
+}
+
+// Save writes the queue
+func (rq *ReviewQueue) Save() error {
+	rq.mu.Lock()
+	defer rq.mu.Unlock()
+	data, err := json.MarshalIndent(rq.leads, "", "  ")
+	if err != nil {
+		return err
+	}
+	return replaceFile(rq.path, func(f *os.File) error {
+		_, err := f.Write(data)
+		return err
+	})
+}
+
+// ReviewSink queues leads not yet in Notion for review on the dashboard instead of inserting them.
+// Its field selection applies when they are pushed to Notion.
+type ReviewSink struct {
+	client *NotionClient
+	queue  *ReviewQueue
+}
+
+func newReviewSink(path string, client *NotionClient) (*ReviewSink, error) {
+	queue, err := openReviewQueue(path)
+	if err != nil {
+		return nil, err
+	}
+	return &ReviewSink{client: client, queue: queue}, nil
+}
+
+func (rs *ReviewSink) Name() string { return "review" }
+
+// Write queues a lead, returning errBusinessExists for leads already in Notion or the queue
+func (rs *ReviewSink) Write(ctx context.Context, b *Business) error {
+	exists, err := rs.client.ExistsAnywhere(ctx, b.PlaceID)
+	if err != nil {
+		return err
+	}
+	if exists || !rs.queue.Add(b) {
+		return errBusinessExists
+	}
+	// Saved per lead, as the CSV sink flushes, so a crash mid-run doesn't lose the queue
+	return rs.queue.Save()
+}
+
+// Update refreshes the queued copy of a lead
+func (rs *ReviewSink) Update(ctx context.Context, b *Business, fields []string) error {
+	rs.queue.Replace(b)
+	return nil
+}
+
+func (rs *ReviewSink) Close() error { return rs.queue.Save() }
@@ -0,0 +1,234 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"googlemaps.github.io/maps"
+)
+
+// reviewThemes maps a theme label to keywords that indicate a review talks about it
+var reviewThemes = map[string][]string{
+	"service":     {"service", "served", "waiter", "waitress", "server"},
+	"staff":       {"staff", "team", "owner", "friendly", "helpful", "rude"},
+	"food":        {"food", "meal", "dish", "menu", "breakfast", "lunch", "dinner", "tasty", "delicious"},
+	"coffee":      {"coffee", "latte", "cappuccino", "espresso", "flat white"},
+	"value":       {"price", "prices", "value", "expensive", "cheap", "overpriced", "affordable"},
+	"cleanliness": {"clean", "dirty", "tidy", "hygiene"},
+	"wait times":  {"wait", "waited", "queue", "slow", "quick", "fast"},
+	"atmosphere":  {"atmosphere", "cosy", "cozy", "vibe", "decor", "ambience", "music"},
+	"location":    {"location", "parking", "view", "views", "seafront", "harbour"},
+	"quality":     {"quality", "professional", "recommend", "workmanship", "reliable"},
+}
+
+var positiveWords = []string{"great", "excellent", "amazing", "lovely", "friendly", "helpful", "delicious", "fantastic", "perfect", "recommend", "best", "wonderful", "good", "brilliant", "fab", "fabulous"}
+
+var negativeWords = []string{"bad", "poor", "rude", "slow", "dirty", "terrible", "awful", "worst", "disappointing", "disappointed", "overpriced", "cold", "never", "avoid", "horrible", "unhelpful"}
+
+// ReviewSummarizer condenses Place reviews into a one-line "Review themes" summary
+type ReviewSummarizer struct {
+	llm *LLMClient
+}
+
+// NewReviewSummarizer initializes a ReviewSummarizer; with a nil llm it uses the keyword summary
+func NewReviewSummarizer(llm *LLMClient) *ReviewSummarizer {
+	return &ReviewSummarizer{llm: llm}
+}
+
+// Summarize returns a one-line summary of the place's reviews, or "" if it has none with text
+func (rs *ReviewSummarizer) Summarize(ctx context.Context, reviews []maps.PlaceReview) (string, error) {
+	var texts []string
+	for _, review := range reviews {
+		if text := strings.TrimSpace(review.Text); text != "" {
+			texts = append(texts, text)
+		}
+	}
+	if len(texts) == 0 {
+		return "", nil
+	}
+
+	if rs.llm != nil {
+		summary, err := rs.llm.Complete(ctx, "Summarize the recurring themes of these customer reviews in one line of at most 20 words, "+
+			"noting what customers praise and what they complain about. Reviews:\n\n- "+strings.Join(texts, "\n- "))
+		if err == nil && summary != "" {
+			return strings.Join(strings.Fields(summary), " "), nil
+		}
+		// Fall back to the keyword summary so a flaky LLM doesn't lose the field
+		return keywordReviewSummary(reviews), err
+	}
+	return keywordReviewSummary(reviews), nil
+}
+
+// keywordReviewSummary scores sentiment with word lists and reports the most mentioned praised and criticised themes
+func keywordReviewSummary(reviews []maps.PlaceReview) string {
+	praised := make(map[string]int)
+	criticised := make(map[string]int)
+	positive, negative := 0, 0
+
+	for _, review := range reviews {
+		text := strings.ToLower(review.Text)
+		score := countWords(text, positiveWords) - countWords(text, negativeWords)
+		// The star rating is a stronger signal than our word lists when they disagree
+		if review.Rating >= 4 {
+			score++
+		} else if review.Rating > 0 && review.Rating <= 2 {
+			score--
+		}
+
+		for theme, keywords := range reviewThemes {
+			if countWords(text, keywords) == 0 {
+				continue
+			}
+			if score >= 0 {
+				praised[theme]++
+			} else {
+				criticised[theme]++
+			}
+		}
+		if score >= 0 {
+			positive++
+		} else {
+			negative++
+		}
+	}
+
+	sentiment := "Mixed"
+	switch {
+	case negative == 0:
+		sentiment = "Positive"
+	case positive == 0:
+		sentiment = "Negative"
+	case positive >= 2*negative:
+		sentiment = "Mostly positive"
+	case negative >= 2*positive:
+		sentiment = "Mostly negative"
+	}
+
+	summary := fmt.Sprintf("%s (%d/%d reviews)", sentiment, positive, positive+negative)
+	if top := topThemes(praised, 3); len(top) > 0 {
+		summary += "; praised: " + strings.Join(top, ", ")
+	}
+	if top := topThemes(criticised, 2); len(top) > 0 {
+		summary += "; complaints: " + strings.Join(top, ", ")
+	}
+	return summary
+}
+
+// countWords counts how many of words appear in text as whole words or phrases; text must be lowercase
+func countWords(text string, words []string) int {
+	tokens := make(map[string]bool)
+	for _, token := range strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && r != '\''
+	}) {
+		tokens[token] = true
+	}
+
+	count := 0
+	for _, word := range words {
+		if tokens[word] || (strings.Contains(word, " ") && strings.Contains(text, word)) {
+			count++
+		}
+	}
+	return count
+}
+
+// topThemes returns up to n themes ordered by mention count, then name
+func topThemes(counts map[string]int, n int) []string {
+	var themes []string
+	for theme := range counts {
+		themes = append(themes, theme)
+	}
+	sort.Slice(themes, func(i, j int) bool {
+		if counts[themes[i]] != counts[themes[j]] {
+			return counts[themes[i]] > counts[themes[j]]
+		}
+		return themes[i] < themes[j]
+	})
+	if len(themes) > n {
+		themes = themes[:n]
+	}
+	return themes
+}
+
+// LLMClient calls an OpenAI-compatible chat completions API
+type LLMClient struct {
+	apiURL string
+	apiKey string
+	model  string
+	client *http.Client
+}
+
+// NewLLMClientFromEnv configures an LLMClient from LLM_API_URL, LLM_API_KEY and LLM_MODEL
+func NewLLMClientFromEnv() (*LLMClient, error) {
+	apiKey := os.Getenv("LLM_API_KEY")
+	if apiKey == "" {
+		return nil, fmt.Errorf("LLM_API_KEY must be set to summarize reviews with an LLM")
+	}
+	apiURL := os.Getenv("LLM_API_URL")
+	if apiURL == "" {
+		apiURL = "https://api.openai.com/v1/chat/completions"
+	}
+	model := os.Getenv("LLM_MODEL")
+	if model == "" {
+		model = "gpt-4o-mini"
+	}
+	return &LLMClient{
+		apiURL: apiURL,
+		apiKey: apiKey,
+		model:  model,
+		client: &http.Client{Timeout: 30 * time.Second},
+	}, nil
+}
+
+// Complete sends a single-message prompt and returns the model's reply
+func (lc *LLMClient) Complete(ctx context.Context, prompt string) (string, error) {
+	body, err := json.Marshal(map[string]any{
+		"model": lc.model,
+		"messages": []map[string]string{
+			{"role": "user", "content": prompt},
+		},
+		"max_tokens": 60,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, lc.apiURL, bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+lc.apiKey)
+
+	resp, err := lc.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("llm request: status %d", resp.StatusCode)
+	}
+
+	var result struct {
+		Choices []struct {
+			Message struct {
+				Content string `json:"content"`
+			} `json:"message"`
+		} `json:"choices"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", err
+	}
+	if len(result.Choices) == 0 {
+		return "", fmt.Errorf("llm request: no choices returned")
+	}
+	return strings.TrimSpace(result.Choices[0].Message.Content), nil
+}
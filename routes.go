@@ -0,0 +1,212 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+
+	"github.com/jomei/notionapi"
+)
+
+// NotionRoute sends the leads matching it to a Notion database other than NOTION_DATABASE_ID,
+// e.g. restaurants and cafes to a Food Leads database
+type NotionRoute struct {
+	// Name identifies the route in logs and the run summary
+	Name       string `json:"name"`
+	DatabaseID string `json:"database_id"`
+	// TokenEnv names the environment variable holding the token of the database's workspace;
+	// NOTION_API_KEY when empty
+	TokenEnv string `json:"token_env,omitempty"`
+	// Types matches leads with any of the given Google place types
+	Types []string `json:"types,omitempty"`
+	// When is an expression leads must also satisfy, e.g. `"restaurant" in types && rating >= 4`
+	When string `json:"when,omitempty"`
+}
+
+// Validate checks that the route names its database and has a condition that compiles
+func (r NotionRoute) Validate() error {
+	if r.Name == "" {
+		return errors.New("name is required")
+	}
+	if r.DatabaseID == "" {
+		return errors.New("database_id is required")
+	}
+	if len(r.Types) == 0 && r.When == "" {
+		return errors.New("types or when is required, or every lead would match")
+	}
+	if r.When != "" {
+		if _, err := compileExpression(r.When); err != nil {
+			return fmt.Errorf("when: %w", err)
+		}
+	}
+	return nil
+}
+
+// Matches reports whether a lead satisfies every condition set on the route
+func (r NotionRoute) Matches(b *Business) bool {
+	if len(r.Types) > 0 && !slices.ContainsFunc(b.Type, func(t string) bool { return slices.Contains(r.Types, t) }) {
+		return false
+	}
+	if r.When != "" && !matchExpression(r.When, b) {
+		return false
+	}
+	return true
+}
+
+// notionRoute is a route with the client of its database
+type notionRoute struct {
+	NotionRoute
+	client *NotionClient
+}
+
+// OpenRoutes connects to the database of each route, checking its schema like the main one's.
+// A route's database must exist already, shared with the integration whose token it uses. Routes
+// on the main token share its Contacts database.
+func (nc *NotionClient) OpenRoutes(ctx context.Context, routes []NotionRoute, addMissing bool) error {
+	for _, route := range routes {
+		tokenEnv := cmp.Or(route.TokenEnv, "NOTION_API_KEY")
+		token := os.Getenv(tokenEnv)
+		if token == "" {
+			return fmt.Errorf("route %s: %s must be set", route.Name, tokenEnv)
+		}
+		client := NewNotionClient(token, route.DatabaseID, "")
+		if route.TokenEnv == "" {
+			client.contactsID = nc.contactsID
+		}
+		if !client.CheckDatabaseExists(ctx) {
+			return fmt.Errorf("route %s: database %s doesn't exist or isn't shared with the integration", route.Name, route.DatabaseID)
+		}
+		if err := client.ReconcileSchema(ctx, addMissing); err != nil {
+			return fmt.Errorf("route %s: %w", route.Name, err)
+		}
+		if err := client.LoadOptions(ctx); err != nil {
+			return fmt.Errorf("route %s: loading select options: %w", route.Name, err)
+		}
+		nc.routes = append(nc.routes, notionRoute{NotionRoute: route, client: client})
+	}
+	return nil
+}
+
+// route returns the client of the database a lead belongs in: that of the first route it
+// matches, or nc itself
+func (nc *NotionClient) route(b *Business) *NotionClient {
+	for _, route := range nc.routes {
+		if route.Matches(b) {
+			return route.client
+		}
+	}
+	return nc
+}
+
+// databases returns the client of the main database followed by those of the routes
+func (nc *NotionClient) databases() []*NotionClient {
+	clients := []*NotionClient{nc}
+	for _, route := range nc.routes {
+		clients = append(clients, route.client)
+	}
+	return clients
+}
+
+// remember records the database a routed lead's page is in, so later updates reach it
+func (nc *NotionClient) remember(pageID string, client *NotionClient) {
+	if client == nc || pageID == "" {
+		return
+	}
+	nc.pagesMu.Lock()
+	defer nc.pagesMu.Unlock()
+	if nc.pages == nil {
+		nc.pages = make(map[string]*NotionClient)
+	}
+	nc.pages[pageID] = client
+}
+
+// pageClient returns the client of the database a page was read from or written to, the main
+// database's unless it was remembered as routed
+func (nc *NotionClient) pageClient(pageID string) *NotionClient {
+	nc.pagesMu.Lock()
+	defer nc.pagesMu.Unlock()
+	return cmp.Or(nc.pages[pageID], nc)
+}
+
+// EachLead calls fn with every lead matching filter in the main database and then in each
+// route's, so commands that go over every lead, such as purges, reach the routed ones too
+func (nc *NotionClient) EachLead(ctx context.Context, filter notionapi.Filter, fn func(Business) error) error {
+	for _, client := range nc.databases() {
+		err := client.EachBusiness(ctx, filter, func(b Business) error {
+			nc.remember(b.PageID, client)
+			return fn(b)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ExistsAnywhere reports whether a place is in the main database or any route's, so a lead is
+// never written to a second database after a route is added or its types change
+func (nc *NotionClient) ExistsAnywhere(ctx context.Context, placeID string) (bool, error) {
+	for _, client := range nc.databases() {
+		exists, err := client.BusinessExists(ctx, placeID)
+		if err != nil || exists {
+			return exists, err
+		}
+	}
+	return false, nil
+}
+
+// ExistingAnywhere looks up which of the PlaceIDs are in the main database or any route's
+func (nc *NotionClient) ExistingAnywhere(ctx context.Context, placeIDs []string) (map[string]bool, error) {
+	existing := make(map[string]bool)
+	for _, client := range nc.databases() {
+		found, err := client.ExistingPlaceIDs(ctx, placeIDs)
+		if err != nil {
+			return nil, err
+		}
+		maps.Copy(existing, found)
+	}
+	return existing, nil
+}
+
+// InsertRouted creates a lead's page in the database it routes to, unless the place is in any of
+// the databases already
+func (nc *NotionClient) InsertRouted(ctx context.Context, b *Business, fields FieldSelector) error {
+	exists, err := nc.ExistsAnywhere(ctx, b.PlaceID)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return errBusinessExists
+	}
+	return nc.createRouted(ctx, b, fields)
+}
+
+// createRouted creates the page of a lead known to be in none of the databases, in the one it
+// routes to
+func (nc *NotionClient) createRouted(ctx context.Context, b *Business, fields FieldSelector) error {
+	client := nc.route(b)
+	if err := client.createPage(ctx, b, fields); err != nil {
+		return err
+	}
+	nc.remember(b.PageID, client)
+	return nil
+}
+
+// FindDuplicateAnywhere returns the lead most like a business under another PlaceID in the main
+// database or, failing that, the first route's database holding one
+func (nc *NotionClient) FindDuplicateAnywhere(ctx context.Context, b *Business) (*Business, error) {
+	for _, client := range nc.databases() {
+		duplicate, err := client.FindDuplicate(ctx, b)
+		if err != nil || duplicate != nil {
+			if duplicate != nil {
+				nc.remember(duplicate.PageID, client)
+			}
+			return duplicate, err
+		}
+	}
+	return nil, nil
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"sync"
+	"time"
+)
+
+// defaultStatePath is where the state of previous runs is kept unless --state-file says otherwise
+const defaultStatePath = ".business-finder-state.json"
+
+// RunState records when searches last ran and every PlaceID they have listed, so later runs can
+// skip the businesses already seen
+type RunState struct {
+	path string
+
+	mu      sync.Mutex
+	lastRun time.Time
+	// earlier are the PlaceIDs previous runs listed, and current those this run has, so a place
+	// listed again this run, e.g. under another type, isn't taken for one seen before
+	earlier, current map[string]bool
+	// added and skipped count this run's places that were new and seen before, each once
+	added, skipped int
+	// checkpoint is the run that was interrupted last, until a run completes
+	checkpoint *runCheckpoint
+}
+
+// runCheckpoint records how far an interrupted run got, so it can be resumed
+type runCheckpoint struct {
+	Started time.Time `json:"started"`
+	// Areas are the areas searched to the end before the run was stopped
+	Areas []string `json:"areas_done"`
+}
+
+// runStateFile is the on-disk form of a RunState
+type runStateFile struct {
+	LastRun    time.Time      `json:"last_run"`
+	PlaceIDs   []string       `json:"place_ids"`
+	Checkpoint *runCheckpoint `json:"checkpoint,omitempty"`
+}
+
+// LoadRunState reads the state file at path; a missing file is a first run
+func LoadRunState(path string) (*RunState, error) {
+	state := &RunState{path: path, earlier: make(map[string]bool), current: make(map[string]bool)}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return state, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var file runStateFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	state.lastRun = file.LastRun
+	state.checkpoint = file.Checkpoint
+	for _, id := range file.PlaceIDs {
+		state.earlier[id] = true
+	}
+	return state, nil
+}
+
+// LastRun is when the previous run started, or the zero time before the first
+func (rs *RunState) LastRun() time.Time {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	return rs.lastRun
+}
+
+// Skip reports whether a previous run listed a place, recording it as seen this run; sources use
+// it to drop known listings before costly lookups. first is false for the place's later listings
+// this run, which shouldn't be counted again.
+func (rs *RunState) Skip(placeID string) (skip, first bool) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	if !rs.earlier[placeID] {
+		return false, false
+	}
+	return true, rs.record(placeID)
+}
+
+// Seen records a listing, reporting whether a previous run already had it. Listings of a place
+// this run already had are neither new nor seen before.
+func (rs *RunState) Seen(placeID string) bool {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	rs.record(placeID)
+	return rs.earlier[placeID]
+}
+
+// record adds a place to this run's, counting it as new or seen before the first time, which it
+// reports; rs.mu must be held
+func (rs *RunState) record(placeID string) bool {
+	if rs.current[placeID] {
+		return false
+	}
+	rs.current[placeID] = true
+	if rs.earlier[placeID] {
+		rs.skipped++
+	} else {
+		rs.added++
+	}
+	return true
+}
+
+// remember moves this run's places into the earlier runs', once the state is saved; rs.mu must
+// be held
+func (rs *RunState) remember() {
+	maps.Copy(rs.earlier, rs.current)
+	clear(rs.current)
+}
+
+// Checkpoint returns how far the last interrupted run got, or nil if the last run completed
+func (rs *RunState) Checkpoint() *runCheckpoint {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	return rs.checkpoint
+}
+
+// Summary reports how many listings this run were new since the last one
+func (rs *RunState) Summary() string {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	if rs.lastRun.IsZero() {
+		return fmt.Sprintf("First tracked run: %d businesses found\n", rs.added)
+	}
+	return fmt.Sprintf("%d new businesses since the last run at %s, %d seen before\n",
+		rs.added, rs.lastRun.Format(time.DateTime), rs.skipped)
+}
+
+// Save writes the state with started as the last run, and resets this run's counts. The run
+// completed, so any checkpoint of an earlier interrupted one is dropped.
+func (rs *RunState) Save(started time.Time) error {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	if err := rs.write(started, nil); err != nil {
+		return err
+	}
+	rs.remember()
+	rs.lastRun, rs.checkpoint, rs.added, rs.skipped = started, nil, 0, 0
+	return nil
+}
+
+// SaveInterrupted writes the state of a run stopped part way, keeping the last run that completed
+// and recording the areas done, with those of the interrupted run it resumed
+func (rs *RunState) SaveInterrupted(started time.Time, areasDone []string) error {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	checkpoint := &runCheckpoint{Started: started, Areas: areasDone}
+	if rs.checkpoint != nil {
+		checkpoint.Started = rs.checkpoint.Started
+	}
+	if err := rs.write(rs.lastRun, checkpoint); err != nil {
+		return err
+	}
+	rs.remember()
+	rs.checkpoint, rs.added, rs.skipped = checkpoint, 0, 0
+	return nil
+}
+
+// write replaces the state file; rs.mu must be held
+func (rs *RunState) write(lastRun time.Time, checkpoint *runCheckpoint) error {
+	file := runStateFile{LastRun: lastRun, PlaceIDs: make([]string, 0, len(rs.earlier)+len(rs.current)), Checkpoint: checkpoint}
+	for id := range rs.earlier {
+		file.PlaceIDs = append(file.PlaceIDs, id)
+	}
+	for id := range rs.current {
+		if !rs.earlier[id] {
+			file.PlaceIDs = append(file.PlaceIDs, id)
+		}
+	}
+	slices.Sort(file.PlaceIDs)
+	data, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return err
+	}
+	return replaceFile(rs.path, func(f *os.File) error {
+		_, err := f.Write(data)
+		return err
+	})
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"log"
+	"log/slog"
+	"math"
+	"math/rand"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jomei/notionapi"
+)
+
+// urgencyWeights are the sampling weights given to each urgency level
+var urgencyWeights = map[string]float64{
+	"High":   3,
+	"Medium": 2,
+	"Low":    1,
+}
+
+// runSample picks a weighted random set of uncontacted leads per rep, assigns them for today, and exports the list
+func runSample(notionClient *NotionClient, args []string) {
+	fs := flag.NewFlagSet("sample", flag.ExitOnError)
+	reps := fs.String("reps", "", "comma-separated list of reps to assign leads to")
+	perRep := fs.Int("per-rep", 20, "number of leads to assign to each rep")
+	halfLife := fs.Float64("half-life", 30, "days after which a lead's recency weight halves")
+	out := fs.String("out", "", "CSV file to write the call list to (default calls-YYYY-MM-DD.csv)")
+	seed := fs.Int64("seed", time.Now().UnixNano(), "random seed for reproducible samples")
+	dryRun := fs.Bool("dry-run", false, "export the list without marking leads as assigned")
+	fs.Parse(args)
+
+	repNames := splitList(*reps)
+	if len(repNames) == 0 {
+		log.Fatal("sample: --reps must list at least one rep")
+	}
+
+	today := startOfDay(time.Now())
+	if *out == "" {
+		*out = fmt.Sprintf("calls-%s.csv", today.Format("2006-01-02"))
+	}
+
+	ctx := context.Background()
+	leads, err := notionClient.ListBusinesses(ctx, &notionapi.PropertyFilter{
+		Property: "Contacted",
+		Select:   &notionapi.SelectFilterCondition{Equals: "Not Contacted"},
+	})
+	if err != nil {
+		log.Fatalf("Failed to list leads: %v", err)
+	}
+
+	var pool []Business
+	for _, lead := range leads {
+		if !lead.AssignedDate.IsZero() && !lead.AssignedDate.Before(today) {
+			continue
+		}
+		pool = append(pool, lead)
+	}
+
+	if stableOrder {
+		// With a fixed --seed this makes the sample itself reproducible, not just its output order
+		sortBusinesses(pool)
+	}
+	rng := rand.New(rand.NewSource(*seed))
+	picked := weightedSample(pool, len(repNames)*(*perRep), func(b Business) float64 {
+		return leadWeight(b, *halfLife)
+	}, rng)
+	fmt.Printf("Sampled %d of %d available leads for %d reps\n", len(picked), len(pool), len(repNames))
+
+	assignments := make(map[string][]Business)
+	for i, lead := range picked {
+		rep := repNames[i%len(repNames)]
+		lead.AssignedTo = rep
+		lead.AssignedDate = today
+		assignments[rep] = append(assignments[rep], lead)
+
+		if *dryRun {
+			continue
+		}
+		properties, err := NewProperties(notionClient.options).
+			Select("AssignedTo", rep).
+			Date("AssignedDate", today).
+			Build()
+		if err == nil {
+			err = notionClient.UpdateBusiness(ctx, lead.PageID, properties)
+		}
+		if err != nil {
+			slog.Error("Failed to assign lead", "operation", "sample", "place_id", lead.PlaceID, "name", lead.Name, "rep", rep, "err", err)
+		}
+	}
+
+	if stableOrder {
+		for _, rep := range repNames {
+			sortBusinesses(assignments[rep])
+		}
+	}
+	if err := writeCallList(*out, repNames, assignments); err != nil {
+		log.Fatalf("Failed to write call list: %v", err)
+	}
+	fmt.Printf("Call list written to %s\n", *out)
+}
+
+// leadWeight combines a lead's score (or urgency for unscored leads) with an exponential recency decay
+func leadWeight(b Business, halfLifeDays float64) float64 {
+	weight, ok := urgencyWeights[b.Urgency]
+	if !ok {
+		weight = 1
+	}
+	// Put urgency on the same 0–100 scale as lead scores so scored and unscored leads mix fairly
+	weight *= 25
+	if b.LeadScore > 0 {
+		weight = float64(b.LeadScore)
+	}
+	if halfLifeDays > 0 && !b.Created.IsZero() {
+		ageDays := time.Since(b.Created).Hours() / 24
+		weight *= math.Max(math.Pow(0.5, ageDays/halfLifeDays), 0.1)
+	}
+	return weight
+}
+
+// weightedSample draws up to n items without replacement, with probability proportional to weight
+func weightedSample(items []Business, n int, weight func(Business) float64, rng *rand.Rand) []Business {
+	type keyed struct {
+		item Business
+		key  float64
+	}
+	keys := make([]keyed, 0, len(items))
+	for _, item := range items {
+		w := weight(item)
+		if w <= 0 {
+			continue
+		}
+		// Efraimidis-Spirakis: the n largest u^(1/w) form a weighted sample
+		keys = append(keys, keyed{item: item, key: math.Pow(rng.Float64(), 1/w)})
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].key > keys[j].key })
+	if n > len(keys) {
+		n = len(keys)
+	}
+	sample := make([]Business, n)
+	for i := range sample {
+		sample[i] = keys[i].item
+	}
+	return sample
+}
+
+// writeCallList exports the assigned leads, grouped by rep, to a CSV file
+func writeCallList(path string, reps []string, assignments map[string][]Business) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	columns := []string{"LeadNumber", "Name", "Address", "Urgency", "WebsiteStatus", "Phone", "Email", "URL", "NotionURL"}
+	w := csv.NewWriter(f)
+	w.Write(append([]string{"Rep"}, columns...))
+	for _, rep := range reps {
+		for _, lead := range assignments[rep] {
+			data := templateData(lead)
+			row := []string{rep}
+			for _, column := range columns {
+				row = append(row, data[column])
+			}
+			w.Write(row)
+		}
+	}
+	w.Flush()
+	return w.Error()
+}
+
+// startOfDay returns midnight at the start of t's day in t's location
+func startOfDay(t time.Time) time.Time {
+	year, month, day := t.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, t.Location())
+}
+
+// splitList splits a comma-separated flag value, dropping empty entries
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// scheduleMacros are the shorthand schedules cron accepts
+var scheduleMacros = map[string]string{
+	"@hourly":   "0 * * * *",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@weekly":   "0 0 * * 0",
+	"@monthly":  "0 0 1 * *",
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+}
+
+var (
+	monthNames   = []string{"JAN", "FEB", "MAR", "APR", "MAY", "JUN", "JUL", "AUG", "SEP", "OCT", "NOV", "DEC"}
+	weekdayNames = []string{"SUN", "MON", "TUE", "WED", "THU", "FRI", "SAT"}
+)
+
+// Schedule is a cron schedule of the usual five fields: minute, hour, day of month, month and day
+// of week, e.g. "0 6 * * MON" for 06:00 every Monday
+type Schedule struct {
+	minute, hour, dom, month, dow uint64
+	// domAny and dowAny record unrestricted day fields; when both are restricted a day matching
+	// either runs, as in cron
+	domAny, dowAny bool
+}
+
+// ParseSchedule parses a cron expression or one of cron's macros: @hourly, @daily or @midnight,
+// @weekly, @monthly, and @yearly or @annually
+func ParseSchedule(expr string) (*Schedule, error) {
+	if macro, ok := scheduleMacros[strings.ToLower(strings.TrimSpace(expr))]; ok {
+		expr = macro
+	}
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("schedule %q: expected 5 fields, got %d", expr, len(fields))
+	}
+	s := &Schedule{domAny: fields[2] == "*", dowAny: fields[4] == "*"}
+	var err error
+	for _, f := range []struct {
+		bits        *uint64
+		field       string
+		first, last int
+		names       []string
+	}{
+		{&s.minute, fields[0], 0, 59, nil},
+		{&s.hour, fields[1], 0, 23, nil},
+		{&s.dom, fields[2], 1, 31, nil},
+		{&s.month, fields[3], 1, 12, monthNames},
+		{&s.dow, fields[4], 0, 7, weekdayNames},
+	} {
+		if *f.bits, err = parseScheduleField(f.field, f.first, f.last, f.names); err != nil {
+			return nil, fmt.Errorf("schedule %q: %w", expr, err)
+		}
+	}
+	// Sunday is both 0 and 7
+	if s.dow&(1<<7) != 0 {
+		s.dow |= 1
+	}
+	return s, nil
+}
+
+// parseScheduleField parses a comma-separated list of values, ranges and steps such as 1-5, */15 or
+// MON-FRI into a bit set. names, when given, stand for the values from first upwards.
+func parseScheduleField(field string, first, last int, names []string) (uint64, error) {
+	value := func(s string) (int, error) {
+		for i, name := range names {
+			if strings.EqualFold(s, name) {
+				return first + i, nil
+			}
+		}
+		n, err := strconv.Atoi(s)
+		if err != nil || n < first || n > last {
+			return 0, fmt.Errorf("%q is not a value from %d to %d", s, first, last)
+		}
+		return n, nil
+	}
+
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		rangePart, stepPart, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			n, err := strconv.Atoi(stepPart)
+			if err != nil || n < 1 {
+				return 0, fmt.Errorf("invalid step %q", stepPart)
+			}
+			step = n
+		}
+		lo, hi := first, last
+		if rangePart != "*" {
+			start, end, isRange := strings.Cut(rangePart, "-")
+			var err error
+			if lo, err = value(start); err != nil {
+				return 0, err
+			}
+			hi = lo
+			if isRange {
+				if hi, err = value(end); err != nil {
+					return 0, err
+				}
+			} else if hasStep {
+				hi = last
+			}
+			if hi < lo {
+				return 0, fmt.Errorf("range %q ends before it starts", rangePart)
+			}
+		}
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << v
+		}
+	}
+	return bits, nil
+}
+
+func (s *Schedule) matchesDay(t time.Time) bool {
+	dom := s.dom&(1<<t.Day()) != 0
+	dow := s.dow&(1<<int(t.Weekday())) != 0
+	switch {
+	case s.domAny && s.dowAny:
+		return true
+	case s.domAny:
+		return dow
+	case s.dowAny:
+		return dom
+	}
+	return dom || dow
+}
+
+// Next returns the first time after t that the schedule runs, or the zero time if it never does
+// within five years, e.g. "0 0 31 2 *". The schedule is of wall clock times, as in cron: on the
+// day clocks go back a time isn't run twice, and one skipped when they go forward runs at the
+// same offset past the change.
+func (s *Schedule) Next(t time.Time) time.Time {
+	wall := time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), 0, 0, time.UTC)
+	limit := wall.AddDate(5, 0, 0)
+	for {
+		wall = s.nextWall(wall.Add(time.Minute), limit)
+		if wall.IsZero() {
+			return time.Time{}
+		}
+		// A wall clock time repeated as clocks go back can map to before t
+		if next := time.Date(wall.Year(), wall.Month(), wall.Day(), wall.Hour(), wall.Minute(), 0, 0, t.Location()); next.After(t) {
+			return next
+		}
+	}
+}
+
+// nextWall returns the first wall clock time from t, given in UTC, that the schedule runs, or the
+// zero time if there is none before limit
+func (s *Schedule) nextWall(t, limit time.Time) time.Time {
+	for t.Before(limit) {
+		switch {
+		case s.month&(1<<int(t.Month())) == 0:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+		case !s.matchesDay(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+		case s.hour&(1<<t.Hour()) == 0:
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+		case s.minute&(1<<t.Minute()) == 0:
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestScheduleNext(t *testing.T) {
+	london, err := time.LoadLocation("Europe/London")
+	if err != nil {
+		t.Skipf("no time zone data: %v", err)
+	}
+	at := func(value string) time.Time {
+		parsed, err := time.ParseInLocation("2006-01-02 15:04:05", value, london)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return parsed
+	}
+	// 2026-10-14 is a Wednesday
+	tests := []struct {
+		expr string
+		from string
+		want string
+	}{
+		{"*/15 * * * *", "2026-10-14 10:07:00", "2026-10-14 10:15:00"},
+		{"*/15 * * * *", "2026-10-14 10:15:00", "2026-10-14 10:30:00"},
+		{"*/15 * * * *", "2026-10-14 10:14:30", "2026-10-14 10:15:00"},
+		{"5/15 * * * *", "2026-10-14 10:07:00", "2026-10-14 10:20:00"},
+		{"0 9-17/4 * * *", "2026-10-14 14:00:00", "2026-10-14 17:00:00"},
+		{"0,30 6 * * *", "2026-10-14 06:10:00", "2026-10-14 06:30:00"},
+		{"0 6 * * MON", "2026-10-14 10:00:00", "2026-10-19 06:00:00"},
+		{"0 6 * * mon-fri", "2026-10-16 07:00:00", "2026-10-19 06:00:00"},
+		{"0 6 * * 0", "2026-10-14 10:00:00", "2026-10-18 06:00:00"},
+		{"0 6 * * 7", "2026-10-14 10:00:00", "2026-10-18 06:00:00"},
+		{"0 6 * * SUN", "2026-10-14 10:00:00", "2026-10-18 06:00:00"},
+		{"0 0 1 JAN-MAR *", "2026-10-14 10:00:00", "2027-01-01 00:00:00"},
+		{"0 0 1 feb *", "2026-10-14 10:00:00", "2027-02-01 00:00:00"},
+		// With both day fields restricted, a day matching either runs
+		{"0 0 13 * FRI", "2026-10-14 10:00:00", "2026-10-16 00:00:00"},
+		{"0 0 13 * FRI", "2026-10-16 10:00:00", "2026-10-23 00:00:00"},
+		{"0 0 13 * FRI", "2026-11-10 10:00:00", "2026-11-13 00:00:00"},
+		{"0 0 29 2 *", "2026-10-14 10:00:00", "2028-02-29 00:00:00"},
+		{"@hourly", "2026-10-14 10:07:00", "2026-10-14 11:00:00"},
+		{"@daily", "2026-10-14 10:07:00", "2026-10-15 00:00:00"},
+		{"@midnight", "2026-10-14 10:07:00", "2026-10-15 00:00:00"},
+		{"@weekly", "2026-10-14 10:07:00", "2026-10-18 00:00:00"},
+		{"@monthly", "2026-10-14 10:07:00", "2026-11-01 00:00:00"},
+		{"@yearly", "2026-10-14 10:07:00", "2027-01-01 00:00:00"},
+		{"@ANNUALLY", "2026-10-14 10:07:00", "2027-01-01 00:00:00"},
+		// Clocks go forward at 01:00 GMT on 29 March 2026, so 01:30 runs at 02:30 BST
+		{"30 1 * * *", "2026-03-28 12:00:00", "2026-03-29 02:30:00"},
+		{"0 3 * * *", "2026-03-28 12:00:00", "2026-03-29 03:00:00"},
+		// Clocks go back at 02:00 BST on 25 October 2026; the repeated 01:30 isn't run again
+		{"30 1 * * *", "2026-10-25 00:00:00", "2026-10-25 01:30:00"},
+		{"0 3 * * *", "2026-10-25 00:00:00", "2026-10-25 03:00:00"},
+	}
+	for _, tt := range tests {
+		s, err := ParseSchedule(tt.expr)
+		if err != nil {
+			t.Errorf("ParseSchedule(%q) error = %v", tt.expr, err)
+			continue
+		}
+		if got := s.Next(at(tt.from)); !got.Equal(at(tt.want)) {
+			t.Errorf("ParseSchedule(%q).Next(%s) = %s, want %s", tt.expr, tt.from, got, at(tt.want))
+		}
+	}
+}
+
+func TestScheduleNextAfterClocksGoBack(t *testing.T) {
+	london, err := time.LoadLocation("Europe/London")
+	if err != nil {
+		t.Skipf("no time zone data: %v", err)
+	}
+	s, err := ParseSchedule("30 1 * * *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ran := time.Date(2026, 10, 25, 0, 30, 0, 0, time.UTC).In(london) // 01:30 BST
+	want := time.Date(2026, 10, 26, 1, 30, 0, 0, london)
+	for _, from := range []time.Time{ran, ran.Add(time.Hour)} { // and 01:30 GMT
+		if got := s.Next(from); !got.Equal(want) {
+			t.Errorf("Next(%s) = %s, want %s", from.In(london), got, want)
+		}
+	}
+}
+
+func TestScheduleNextNever(t *testing.T) {
+	s, err := ParseSchedule("0 0 31 2 *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := s.Next(time.Date(2026, 10, 14, 10, 0, 0, 0, time.UTC)); !got.IsZero() {
+		t.Errorf("Next() = %s, want the zero time for a date that never comes", got)
+	}
+}
+
+func TestParseScheduleErrors(t *testing.T) {
+	for _, expr := range []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"5-1 * * * *",
+		"*/0 * * * *",
+		"*/x * * * *",
+		"* * * FOO *",
+		"@fortnightly",
+	} {
+		if _, err := ParseSchedule(expr); err == nil {
+			t.Errorf("ParseSchedule(%q) succeeded, want an error", expr)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+
+	"github.com/jomei/notionapi"
+)
+
+// schemaDiff is how a live database differs from databaseProperties
+type schemaDiff struct {
+	// missing are expected properties the database doesn't have
+	missing []string
+	// mismatched describe properties the database has with another type than the finder writes
+	mismatched []string
+}
+
+// String reports the differences one per line
+func (sd schemaDiff) String() string {
+	var sb strings.Builder
+	for _, line := range sd.missing {
+		fmt.Fprintf(&sb, "\n  missing %s", line)
+	}
+	for _, line := range sd.mismatched {
+		fmt.Fprintf(&sb, "\n  %s", line)
+	}
+	return sb.String()
+}
+
+// diffSchema compares the properties of a database with the expected ones
+func diffSchema(expected, live notionapi.PropertyConfigs) schemaDiff {
+	names := make([]string, 0, len(expected))
+	for name := range expected {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var sd schemaDiff
+	for _, name := range names {
+		want := expected[name].GetType()
+		config, ok := live[name]
+		switch {
+		case !ok:
+			sd.missing = append(sd.missing, fmt.Sprintf("%s (%s)", name, want))
+		case config.GetType() != want:
+			sd.mismatched = append(sd.mismatched, fmt.Sprintf("%s is %s, expected %s", name, config.GetType(), want))
+		case want == notionapi.PropertyConfigTypeRelation:
+			target := expected[name].(notionapi.RelationPropertyConfig).Relation.DatabaseID
+			if live, ok := config.(*notionapi.RelationPropertyConfig); ok && !sameDatabase(live.Relation.DatabaseID, target) {
+				sd.mismatched = append(sd.mismatched, fmt.Sprintf("%s relates to database %s, expected %s", name, live.Relation.DatabaseID, target))
+			}
+		}
+	}
+	return sd
+}
+
+// ReconcileSchema checks the live database, and the Contacts database when one is configured,
+// against the properties the finder writes, so inserts don't fail part way through a run. Missing
+// properties are added unless addMissing is false; properties of the wrong type are never
+// changed, as that would lose their values, and are reported in the error for fixing in Notion
+// (or renaming, leaving the finder to add its own).
+func (nc *NotionClient) ReconcileSchema(ctx context.Context, addMissing bool) error {
+	expected := databaseProperties()
+	if nc.contactsID != "" {
+		if err := nc.reconcileDatabase(ctx, nc.contactsID, contactProperties(), addMissing); err != nil {
+			return fmt.Errorf("contacts database: %w", err)
+		}
+		expected["Contacts"] = contactsRelation(nc.contactsID)
+	}
+	return nc.reconcileDatabase(ctx, nc.databaseID, expected, addMissing)
+}
+
+// reconcileDatabase adds the expected properties a database is missing, or reports them
+func (nc *NotionClient) reconcileDatabase(ctx context.Context, id notionapi.DatabaseID, expected notionapi.PropertyConfigs, addMissing bool) error {
+	db, err := nc.client.Database.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	sd := diffSchema(expected, db.Properties)
+	if len(sd.mismatched) > 0 || (len(sd.missing) > 0 && !addMissing) {
+		return fmt.Errorf("database schema does not match:%s", sd)
+	}
+	if len(sd.missing) == 0 {
+		return nil
+	}
+
+	add := make(notionapi.PropertyConfigs, len(sd.missing))
+	for name, config := range expected {
+		if _, ok := db.Properties[name]; !ok {
+			add[name] = config
+		}
+	}
+	_, err = nc.client.Database.Update(ctx, id, &notionapi.DatabaseUpdateRequest{Properties: add})
+	if err != nil {
+		return fmt.Errorf("adding missing properties:%s: %w", sd, err)
+	}
+	slog.Info("Added missing database properties", "operation", "schema", "database", string(id), "properties", strings.Join(sd.missing, ", "))
+	return nil
+}
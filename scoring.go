@@ -0,0 +1,114 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+)
+
+const (
+	websiteNone    = "none"
+	websitePresent = "present"
+	websiteBroken  = "broken"
+	websiteUnknown = "unknown"
+)
+
+// UrgencyRule assigns an urgency to businesses matching all of its set conditions
+type UrgencyRule struct {
+	Urgency string `json:"urgency"`
+	// Website is "none", "present" (a working site), "broken" (listed but unreachable) or
+	// "unknown" (from a source that doesn't list websites)
+	Website     string   `json:"website,omitempty"`
+	SSL         *bool    `json:"ssl,omitempty"`
+	MinReviews  *int     `json:"min_reviews,omitempty"`
+	MaxReviews  *int     `json:"max_reviews,omitempty"`
+	MinRating   *float64 `json:"min_rating,omitempty"`
+	MaxRating   *float64 `json:"max_rating,omitempty"`
+	HoursListed *bool    `json:"hours_listed,omitempty"`
+	// Types matches businesses with any of the given Google place types
+	Types []string `json:"types,omitempty"`
+	// When is an expression the business must also satisfy, e.g. `rating >= 4.0 && reviews > 25`
+	When string `json:"when,omitempty"`
+}
+
+// defaultUrgencyRules reproduce the original behaviour: no website is High, anything else Medium
+func defaultUrgencyRules() []UrgencyRule {
+	return []UrgencyRule{
+		{Urgency: "High", Website: websiteNone},
+		{Urgency: "High", Website: websiteBroken},
+		{Urgency: "Medium"},
+	}
+}
+
+// Validate checks that the rule names a known urgency and website state
+func (r UrgencyRule) Validate() error {
+	if _, ok := urgencyWeights[r.Urgency]; !ok {
+		return fmt.Errorf("unknown urgency %q", r.Urgency)
+	}
+	switch r.Website {
+	case "", websiteNone, websitePresent, websiteBroken, websiteUnknown:
+	default:
+		return fmt.Errorf("unknown website condition %q", r.Website)
+	}
+	if r.When != "" {
+		if _, err := compileExpression(r.When); err != nil {
+			return fmt.Errorf("when: %w", err)
+		}
+	}
+	return nil
+}
+
+// Matches reports whether the business satisfies every condition set on the rule
+func (r UrgencyRule) Matches(b *Business) bool {
+	if r.Website != "" && r.Website != websiteState(b) {
+		return false
+	}
+	if r.SSL != nil && (b.WebsiteStatus != "Has Website" || b.HTTPS != *r.SSL) {
+		return false
+	}
+	if r.MinReviews != nil && b.ReviewCount < *r.MinReviews {
+		return false
+	}
+	if r.MaxReviews != nil && b.ReviewCount > *r.MaxReviews {
+		return false
+	}
+	if r.MinRating != nil && (b.ReviewCount == 0 || b.Rating < *r.MinRating) {
+		return false
+	}
+	if r.MaxRating != nil && (b.ReviewCount == 0 || b.Rating > *r.MaxRating) {
+		return false
+	}
+	if r.HoursListed != nil && b.HoursListed != *r.HoursListed {
+		return false
+	}
+	if len(r.Types) > 0 && !slices.ContainsFunc(b.Type, func(t string) bool { return slices.Contains(r.Types, t) }) {
+		return false
+	}
+	if r.When != "" && !matchExpression(r.When, b) {
+		return false
+	}
+	return true
+}
+
+// websiteState maps a business's WebsiteStatus onto the rule vocabulary
+func websiteState(b *Business) string {
+	switch b.WebsiteStatus {
+	case "Has Website":
+		return websitePresent
+	case "Broken Website":
+		return websiteBroken
+	case "Unknown Website":
+		return websiteUnknown
+	default:
+		return websiteNone
+	}
+}
+
+// EvaluateUrgency returns the urgency of the first matching rule, or Low if none match
+func EvaluateUrgency(rules []UrgencyRule, b *Business) string {
+	for _, rule := range rules {
+		if rule.Matches(b) {
+			return rule.Urgency
+		}
+	}
+	return "Low"
+}
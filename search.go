@@ -0,0 +1,319 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"slices"
+	"strings"
+
+	"googlemaps.github.io/maps"
+)
+
+// earthRadius is the mean radius of the Earth in metres
+const earthRadius = 6371000.0
+
+// SearchCell is one circle of a search, at most 50km in radius as Nearby Search requires
+type SearchCell struct {
+	Center maps.LatLng
+	Radius uint
+}
+
+// String identifies a cell by its centre and radius, e.g. 50.15257,-5.06627 r2000m
+func (c SearchCell) String() string {
+	return fmt.Sprintf("%.5f,%.5f r%dm", c.Center.Lat, c.Center.Lng, c.Radius)
+}
+
+// SearchArea describes where to search and which place types to search for
+type SearchArea struct {
+	Name        string       `json:"name"`
+	Description string       `json:"description,omitempty"`
+	Location    maps.LatLng  `json:"location"`
+	Radius      uint         `json:"radius"`
+	Types       []SearchType `json:"types"`
+	// Queries are keyword text searches, e.g. "independent coffee shops", run after the type searches
+	Queries []string `json:"queries,omitempty"`
+	// GridCellRadius tiles the area into overlapping cells of this radius in metres.
+	// Nearby Search returns at most 60 results per query, so dense areas need small cells.
+	GridCellRadius uint `json:"grid_cell_radius,omitempty"`
+	// Boundary is a GeoJSON file of polygons; results outside it are dropped before their details are fetched
+	Boundary string `json:"boundary,omitempty"`
+
+	boundary *Boundary
+}
+
+// SearchType is a place type to search for, optionally narrowed by a keyword. In config it is
+// either a bare type, "store", or an object, {"type": "store", "keyword": "surf shop"}.
+type SearchType struct {
+	Type    maps.PlaceType `json:"type"`
+	Keyword string         `json:"keyword,omitempty"`
+	// Radius and Location, when set, replace the area's for this type, as catchments differ by
+	// category: a cafe draws from 5km, a roofing contractor from 50km
+	Radius   uint         `json:"radius,omitempty"`
+	Location *maps.LatLng `json:"location,omitempty"`
+}
+
+// placeTypePattern matches the form of Google place types, e.g. car_repair. Types aren't checked
+// against a list, as the Places APIs keep adding them.
+var placeTypePattern = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+// parsePlaceType checks a place type name, accepting any in the form Google uses
+func parsePlaceType(name string) (maps.PlaceType, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if !placeTypePattern.MatchString(name) {
+		return "", fmt.Errorf("invalid place type %q, expected e.g. car_repair", name)
+	}
+	return maps.PlaceType(name), nil
+}
+
+// parsePlaceTypes checks a list of place type names
+func parsePlaceTypes(names []string) ([]maps.PlaceType, error) {
+	types := make([]maps.PlaceType, len(names))
+	for i, name := range names {
+		placeType, err := parsePlaceType(name)
+		if err != nil {
+			return nil, err
+		}
+		types[i] = placeType
+	}
+	return types, nil
+}
+
+// UnmarshalJSON accepts both the bare and the object form
+func (st *SearchType) UnmarshalJSON(data []byte) error {
+	var placeType string
+	if err := json.Unmarshal(data, &placeType); err == nil {
+		*st = SearchType{Type: maps.PlaceType(placeType)}
+		return nil
+	}
+	type plain SearchType
+	if err := json.Unmarshal(data, (*plain)(st)); err != nil {
+		return err
+	}
+	if st.Type == "" {
+		return fmt.Errorf("search type %s has no type", data)
+	}
+	return nil
+}
+
+// String names the search in progress output, e.g. store "surf shop"
+func (st SearchType) String() string {
+	if st.Keyword == "" {
+		return string(st.Type)
+	}
+	return fmt.Sprintf("%s %q", st.Type, st.Keyword)
+}
+
+// term is the search as free text, for sources whose categories don't line up with Google's
+// place types and so search by term
+func (st SearchType) term() string {
+	return strings.TrimSpace(strings.ReplaceAll(string(st.Type), "_", " ") + " " + st.Keyword)
+}
+
+// tagSearch records on a business the search that found it
+func (b *Business) tagSearch(st SearchType, cell SearchCell) {
+	b.SearchedType, b.SearchKeyword, b.SearchCell = string(st.Type), st.Keyword, cell.String()
+}
+
+// searchTerms are the area's types followed by its keyword queries, as searches with no type
+func (a *SearchArea) searchTerms() []SearchType {
+	terms := slices.Clone(a.Types)
+	for _, query := range a.Queries {
+		terms = append(terms, SearchType{Keyword: query})
+	}
+	return terms
+}
+
+// defaultSearchArea is searched when neither --area nor the config name one
+func defaultSearchArea() *SearchArea {
+	types := make([]SearchType, len(defaultPlaceTypes))
+	for i, t := range defaultPlaceTypes {
+		types[i] = SearchType{Type: t}
+	}
+	return &SearchArea{
+		Name:     "falmouth",
+		Location: maps.LatLng{Lat: 50.152573, Lng: -5.066270},
+		Radius:   50000,
+		Types:    types,
+	}
+}
+
+// withDefaults fills an area's unset radius and types from the built-in search area
+func (a SearchArea) withDefaults() *SearchArea {
+	defaults := defaultSearchArea()
+	if a.Radius == 0 {
+		a.Radius = defaults.Radius
+	}
+	if len(a.Types) == 0 {
+		a.Types = defaults.Types
+	}
+	return &a
+}
+
+// selectTypes narrows the types an area is searched for: types, when given, replace the area's,
+// keeping the radius and location the area sets for a type unless they set their own, and
+// excluded types are dropped either way
+func (a *SearchArea) selectTypes(types []SearchType, exclude []maps.PlaceType) {
+	if len(types) > 0 {
+		selected := slices.Clone(types)
+		for i, st := range selected {
+			own := slices.IndexFunc(a.Types, func(own SearchType) bool { return own.Type == st.Type })
+			if own >= 0 && st.Radius == 0 && st.Location == nil {
+				selected[i].Radius, selected[i].Location = a.Types[own].Radius, a.Types[own].Location
+			}
+		}
+		a.Types = selected
+	}
+	a.Types = slices.DeleteFunc(slices.Clone(a.Types), func(st SearchType) bool { return slices.Contains(exclude, st.Type) })
+}
+
+// LoadBoundary reads the area's boundary file, if it has one
+func (a *SearchArea) LoadBoundary() error {
+	if a.Boundary == "" {
+		return nil
+	}
+	boundary, err := LoadBoundary(a.Boundary)
+	if err != nil {
+		return err
+	}
+	a.boundary = boundary
+	return nil
+}
+
+// InBoundary reports whether a location is inside the area's boundary; without one everything is
+func (a *SearchArea) InBoundary(p maps.LatLng) bool {
+	return a.boundary == nil || a.boundary.Contains(p)
+}
+
+// forType returns the area as searched for one type, with the type's radius and location in
+// place of the area's
+func (a *SearchArea) forType(st SearchType) *SearchArea {
+	typeArea := *a
+	if st.Radius != 0 {
+		typeArea.Radius = st.Radius
+	}
+	if st.Location != nil {
+		typeArea.Location = *st.Location
+	}
+	return &typeArea
+}
+
+// Cells returns the circles to search: the whole area, or a hexagonal grid of overlapping cells
+// covering it when GridCellRadius is set
+func (a *SearchArea) Cells() []SearchCell {
+	if a.GridCellRadius == 0 || a.GridCellRadius >= a.Radius {
+		return []SearchCell{{Center: a.Location, Radius: a.Radius}}
+	}
+	return hexGrid(a.Location, float64(a.Radius), float64(a.GridCellRadius))
+}
+
+// hexGrid covers a circle with cells of cellRadius on a hexagonal lattice. Centres are spaced
+// cellRadius*sqrt(3) apart, the widest spacing at which circles still leave no gaps.
+func hexGrid(center maps.LatLng, radius, cellRadius float64) []SearchCell {
+	spacing := cellRadius * math.Sqrt(3)
+	rowHeight := spacing * math.Sqrt(3) / 2
+	rows := int(math.Ceil(radius / rowHeight))
+	cols := int(math.Ceil(radius / spacing))
+
+	var cells []SearchCell
+	for row := -rows; row <= rows; row++ {
+		dy := float64(row) * rowHeight
+		offset := 0.0
+		if row%2 != 0 {
+			offset = spacing / 2
+		}
+		for col := -cols - 1; col <= cols+1; col++ {
+			dx := float64(col)*spacing + offset
+			// Keep every cell that overlaps the area
+			if math.Hypot(dx, dy) > radius+cellRadius {
+				continue
+			}
+			cells = append(cells, SearchCell{
+				Center: offsetLatLng(center, dx, dy),
+				Radius: uint(cellRadius),
+			})
+		}
+	}
+	return cells
+}
+
+// offsetLatLng moves a point east by dx and north by dy metres
+func offsetLatLng(p maps.LatLng, dx, dy float64) maps.LatLng {
+	lat := p.Lat + dy/earthRadius*180/math.Pi
+	lng := p.Lng + dx/(earthRadius*math.Cos(p.Lat*math.Pi/180))*180/math.Pi
+	return maps.LatLng{Lat: lat, Lng: lng}
+}
+
+// located reports whether the source placed the business
+func (b *Business) located() bool {
+	return b.Location != maps.LatLng{}
+}
+
+// setDistance records how far the business is from the centre of the area it was found searching,
+// in km to one decimal place; at least 0.1, as 0 means the distance isn't known
+func (b *Business) setDistance(area *SearchArea) {
+	if !b.located() || area.Location == (maps.LatLng{}) {
+		return
+	}
+	b.DistanceKm = max(math.Round(distanceMetres(area.Location, b.Location)/100)/10, 0.1)
+}
+
+// distanceMetres is the great-circle distance between two points
+func distanceMetres(a, b maps.LatLng) float64 {
+	lat1, lat2 := a.Lat*math.Pi/180, b.Lat*math.Pi/180
+	dLat, dLng := lat2-lat1, (b.Lng-a.Lng)*math.Pi/180
+	h := math.Pow(math.Sin(dLat/2), 2) + math.Cos(lat1)*math.Cos(lat2)*math.Pow(math.Sin(dLng/2), 2)
+	return 2 * earthRadius * math.Asin(math.Sqrt(h))
+}
+
+// defaultPlaceTypes are the place types searched when no area preset narrows them down
+var defaultPlaceTypes = []maps.PlaceType{
+	maps.PlaceTypeArtGallery,
+	maps.PlaceTypeBakery,
+	maps.PlaceTypeBank,
+	maps.PlaceTypeBar,
+	maps.PlaceTypeBeautySalon,
+	maps.PlaceTypeBicycleStore,
+	maps.PlaceTypeBookStore,
+	maps.PlaceTypeBowlingAlley,
+	maps.PlaceTypeCafe,
+	maps.PlaceTypeCampground,
+	maps.PlaceTypeClothingStore,
+	maps.PlaceTypeConvenienceStore,
+	maps.PlaceTypeDepartmentStore,
+	maps.PlaceTypeElectrician,
+	maps.PlaceTypeElectronicsStore,
+	maps.PlaceTypeFlorist,
+	maps.PlaceTypeFuneralHome,
+	maps.PlaceTypeGym,
+	maps.PlaceTypeHairCare,
+	maps.PlaceTypeHomeGoodsStore,
+	maps.PlaceTypeJewelryStore,
+	maps.PlaceTypeLaundry,
+	maps.PlaceTypeLibrary,
+	maps.PlaceTypeLiquorStore,
+	maps.PlaceTypeLocksmith,
+	maps.PlaceTypeLodging,
+	maps.PlaceTypeMealDelivery,
+	maps.PlaceTypeMealTakeaway,
+	maps.PlaceTypeMovieRental,
+	maps.PlaceTypeMovingCompany,
+	maps.PlaceTypeMuseum,
+	maps.PlaceTypeNightClub,
+	maps.PlaceTypePainter,
+	maps.PlaceTypePetStore,
+	maps.PlaceTypePhysiotherapist,
+	maps.PlaceTypePlumber,
+	maps.PlaceTypeRestaurant,
+	maps.PlaceTypeRoofingContractor,
+	maps.PlaceTypeRvPark,
+	maps.PlaceTypeShoeStore,
+	maps.PlaceTypeShoppingMall,
+	maps.PlaceTypeSpa,
+	maps.PlaceTypeStorage,
+	maps.PlaceTypeStore,
+	maps.PlaceTypeSupermarket,
+	maps.PlaceTypeTravelAgency,
+	maps.PlaceTypeVeterinaryCare,
+}
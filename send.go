@@ -0,0 +1,148 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"log/slog"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jomei/notionapi"
+	"golang.org/x/time/rate"
+)
+
+// readyToContact is the Contacted status of leads approved for the send command to email
+const readyToContact = "Ready to Contact"
+
+// outreachEmail is a templated email to a lead
+type outreachEmail struct {
+	lead    Business
+	subject string
+	body    string
+}
+
+// runSend emails the templated outreach to every lead marked Ready to Contact through the
+// configured SMTP server, then marks them Contacted today. Gmail works through smtp.gmail.com
+// with an app password.
+func runSend(notionClient *NotionClient, cfg *Config, sinks []Sink, args []string) {
+	fs := flag.NewFlagSet("send", flag.ExitOnError)
+	templatePath := fs.String("template", "", "outreach template file for the email body, with merge variables like {{.Name}} (see `template vars`)")
+	subject := fs.String("subject", "Websites for {{.Name}}", "subject line template")
+	limit := fs.Int("limit", 0, "send at most this many emails (0 for every lead ready to contact)")
+	dryRun := fs.Bool("dry-run", false, "print the emails that would be sent without sending them")
+	fs.Parse(args)
+
+	if *templatePath == "" {
+		log.Fatal("send: --template is required")
+	}
+	if cfg.SMTP == nil {
+		log.Fatal("send: the smtp server must be configured")
+	}
+	text, err := os.ReadFile(*templatePath)
+	if err != nil {
+		log.Fatalf("send: %v", err)
+	}
+	bodyTemplate, err := parseOutreachTemplate(*templatePath, string(text))
+	if err != nil {
+		log.Fatalf("send: %v", err)
+	}
+	subjectTemplate, err := parseOutreachTemplate("subject", *subject)
+	if err != nil {
+		log.Fatalf("send: subject: %v", err)
+	}
+
+	ctx := context.Background()
+	var emails []outreachEmail
+	filter := notionapi.PropertyFilter{
+		Property: "Contacted",
+		Select:   &notionapi.SelectFilterCondition{Equals: readyToContact},
+	}
+	err = notionClient.EachLead(ctx, filter, func(b Business) error {
+		if *limit > 0 && len(emails) >= *limit {
+			return nil
+		}
+		if b.Email == "" {
+			slog.Warn("Lead ready to contact has no email, skipping", "operation", "send", "lead", b.LeadNumber, "name", b.Name)
+			return nil
+		}
+		email := outreachEmail{lead: b}
+		if email.subject, err = renderTemplate(subjectTemplate, b); err != nil {
+			return fmt.Errorf("%s: subject: %w", b.LeadNumber, err)
+		}
+		if email.body, err = renderTemplate(bodyTemplate, b); err != nil {
+			return fmt.Errorf("%s: %w", b.LeadNumber, err)
+		}
+		emails = append(emails, email)
+		return nil
+	})
+	if err != nil {
+		log.Fatalf("Failed to list leads: %v", err)
+	}
+
+	if *dryRun {
+		for _, email := range emails {
+			fmt.Printf("To: %s (%s)\nSubject: %s\n\n%s\n\n", email.lead.Email, email.lead.LeadNumber, email.subject, email.body)
+		}
+		fmt.Printf("Would send %d emails\n", len(emails))
+		return
+	}
+
+	now := time.Now()
+	limiter := rate.NewLimiter(notionRequestsPerSecond, 1)
+	// Notion is marked directly, so a failure stops the run; the other sinks only get copies
+	others := slices.DeleteFunc(slices.Clone(sinks), func(sink Sink) bool { return sink.Name() == "notion" })
+	sent := 0
+	for _, email := range emails {
+		b := email.lead
+		message, err := buildMessage(cfg.SMTP.From, []string{b.Email}, strings.TrimSpace(email.subject), email.body, nil)
+		if err == nil {
+			err = cfg.SMTP.send([]string{b.Email}, message)
+		}
+		if err != nil {
+			slog.Error("Failed to send email", "operation", "send", "lead", b.LeadNumber, "name", b.Name, "err", err)
+			continue
+		}
+		sent++
+		fmt.Printf("Sent to %s (%s)\n", b.Name, b.LeadNumber)
+
+		before := b
+		markContacted(cfg.Cadence, &b, now)
+		if err := limiter.Wait(ctx); err != nil {
+			log.Fatal(err)
+		}
+		if err := markSent(ctx, notionClient, &b); err != nil {
+			fmt.Printf("Sent %d of %d emails\n", sent, len(emails))
+			log.Fatalf("send: stopped, %s (%s) was emailed but couldn't be marked Contacted, so the next send would email it again; mark it in Notion first: %v", b.Name, b.LeadNumber, err)
+		}
+		updateSinks(ctx, others, before, &b, cadenceFields, "sent outreach email")
+	}
+	fmt.Printf("Sent %d of %d emails\n", sent, len(emails))
+}
+
+// markSent writes a lead's Contacted status and cadence fields to its Notion page
+func markSent(ctx context.Context, notionClient *NotionClient, b *Business) error {
+	client := notionClient.pageClient(b.PageID)
+	props, err := client.businessProperties(b).Build()
+	if err != nil {
+		return err
+	}
+	for name := range props {
+		if !slices.Contains(cadenceFields, propertyField(name)) {
+			delete(props, name)
+		}
+	}
+	return client.UpdateBusiness(ctx, b.PageID, props)
+}
+
+// markContacted records that a lead was emailed today: as its cadence's step done when one is
+// configured, which schedules the follow-up, or else just as Contacted
+func markContacted(cadence Cadence, b *Business, now time.Time) {
+	b.Contacted = "Contacted"
+	if err := cadence.Complete(b, now); err != nil {
+		b.LastActionDate, b.LastContacted = startOfDayUTC(now), startOfDayUTC(now)
+	}
+}
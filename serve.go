@@ -0,0 +1,292 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"log/slog"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"time"
+)
+
+// searchRun is one search of every configured area; serve repeats it on a schedule
+type searchRun struct {
+	notionClient  *NotionClient
+	cfg           *Config
+	sources       []Source
+	companies     *CompaniesHouse
+	emails        *EmailGuesser
+	weights       ScoreWeights
+	areas         []*SearchArea
+	qualityReport string
+	state         *RunState
+	sinceLastRun  bool
+	// resume skips the areas the interrupted run in the state's checkpoint searched to the end
+	resume bool
+	// maxRuntime stops searching once a run has taken this long; 0 is no limit
+	maxRuntime time.Duration
+	notify     *Notifications
+	history    *History
+	// detailsCache is nil unless place details are cached between runs
+	detailsCache *DetailsCache
+	// deadLetters keep the leads sinks failed to write, for retry-failed
+	deadLetters *DeadLetters
+	budget      *Budget
+	campaign    string
+	filter      *ListingFilter
+	// onLead, when set, is called with every new lead once it is written
+	onLead func(*Business)
+	// fixture runs search canned Places data offline: nothing is billed, and the made-up websites
+	// aren't crawled or looked up over RDAP
+	fixture bool
+}
+
+// Run purges what the policy requires, searches every area and prints the run's reports. Leads
+// already in Notion are skipped, so repeated runs only insert new businesses; with sinceLastRun,
+// listings an earlier run saw aren't even enriched. It returns the run summary and the digest of
+// what it wrote.
+func (sr *searchRun) Run(ctx context.Context) (string, *RunDigest, error) {
+	started := time.Now()
+	runID := newRunID(started)
+	if sr.maxRuntime > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, sr.maxRuntime)
+		defer cancel()
+	}
+	progress.Reset()
+	sr.budget.Reset()
+	sr.detailsCache.Reset()
+	sr.deadLetters.Reset()
+	sr.filter.Reset()
+	searchesGoogle := !sr.fixture && slices.ContainsFunc(sr.sources, func(s Source) bool { return s.Name() == "google" || s.Name() == importSourceName })
+	if !sr.fixture && slices.ContainsFunc(sr.sources, func(s Source) bool { return s.Name() == "google" }) {
+		fmt.Print(estimateCost(sr.areas, sr.cfg.DetailsFieldMask()))
+	}
+	sinks, err := openSinks(sr.cfg.Sinks, sr.notionClient)
+	if err != nil {
+		slog.Error("Failed to open sinks", "operation", "search", "err", err)
+		sr.notify.Send(ctx, Event{Type: eventError, Summary: fmt.Sprintf("Search run failed to open sinks: %v", err)})
+		return "", nil, fmt.Errorf("opening sinks: %w", err)
+	}
+	defer func() {
+		if err := closeSinks(sinks); err != nil {
+			slog.Error("Failed to close sinks", "operation", "search", "err", err)
+		}
+	}()
+
+	finder := &Finder{
+		sources:      sr.sources,
+		sinks:        sinks,
+		companies:    sr.companies,
+		emails:       sr.emails,
+		urgencyRules: sr.cfg.UrgencyRules,
+		weights:      sr.weights,
+		policy:       sr.cfg.Policy,
+		cadence:      sr.cfg.Cadence,
+		state:        sr.state,
+		sinceLastRun: sr.sinceLastRun,
+		notify:       sr.notify,
+		fieldSources: sr.cfg.FieldSources,
+		history:      sr.history,
+		deadLetters:  sr.deadLetters,
+		campaign:     sr.campaign,
+		runID:        runID,
+		filter:       sr.filter,
+		onLead:       sr.onLead,
+		enrichSteps:  sr.cfg.Enrichers,
+		hooks:        sr.cfg.Hooks,
+	}
+	if !sr.fixture {
+		finder.crawler, finder.domainChecker = NewWebsiteCrawler(), NewDomainChecker()
+	}
+
+	// Opt-outs recorded in Notion since the last run are honoured before anything new is written.
+	// The mock Notion starts empty, with nothing to purge.
+	if sr.notionClient != nil {
+		sr.purge(ctx, sinks)
+	}
+
+	var done []string
+	if checkpoint := sr.state.Checkpoint(); sr.resume && checkpoint != nil {
+		done = slices.Clone(checkpoint.Areas)
+		fmt.Printf("Resuming the run interrupted since %s\n", checkpoint.Started.Format(time.DateTime))
+	}
+	finder.Start(ctx)
+	for _, area := range sr.areas {
+		if slices.Contains(done, area.Name) {
+			progress.Printf("Skipping area %s, searched before the run was interrupted\n", area.Name)
+			continue
+		}
+		finder.Search(ctx, area)
+		if ctx.Err() != nil {
+			break
+		}
+		done = append(done, area.Name)
+	}
+	finder.Wait()
+	// A stopped run still reports and saves what it did
+	stopped := ctx.Err()
+	interrupted := stopped != nil
+	ctx = context.WithoutCancel(ctx)
+	summary := fmt.Sprintf("Run ID: %s\n", runID) + progress.Summary() + sr.filter.Summary() + sr.state.Summary() + sr.deadLetters.Summary()
+	if searchesGoogle {
+		summary += sr.detailsCache.Summary() + sr.budget.String()
+	}
+	if errors.Is(stopped, context.DeadlineExceeded) {
+		summary += fmt.Sprintf("Run stopped at the --max-runtime of %s\n", sr.maxRuntime)
+	}
+	if interrupted {
+		summary += fmt.Sprintf("Run interrupted after %d of %d areas; resume with:\n  %s\n", len(done), len(sr.areas), resumeCommand(os.Args))
+	}
+	fmt.Print(summary)
+	digest := &RunDigest{Leads: finder.found, Failures: finder.failures}
+	sr.notify.Send(ctx, Event{Type: eventRunFinished, Summary: strings.TrimSpace(summary), Digest: digest})
+	// Runs against the mock Notion leave the local state, history and ledger as they were
+	if sr.notionClient != nil {
+		sr.saveFiles(started, interrupted, done)
+	}
+	if len(sr.sources) > 1 {
+		fmt.Print(finder.SourceReport())
+	}
+	if finder.Quality.Len() > 0 {
+		report := finder.Quality.String()
+		if sr.qualityReport != "" {
+			if err := os.WriteFile(sr.qualityReport, []byte(report), 0o644); err != nil {
+				slog.Error("Failed to write data quality report", "operation", "quality-report", "path", sr.qualityReport, "err", err)
+			} else {
+				fmt.Printf("Wrote %d data quality issues to %s\n", finder.Quality.Len(), sr.qualityReport)
+			}
+		} else {
+			fmt.Print(report)
+		}
+	}
+	return summary, digest, nil
+}
+
+// purge removes the contact data of leads that opted out, and of leads past the retention period
+func (sr *searchRun) purge(ctx context.Context, sinks []Sink) {
+	if purged, err := purgeDoNotContact(ctx, sr.notionClient, sinks, sr.deadLetters, false); err != nil {
+		slog.Error("Failed to purge opted-out leads", "operation", "purge", "err", err)
+	} else if purged > 0 {
+		fmt.Printf("Purged contact data of %d %s records\n", purged, doNotContact)
+	}
+	if days := sr.cfg.Policy.RetentionDays; days > 0 {
+		purged, err := purgeExpired(ctx, sr.notionClient, sr.deadLetters, days, false)
+		if err != nil {
+			slog.Error("Failed to purge expired leads", "operation", "purge", "err", err)
+		} else if purged > 0 {
+			fmt.Printf("Purged contact data of %d leads older than %d days\n", purged, days)
+		}
+	}
+}
+
+// saveFiles saves the run state, usage ledger, lead history, details cache and failed writes after
+// a run, checkpointing the areas done if it was interrupted
+func (sr *searchRun) saveFiles(started time.Time, interrupted bool, done []string) {
+	saveState := func() error { return sr.state.Save(started) }
+	if interrupted {
+		saveState = func() error { return sr.state.SaveInterrupted(started, done) }
+	}
+	if err := saveState(); err != nil {
+		slog.Error("Failed to save run state", "operation", "search", "path", sr.state.path, "err", err)
+	}
+	if err := sr.budget.ledger.Save(); err != nil {
+		slog.Error("Failed to save usage ledger", "operation", "search", "path", sr.budget.ledger.path, "err", err)
+	}
+	if err := sr.history.Save(); err != nil {
+		slog.Error("Failed to save lead history", "operation", "search", "path", sr.history.path, "err", err)
+	}
+	if err := sr.detailsCache.Save(); err != nil {
+		slog.Error("Failed to save place details cache", "operation", "search", "path", sr.detailsCache.path, "err", err)
+	}
+	if err := sr.deadLetters.Save(); err != nil {
+		slog.Error("Failed to save failed writes", "operation", "search", "path", sr.deadLetters.path, "err", err)
+	} else if n := sr.deadLetters.Len(); n > 0 {
+		fmt.Printf("%d failed writes are kept in %s; write them again with: business-finder retry-failed\n", n, sr.deadLetters.path)
+	}
+}
+
+// runServe repeats the search run on a cron schedule, serves the REST API, or both, until the
+// process is stopped. Scheduled runs due while an API run is going are skipped.
+func runServe(ctx context.Context, run *searchRun, args []string) {
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	scheduleExpr := fs.String("schedule", "", `cron schedule of search runs, e.g. "0 6 * * MON" for 06:00 every Monday`)
+	runNow := fs.Bool("run-now", false, "run once at startup, before the first scheduled run")
+	listen := fs.String("listen", "", "serve the REST API on this address, e.g. 127.0.0.1:8080; other interfaces need BUSINESS_FINDER_API_TOKEN")
+	grpcListen := fs.String("grpc-listen", "", "serve the gRPC Finder service on this address, e.g. 127.0.0.1:9090; other interfaces need BUSINESS_FINDER_API_TOKEN")
+	fs.Parse(args)
+
+	if *scheduleExpr == "" && *listen == "" && *grpcListen == "" {
+		log.Fatal("serve: --schedule, --listen or --grpc-listen is required")
+	}
+	// Leads' contact data and paid Google runs aren't left open to the network
+	if os.Getenv("BUSINESS_FINDER_API_TOKEN") == "" {
+		for _, addr := range []string{*listen, *grpcListen} {
+			if addr != "" && !loopbackAddress(addr) {
+				log.Fatalf("serve: %s listens beyond this machine; set BUSINESS_FINDER_API_TOKEN or listen on 127.0.0.1", addr)
+			}
+		}
+	}
+	var schedule *Schedule
+	if *scheduleExpr != "" {
+		var err error
+		if schedule, err = ParseSchedule(*scheduleExpr); err != nil {
+			log.Fatalf("serve: %v", err)
+		}
+	}
+	runner := newRunner(run)
+	if *listen != "" {
+		server := &http.Server{Addr: *listen, Handler: NewAPIServer(ctx, runner).Handler()}
+		go func() {
+			if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				log.Fatalf("serve: %v", err)
+			}
+		}()
+		defer server.Close()
+		fmt.Printf("Serving the API on %s\n", *listen)
+	}
+	if *grpcListen != "" {
+		server := newGRPCServer(runner, os.Getenv("BUSINESS_FINDER_API_TOKEN"))
+		go func() {
+			if err := serveGRPC(server, *grpcListen); err != nil {
+				log.Fatalf("serve: %v", err)
+			}
+		}()
+		defer server.Stop()
+		fmt.Printf("Serving gRPC on %s\n", *grpcListen)
+	}
+
+	runOnce := func(trigger string) {
+		status, err := runner.begin(nil, trigger)
+		if err != nil {
+			slog.Warn("Skipped search run", "operation", "serve", "trigger", trigger, "err", err)
+			return
+		}
+		runner.execute(ctx, status, nil)
+	}
+	if *runNow {
+		runOnce("startup")
+	}
+	if schedule == nil {
+		<-ctx.Done()
+		return
+	}
+	for {
+		next := schedule.Next(time.Now())
+		if next.IsZero() {
+			log.Fatalf("serve: schedule %q never runs", *scheduleExpr)
+		}
+		fmt.Printf("Next search run at %s\n", next.Format(time.DateTime))
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Until(next)):
+		}
+		runOnce("schedule")
+	}
+}
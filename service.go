@@ -0,0 +1,230 @@
+package main
+
+import (
+	"encoding/xml"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"text/template"
+)
+
+// serviceName names the installed service, scheduled task or launch agent
+const serviceName = "business-finder"
+
+// serviceUnit is the systemd user unit that runs serve on Linux
+var serviceUnit = template.Must(template.New("unit").Parse(`[Unit]
+Description=Business finder scheduled search runs
+After=network-online.target
+
+[Service]
+WorkingDirectory={{.Dir}}
+ExecStart={{.Command}}
+Restart=on-failure
+
+[Install]
+WantedBy=default.target
+`))
+
+// launchAgent is the launchd agent that runs serve on macOS
+var launchAgent = template.Must(template.New("plist").Funcs(template.FuncMap{"xml": xmlText}).Parse(`<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>Label</key><string>com.{{.Name}}</string>
+	<key>ProgramArguments</key>
+	<array>{{range .Args}}
+		<string>{{xml .}}</string>{{end}}
+	</array>
+	<key>WorkingDirectory</key><string>{{xml .Dir}}</string>
+	<key>RunAtLoad</key><true/>
+	<key>KeepAlive</key><true/>
+	<key>StandardOutPath</key><string>{{xml .Log}}</string>
+	<key>StandardErrorPath</key><string>{{xml .Log}}</string>
+</dict>
+</plist>
+`))
+
+// xmlText escapes a value for a plist string
+func xmlText(s string) string {
+	var sb strings.Builder
+	xml.EscapeText(&sb, []byte(s))
+	return sb.String()
+}
+
+// serviceScript is the batch file the Windows scheduled task starts. Tasks start in the system
+// directory, so it changes to the directory .env, the config and the state files are in first.
+var serviceScript = template.Must(template.New("cmd").Parse("@echo off\r\n" +
+	"cd /d \"{{.Dir}}\"\r\n" +
+	"{{.Command}} >> \"{{.Log}}\" 2>&1\r\n"))
+
+// serviceFiles describe an installation of serve in the current directory
+type serviceFiles struct {
+	Name    string
+	Dir     string
+	Args    []string
+	Command string
+	Log     string
+	// path is where the unit, plist or batch file goes
+	path string
+}
+
+// runService installs, removes or shows the service that keeps serve running in the background:
+// a systemd user unit on Linux, a launch agent on macOS and a scheduled task started at logon on
+// Windows. The global flags given before "service" are passed on to serve.
+func runService(globalArgs, args []string) {
+	if len(args) == 0 {
+		log.Fatal("service: expected install, uninstall or status")
+	}
+	action := args[0]
+	fs := flag.NewFlagSet("service "+action, flag.ExitOnError)
+	schedule := fs.String("schedule", "", `cron schedule passed to serve, e.g. "0 6 * * MON" (required to install)`)
+	dryRun := fs.Bool("dry-run", false, "print the service file and commands instead of installing")
+	fs.Parse(args[1:])
+
+	files, err := newServiceFiles(globalArgs, *schedule)
+	if err != nil {
+		log.Fatalf("service: %v", err)
+	}
+	var commands [][]string
+	switch action {
+	case "install":
+		if *schedule == "" {
+			log.Fatal("service install: --schedule is required")
+		}
+		if _, err := ParseSchedule(*schedule); err != nil {
+			log.Fatalf("service install: %v", err)
+		}
+		content, err := files.render()
+		if err != nil {
+			log.Fatalf("service install: %v", err)
+		}
+		if *dryRun {
+			fmt.Printf("Would write %s:\n%s\n", files.path, content)
+		} else {
+			if err := os.MkdirAll(filepath.Dir(files.path), 0o755); err != nil {
+				log.Fatalf("service install: %v", err)
+			}
+			if err := os.WriteFile(files.path, []byte(content), 0o644); err != nil {
+				log.Fatalf("service install: %v", err)
+			}
+			fmt.Printf("Wrote %s\n", files.path)
+		}
+		commands = files.installCommands()
+	case "uninstall":
+		commands = files.uninstallCommands()
+	case "status":
+		commands = files.statusCommands()
+	default:
+		log.Fatalf("service: unknown action %q, expected install, uninstall or status", action)
+	}
+
+	for _, command := range commands {
+		if *dryRun {
+			fmt.Printf("Would run: %s\n", strings.Join(command, " "))
+			continue
+		}
+		cmd := exec.Command(command[0], command[1:]...)
+		cmd.Stdout, cmd.Stderr = os.Stdout, os.Stderr
+		if err := cmd.Run(); err != nil && action != "status" {
+			log.Fatalf("service %s: %s: %v", action, strings.Join(command, " "), err)
+		}
+	}
+	if action == "uninstall" && !*dryRun {
+		if err := os.Remove(files.path); err != nil && !os.IsNotExist(err) {
+			log.Fatalf("service uninstall: %v", err)
+		}
+		fmt.Printf("Removed %s\n", files.path)
+	}
+}
+
+// newServiceFiles describes running this executable's serve command from the current directory
+func newServiceFiles(globalArgs []string, schedule string) (*serviceFiles, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return nil, err
+	}
+	dir, err := os.Getwd()
+	if err != nil {
+		return nil, err
+	}
+	files := &serviceFiles{
+		Name: serviceName,
+		Dir:  dir,
+		Args: append(append([]string{exe}, globalArgs...), "serve", "--schedule", schedule),
+		Log:  filepath.Join(dir, serviceName+".log"),
+	}
+	quoted := make([]string, len(files.Args))
+	for i, arg := range files.Args {
+		quoted[i] = `"` + strings.ReplaceAll(arg, `"`, `\"`) + `"`
+	}
+	files.Command = strings.Join(quoted, " ")
+
+	switch runtime.GOOS {
+	case "windows":
+		files.path = filepath.Join(dir, serviceName+"-service.cmd")
+	case "darwin":
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, err
+		}
+		files.path = filepath.Join(home, "Library", "LaunchAgents", "com."+serviceName+".plist")
+	default:
+		configDir, err := os.UserConfigDir()
+		if err != nil {
+			return nil, err
+		}
+		files.path = filepath.Join(configDir, "systemd", "user", serviceName+".service")
+	}
+	return files, nil
+}
+
+func (sf *serviceFiles) render() (string, error) {
+	tmpl := serviceUnit
+	switch runtime.GOOS {
+	case "windows":
+		tmpl = serviceScript
+	case "darwin":
+		tmpl = launchAgent
+	}
+	var sb strings.Builder
+	err := tmpl.Execute(&sb, sf)
+	return sb.String(), err
+}
+
+func (sf *serviceFiles) installCommands() [][]string {
+	switch runtime.GOOS {
+	case "windows":
+		return [][]string{{"schtasks", "/Create", "/F", "/SC", "ONLOGON", "/TN", sf.Name, "/TR", `"` + sf.path + `"`}}
+	case "darwin":
+		return [][]string{{"launchctl", "load", "-w", sf.path}}
+	}
+	return [][]string{
+		{"systemctl", "--user", "daemon-reload"},
+		{"systemctl", "--user", "enable", "--now", sf.Name},
+	}
+}
+
+func (sf *serviceFiles) uninstallCommands() [][]string {
+	switch runtime.GOOS {
+	case "windows":
+		return [][]string{{"schtasks", "/Delete", "/F", "/TN", sf.Name}}
+	case "darwin":
+		return [][]string{{"launchctl", "unload", "-w", sf.path}}
+	}
+	return [][]string{{"systemctl", "--user", "disable", "--now", sf.Name}}
+}
+
+func (sf *serviceFiles) statusCommands() [][]string {
+	switch runtime.GOOS {
+	case "windows":
+		return [][]string{{"schtasks", "/Query", "/TN", sf.Name}}
+	case "darwin":
+		return [][]string{{"launchctl", "list", "com." + sf.Name}}
+	}
+	return [][]string{{"systemctl", "--user", "status", sf.Name}}
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"slices"
+	"strings"
+	"syscall"
+)
+
+// cancelOnSignal cancels the run on the first SIGINT or SIGTERM, so it stops searching but still
+// writes the leads in flight and saves its state; a second signal quits at once. The returned
+// function stops listening.
+func cancelOnSignal(cancel context.CancelFunc) func() {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-signals:
+			// Restoring the default handling lets the next signal kill the process
+			signal.Stop(signals)
+			fmt.Fprintln(os.Stderr, "Stopping: writing the leads in flight and saving state; interrupt again to quit now")
+			cancel()
+		case <-done:
+		}
+	}()
+	return func() {
+		signal.Stop(signals)
+		close(done)
+	}
+}
+
+// resumeCommand is the command line that resumes an interrupted run: the same one with --resume
+func resumeCommand(args []string) string {
+	if len(args) == 0 {
+		return ""
+	}
+	args = slices.Clone(args)
+	if !slices.ContainsFunc(args, func(arg string) bool { return arg == "--resume" || arg == "-resume" }) {
+		// Global flags come before any command
+		args = slices.Insert(args, 1, "--resume")
+	}
+	for i, arg := range args {
+		if arg == "" || strings.ContainsAny(arg, " \t\n'\"$`\\*?&;|<>()") {
+			args[i] = "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+		}
+	}
+	return strings.Join(args, " ")
+}
@@ -0,0 +1,575 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Sink receives every enriched business of a run
+type Sink interface {
+	Name() string
+	Write(ctx context.Context, b *Business) error
+	Close() error
+}
+
+// Rewriter is implemented by sinks that can change records they already hold. fn edits a record,
+// keyed by field name, in place and reports whether it changed it; Rewrite returns how many did.
+type Rewriter interface {
+	Rewrite(fn func(record map[string]string) bool) (int, error)
+}
+
+// Updater is implemented by sinks that can change the given fields of a business written earlier
+type Updater interface {
+	Update(ctx context.Context, b *Business, fields []string) error
+}
+
+// updateSinks sends changed fields of a business to every sink that can update it, recording in
+// the diff log how they differ from before and why
+func updateSinks(ctx context.Context, sinks []Sink, before Business, b *Business, fields []string, reason string) {
+	diffLog.Record(before, *b, fields, reason)
+	for _, sink := range sinks {
+		updater, ok := sink.(Updater)
+		if !ok {
+			continue
+		}
+		if err := updater.Update(ctx, b, fields); err != nil {
+			slog.Error("Failed to update lead", "operation", "update", "sink", sink.Name(), "place_id", b.PlaceID, "name", b.Name, "err", err)
+		}
+	}
+}
+
+// pendingUpdates are field changes to file sink records, keyed by PlaceID. Rewriting a file per
+// change would be quadratic, so they are applied in a single rewrite when the sink closes.
+type pendingUpdates map[string]map[string]string
+
+func (pu pendingUpdates) add(b *Business, fields []string) {
+	data := templateData(*b)
+	changes := pu[b.PlaceID]
+	if changes == nil {
+		changes = make(map[string]string)
+		pu[b.PlaceID] = changes
+	}
+	for _, field := range fields {
+		changes[field] = data[field]
+	}
+}
+
+func (pu pendingUpdates) apply(rewriter Rewriter) error {
+	if len(pu) == 0 {
+		return nil
+	}
+	_, err := rewriter.Rewrite(func(record map[string]string) bool {
+		changed := false
+		for field, value := range pu[record["PlaceID"]] {
+			changed = setExisting(record, field, value) || changed
+		}
+		return changed
+	})
+	clear(pu)
+	return err
+}
+
+// setExisting sets a field of a sink record, leaving fields the sink doesn't hold alone
+func setExisting(record map[string]string, field, value string) bool {
+	current, ok := record[field]
+	if !ok || current == value {
+		return false
+	}
+	record[field] = value
+	return true
+}
+
+// FieldSelector picks which fields a sink receives. Field names are the merge variables listed by
+// `template vars`, e.g. Name, Phone, Types. An empty Include means every field.
+type FieldSelector struct {
+	Include []string `json:"include,omitempty"`
+	Exclude []string `json:"exclude,omitempty"`
+}
+
+// Allows reports whether a field passes the selector
+func (fs FieldSelector) Allows(field string) bool {
+	if len(fs.Include) > 0 && !slices.Contains(fs.Include, field) {
+		return false
+	}
+	return !slices.Contains(fs.Exclude, field)
+}
+
+// fields returns the catalog fields allowed by the selector, in catalog order
+func (fs FieldSelector) fields() []string {
+	var fields []string
+	for _, v := range templateVariables {
+		if fs.Allows(v.Name) {
+			fields = append(fields, v.Name)
+		}
+	}
+	return fields
+}
+
+// validate rejects field names that are not in the catalog
+func (fs FieldSelector) validate() error {
+	known := make(map[string]bool, len(templateVariables))
+	for _, v := range templateVariables {
+		known[v.Name] = true
+	}
+	// Photos, review snippets and contact records aren't merge variables but can be dropped from
+	// sinks that store them
+	known["Photos"] = true
+	known["ReviewSnippets"] = true
+	known["Contacts"] = true
+	for _, field := range append(slices.Clone(fs.Include), fs.Exclude...) {
+		if !known[field] {
+			return fmt.Errorf("unknown field %q", field)
+		}
+	}
+	return nil
+}
+
+// propertyField maps a Notion property name onto its field name
+func propertyField(property string) string {
+	if property == "Type" {
+		return "Types"
+	}
+	return property
+}
+
+// SinkConfig declares one output of a run
+type SinkConfig struct {
+	// Type is "notion", "csv", "jsonl" or "review", which queues leads for the dashboard
+	// instead of inserting them into Notion
+	Type string `json:"type"`
+	// Path is the output file of csv and jsonl sinks and the queue file of the review sink
+	Path string `json:"path,omitempty"`
+	FieldSelector
+}
+
+// Validate checks that the sink type is known and its fields exist
+func (sc SinkConfig) Validate() error {
+	switch sc.Type {
+	case "notion":
+	case "csv", "jsonl":
+		if sc.Path == "" {
+			return fmt.Errorf("%s sink needs a path", sc.Type)
+		}
+		// Records are matched on PlaceID when leads are updated or purged later
+		if !sc.Allows("PlaceID") {
+			return fmt.Errorf("%s sink must include PlaceID", sc.Type)
+		}
+	case "review":
+		if sc.Path == "" {
+			return fmt.Errorf("%s sink needs a path", sc.Type)
+		}
+	default:
+		return fmt.Errorf("unknown sink type %q", sc.Type)
+	}
+	return sc.FieldSelector.validate()
+}
+
+// openSinks creates the configured sinks. The Notion sink is always first so that the lead
+// number it assigns is available to the others; without a Notion client, it is a MockSink.
+func openSinks(configs []SinkConfig, notionClient *NotionClient) ([]Sink, error) {
+	if len(configs) == 0 {
+		configs = []SinkConfig{{Type: "notion"}}
+	}
+	configs = slices.Clone(configs)
+	slices.SortStableFunc(configs, func(a, b SinkConfig) int {
+		if (a.Type == "notion") == (b.Type == "notion") {
+			return 0
+		}
+		if a.Type == "notion" {
+			return -1
+		}
+		return 1
+	})
+
+	var sinks []Sink
+	for _, sc := range configs {
+		var sink Sink
+		var err error
+		switch sc.Type {
+		case "notion":
+			if notionClient == nil {
+				sink = NewMockSink()
+				break
+			}
+			sink = &NotionSink{client: notionClient, fields: sc.FieldSelector}
+		case "csv":
+			sink, err = newCSVSink(sc.Path, sc.FieldSelector)
+		case "jsonl":
+			sink, err = newJSONLSink(sc.Path, sc.FieldSelector)
+		case "review":
+			if notionClient == nil {
+				err = errors.New("the review queue needs Notion")
+				break
+			}
+			sink, err = newReviewSink(sc.Path, notionClient)
+		}
+		if err != nil {
+			closeSinks(sinks)
+			return nil, fmt.Errorf("opening %s sink: %w", sc.Type, err)
+		}
+		sinks = append(sinks, sink)
+	}
+	return sinks, nil
+}
+
+// withSinks runs a command against the configured sinks, closing them afterwards
+func withSinks(configs []SinkConfig, notionClient *NotionClient, run func(sinks []Sink)) {
+	sinks, err := openSinks(configs, notionClient)
+	if err != nil {
+		log.Fatalf("Failed to open sinks: %v", err)
+	}
+	run(sinks)
+	if err := closeSinks(sinks); err != nil {
+		slog.Error("Failed to close sinks", "err", err)
+	}
+}
+
+// closeSinks closes every sink, returning the combined errors
+func closeSinks(sinks []Sink) error {
+	var errs []error
+	for _, sink := range sinks {
+		if err := sink.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", sink.Name(), err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// NotionSink inserts businesses into the Notion database, or the database of the first route
+// they match
+type NotionSink struct {
+	client *NotionClient
+	fields FieldSelector
+
+	mu sync.Mutex
+	// checked are the PlaceIDs Existing found missing, so Write needn't look them up again
+	checked map[string]bool
+}
+
+func (ns *NotionSink) Name() string { return "notion" }
+
+func (ns *NotionSink) concurrentWrites() {}
+
+func (ns *NotionSink) Write(ctx context.Context, b *Business) error {
+	ns.mu.Lock()
+	checked := ns.checked[b.PlaceID]
+	delete(ns.checked, b.PlaceID)
+	ns.mu.Unlock()
+	if checked {
+		return ns.client.createRouted(ctx, b, ns.fields)
+	}
+	return ns.client.InsertRouted(ctx, b, ns.fields)
+}
+
+// Existing looks a batch of leads up in one query per database, the main one and each route's
+func (ns *NotionSink) Existing(ctx context.Context, leads []*Business) (map[string]bool, error) {
+	placeIDs := make([]string, len(leads))
+	for i, b := range leads {
+		placeIDs[i] = b.PlaceID
+	}
+	existing, err := ns.client.ExistingAnywhere(ctx, placeIDs)
+	if err != nil {
+		return nil, err
+	}
+	ns.mu.Lock()
+	defer ns.mu.Unlock()
+	if ns.checked == nil {
+		ns.checked = make(map[string]bool)
+	}
+	for _, placeID := range placeIDs {
+		if !existing[placeID] {
+			ns.checked[placeID] = true
+		}
+	}
+	return existing, nil
+}
+
+// Duplicate looks for a lead in the databases like b under another PlaceID
+func (ns *NotionSink) Duplicate(ctx context.Context, b *Business) (*Business, error) {
+	return ns.client.FindDuplicateAnywhere(ctx, b)
+}
+
+// Update writes the given fields of a lead inserted earlier this run or read back from Notion
+func (ns *NotionSink) Update(ctx context.Context, b *Business, fields []string) error {
+	if b.PageID == "" {
+		return nil
+	}
+	client := ns.client.pageClient(b.PageID)
+	props, err := client.businessProperties(b).Build()
+	if err != nil {
+		return err
+	}
+	for name := range props {
+		if field := propertyField(name); !slices.Contains(fields, field) || !ns.fields.Allows(field) {
+			delete(props, name)
+		}
+	}
+	if len(props) == 0 {
+		return nil
+	}
+	return client.UpdateBusiness(ctx, b.PageID, props)
+}
+
+func (ns *NotionSink) Close() error { return nil }
+
+// CSVSink appends businesses to a CSV file, writing a header when the file is new
+type CSVSink struct {
+	path    string
+	f       *os.File
+	w       *csv.Writer
+	fields  []string
+	pending pendingUpdates
+}
+
+func newCSVSink(path string, selector FieldSelector) (*CSVSink, error) {
+	sink := &CSVSink{path: path, fields: selector.fields(), pending: make(pendingUpdates)}
+	if err := sink.open(); err != nil {
+		return nil, err
+	}
+	return sink, nil
+}
+
+// open opens the file for appending, writing the header if it is empty
+func (cs *CSVSink) open() error {
+	f, err := os.OpenFile(cs.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	cs.f, cs.w = f, csv.NewWriter(f)
+	if info, err := f.Stat(); err == nil && info.Size() == 0 {
+		cs.w.Write(cs.fields)
+	}
+	return nil
+}
+
+func (cs *CSVSink) Name() string { return "csv" }
+
+func (cs *CSVSink) Write(ctx context.Context, b *Business) error {
+	data := templateData(*b)
+	row := make([]string, len(cs.fields))
+	for i, field := range cs.fields {
+		row[i] = data[field]
+	}
+	cs.w.Write(row)
+	// Flush per record so a crash mid-run doesn't lose buffered leads
+	cs.w.Flush()
+	return cs.w.Error()
+}
+
+// Update queues field changes for the next rewrite
+func (cs *CSVSink) Update(ctx context.Context, b *Business, fields []string) error {
+	cs.pending.add(b, fields)
+	return nil
+}
+
+func (cs *CSVSink) Close() error {
+	return errors.Join(cs.pending.apply(cs), cs.closeFile())
+}
+
+func (cs *CSVSink) closeFile() error {
+	cs.w.Flush()
+	return errors.Join(cs.w.Error(), cs.f.Close())
+}
+
+// Rewrite applies fn to every row of the file. A file that can't be reopened afterwards is
+// reported, as every later write to the sink would fail.
+func (cs *CSVSink) Rewrite(fn func(record map[string]string) bool) (_ int, err error) {
+	if err := cs.closeFile(); err != nil {
+		return 0, err
+	}
+	defer func() {
+		if openErr := cs.open(); openErr != nil {
+			err = errors.Join(err, fmt.Errorf("reopening %s: %w", cs.path, openErr))
+		}
+	}()
+
+	f, err := os.Open(cs.path)
+	if err != nil {
+		return 0, err
+	}
+	rows, err := csv.NewReader(f).ReadAll()
+	f.Close()
+	if err != nil || len(rows) == 0 {
+		return 0, err
+	}
+
+	header := rows[0]
+	changed := 0
+	for _, row := range rows[1:] {
+		record := make(map[string]string, len(header))
+		for i, field := range header {
+			if i < len(row) {
+				record[field] = row[i]
+			}
+		}
+		if !fn(record) {
+			continue
+		}
+		for i, field := range header {
+			if i < len(row) {
+				row[i] = record[field]
+			}
+		}
+		changed++
+	}
+	if changed == 0 {
+		return 0, nil
+	}
+	return changed, replaceFile(cs.path, func(f *os.File) error {
+		w := csv.NewWriter(f)
+		w.WriteAll(rows)
+		return w.Error()
+	})
+}
+
+// JSONLSink appends businesses to a JSON Lines file, one object per business
+type JSONLSink struct {
+	path    string
+	f       *os.File
+	enc     *json.Encoder
+	fields  []string
+	pending pendingUpdates
+}
+
+func newJSONLSink(path string, selector FieldSelector) (*JSONLSink, error) {
+	sink := &JSONLSink{path: path, fields: selector.fields(), pending: make(pendingUpdates)}
+	if err := sink.open(); err != nil {
+		return nil, err
+	}
+	return sink, nil
+}
+
+// open opens the file for appending
+func (js *JSONLSink) open() error {
+	f, err := os.OpenFile(js.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	js.f, js.enc = f, json.NewEncoder(f)
+	return nil
+}
+
+func (js *JSONLSink) Name() string { return "jsonl" }
+
+func (js *JSONLSink) Write(ctx context.Context, b *Business) error {
+	data := templateData(*b)
+	record := make(map[string]string, len(js.fields))
+	for _, field := range js.fields {
+		record[field] = data[field]
+	}
+	return js.enc.Encode(record)
+}
+
+// Update queues field changes for the next rewrite
+func (js *JSONLSink) Update(ctx context.Context, b *Business, fields []string) error {
+	js.pending.add(b, fields)
+	return nil
+}
+
+func (js *JSONLSink) Close() error {
+	return errors.Join(js.pending.apply(js), js.f.Close())
+}
+
+// Rewrite applies fn to every record of the file, reporting a file that can't be reopened
+// afterwards as CSVSink.Rewrite does
+func (js *JSONLSink) Rewrite(fn func(record map[string]string) bool) (_ int, err error) {
+	if err := js.f.Close(); err != nil {
+		return 0, err
+	}
+	defer func() {
+		if openErr := js.open(); openErr != nil {
+			err = errors.Join(err, fmt.Errorf("reopening %s: %w", js.path, openErr))
+		}
+	}()
+
+	f, err := os.Open(js.path)
+	if err != nil {
+		return 0, err
+	}
+	var records []map[string]string
+	dec := json.NewDecoder(f)
+	for dec.More() {
+		var record map[string]string
+		if err := dec.Decode(&record); err != nil {
+			f.Close()
+			return 0, fmt.Errorf("reading %s: %w", js.path, err)
+		}
+		records = append(records, record)
+	}
+	f.Close()
+
+	changed := 0
+	for _, record := range records {
+		if fn(record) {
+			changed++
+		}
+	}
+	if changed == 0 {
+		return 0, nil
+	}
+	return changed, replaceFile(js.path, func(f *os.File) error {
+		enc := json.NewEncoder(f)
+		for _, record := range records {
+			if err := enc.Encode(record); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// rewriteSinks applies fn to the records of every sink that supports rewriting
+func rewriteSinks(sinks []Sink, fn func(record map[string]string) bool) (int, error) {
+	var errs []error
+	changed := 0
+	for _, sink := range sinks {
+		rewriter, ok := sink.(Rewriter)
+		if !ok {
+			continue
+		}
+		n, err := rewriter.Rewrite(fn)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", sink.Name(), err))
+		}
+		changed += n
+	}
+	return changed, errors.Join(errs...)
+}
+
+// replaceFile rewrites a file through a temporary file, so a failed write leaves the original intact
+func replaceFile(path string, write func(f *os.File) error) error {
+	// Windows only lifts its 260 character path limit for absolute paths
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if err := errors.Join(write(tmp), tmp.Close()); err != nil {
+		return err
+	}
+	err = os.Rename(tmp.Name(), path)
+	// On Windows a file can't be replaced while another process, such as a virus scanner or a
+	// spreadsheet the CSV is open in, holds it, which is usually brief
+	for attempt := 1; err != nil && runtime.GOOS == "windows" && attempt <= renameAttempts; attempt++ {
+		time.Sleep(time.Duration(attempt) * 200 * time.Millisecond)
+		err = os.Rename(tmp.Name(), path)
+	}
+	return err
+}
+
+// renameAttempts is how many more times replacing a file is attempted on Windows
+const renameAttempts = 5
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// slackEscaper escapes the characters Slack's mrkdwn gives a meaning to
+var slackEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+// SlackNotifier posts events to a Slack channel through an incoming webhook
+type SlackNotifier struct {
+	url    string
+	client *http.Client
+}
+
+// NewSlackNotifier initializes a new SlackNotifier
+func NewSlackNotifier(url string) *SlackNotifier {
+	return &SlackNotifier{url: url, client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+func (sn *SlackNotifier) Name() string { return "slack" }
+
+// Notify posts the event summary, or for hot_lead events the lead's name linked to its Notion
+// page, its address and why it is urgent
+func (sn *SlackNotifier) Notify(ctx context.Context, event Event) error {
+	text := slackEscaper.Replace(event.Summary)
+	if event.Lead != nil {
+		text = slackLead(*event.Lead)
+	}
+	return postJSON(ctx, sn.client, sn.url, map[string]string{"text": text})
+}
+
+// slackLead formats a hot lead as a Slack message
+func slackLead(b Business) string {
+	name := "*" + slackEscaper.Replace(b.Name) + "*"
+	if b.PageURL != "" {
+		name = fmt.Sprintf("<%s|%s>", b.PageURL, slackEscaper.Replace(b.Name))
+	}
+	lines := []string{":fire: New High urgency lead: " + name}
+	if b.Address != "" {
+		lines = append(lines, slackEscaper.Replace(b.Address))
+	}
+	reason := explainScore(b.ScoreBreakdown)
+	if reason == "" {
+		reason = b.WebsiteStatus
+	}
+	lines = append(lines, "Why: "+slackEscaper.Replace(reason))
+	return strings.Join(lines, "\n")
+}
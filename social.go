@@ -0,0 +1,100 @@
+package main
+
+import (
+	"net/url"
+	"strings"
+)
+
+// SocialProfiles holds the social media profile URLs of a business
+type SocialProfiles struct {
+	Facebook  string
+	Instagram string
+	LinkedIn  string
+	X         string
+}
+
+// socialNonProfilePaths are first path segments that point at share widgets or platform pages rather than a profile
+var socialNonProfilePaths = map[string]bool{
+	"sharer":       true,
+	"sharer.php":   true,
+	"share":        true,
+	"shareArticle": true,
+	"intent":       true,
+	"home":         true,
+	"login":        true,
+	"plugins":      true,
+	"dialog":       true,
+	"tr":           true,
+	"p":            true,
+	"explore":      true,
+	"hashtag":      true,
+	"policies":     true,
+}
+
+// Merge fills any empty profiles from other
+func (sp *SocialProfiles) Merge(other SocialProfiles) {
+	if sp.Facebook == "" {
+		sp.Facebook = other.Facebook
+	}
+	if sp.Instagram == "" {
+		sp.Instagram = other.Instagram
+	}
+	if sp.LinkedIn == "" {
+		sp.LinkedIn = other.LinkedIn
+	}
+	if sp.X == "" {
+		sp.X = other.X
+	}
+}
+
+// IsEmpty reports whether no profiles were found
+func (sp SocialProfiles) IsEmpty() bool {
+	return sp.Facebook == "" && sp.Instagram == "" && sp.LinkedIn == "" && sp.X == ""
+}
+
+// extractSocialProfiles collects the first profile link per network from a page
+func extractSocialProfiles(page string) SocialProfiles {
+	var profiles SocialProfiles
+	for _, match := range hrefPattern.FindAllStringSubmatch(page, -1) {
+		profiles.Merge(classifySocialURL(match[1]))
+	}
+	return profiles
+}
+
+// classifySocialURL returns a SocialProfiles with the matching network set, if link is a profile URL
+func classifySocialURL(link string) SocialProfiles {
+	var profiles SocialProfiles
+
+	u, err := url.Parse(strings.TrimSpace(link))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return profiles
+	}
+	host := strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+	host = strings.TrimPrefix(host, "m.")
+	segment := strings.Split(strings.Trim(u.Path, "/"), "/")[0]
+	if segment == "" || socialNonProfilePaths[segment] {
+		return profiles
+	}
+
+	clean := "https://" + u.Hostname() + strings.TrimRight(u.Path, "/")
+	switch host {
+	case "facebook.com", "fb.com":
+		profiles.Facebook = clean
+	case "instagram.com":
+		profiles.Instagram = clean
+	case "linkedin.com", "uk.linkedin.com":
+		if segment == "company" || segment == "in" {
+			profiles.LinkedIn = clean
+		}
+	case "x.com", "twitter.com":
+		profiles.X = clean
+	}
+	return profiles
+}
+
+// SetSocials fills any of the business's empty social profile fields from profiles
+func (b *Business) SetSocials(profiles SocialProfiles) {
+	current := SocialProfiles{Facebook: b.Facebook, Instagram: b.Instagram, LinkedIn: b.LinkedIn, X: b.X}
+	current.Merge(profiles)
+	b.Facebook, b.Instagram, b.LinkedIn, b.X = current.Facebook, current.Instagram, current.LinkedIn, current.X
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+)
+
+// Source is a directory of businesses that areas are searched in
+type Source interface {
+	Name() string
+	// Search calls fn with every business found in the area. Businesses are passed on as they
+	// are found rather than collected, so a run's memory doesn't grow with the area.
+	Search(ctx context.Context, area *SearchArea, fn func(*Business)) error
+}
+
+// RunScoped is implemented by sources that remember what they listed across the areas of a run;
+// Reset forgets it before the next run
+type RunScoped interface {
+	Reset()
+}
+
+// defaultSources are searched when neither --source nor the config's sources are given
+const defaultSources = "google"
+
+// knownSources are the source names openSources accepts
+var knownSources = []string{"google", "yelp", "foursquare", "osm", fixtureSourceName}
+
+// sourceNeedsGoogle reports whether any of the named sources uses the Google Places API
+func sourceNeedsGoogle(names []string) bool {
+	return slices.ContainsFunc(names, func(name string) bool { return strings.EqualFold(name, "google") })
+}
+
+// openSources creates the named sources, in the order they are searched
+func openSources(names []string, google func() *GoogleSource) ([]Source, error) {
+	var sources []Source
+	for _, name := range names {
+		switch strings.ToLower(name) {
+		case "google":
+			sources = append(sources, google())
+		case "yelp":
+			apiKey := os.Getenv("YELP_API_KEY")
+			if apiKey == "" {
+				return nil, fmt.Errorf("YELP_API_KEY must be set to search Yelp")
+			}
+			sources = append(sources, NewYelpSource(apiKey))
+		case "foursquare":
+			apiKey := os.Getenv("FOURSQUARE_API_KEY")
+			if apiKey == "" {
+				return nil, fmt.Errorf("FOURSQUARE_API_KEY must be set to search Foursquare")
+			}
+			sources = append(sources, NewFoursquareSource(apiKey))
+		case "osm":
+			sources = append(sources, NewOSMSource())
+		case fixtureSourceName:
+			// The Google source, searching the fixtures main gave it in place of the Places API
+			sources = append(sources, google())
+		default:
+			return nil, fmt.Errorf("unknown source %q", name)
+		}
+	}
+	if len(sources) == 0 {
+		return nil, fmt.Errorf("no sources to search")
+	}
+	return sources, nil
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"slices"
+)
+
+// runStats prints how many stored leads each source listed, found first, and listed alone, which
+// shows what each provider subscription adds
+func runStats(notionClient *NotionClient, args []string) {
+	fs := flag.NewFlagSet("stats", flag.ExitOnError)
+	where := fs.String("where", "", `only count leads matching a filter expression, e.g. "town=Falmouth"`)
+	fs.Parse(args)
+
+	filter, err := ParseFilter(*where)
+	if err != nil {
+		log.Fatalf("stats: %v", err)
+	}
+
+	type counts struct{ listed, first, only int }
+	bySource := make(map[string]*counts)
+	count := func(source string) *counts {
+		if bySource[source] == nil {
+			bySource[source] = &counts{}
+		}
+		return bySource[source]
+	}
+	total := 0
+	err = notionClient.EachBusiness(context.Background(), nil, func(b Business) error {
+		if !filter.Matches(b) {
+			return nil
+		}
+		total++
+		// Leads from before sources were recorded all came from Google
+		sources := b.Sources
+		if len(sources) == 0 {
+			sources = []string{"google"}
+		}
+		for _, source := range sources {
+			count(source).listed++
+		}
+		count(cmp.Or(b.FirstSource, sources[0])).first++
+		if len(sources) == 1 {
+			count(sources[0]).only++
+		}
+		return nil
+	})
+	if err != nil {
+		log.Fatalf("Failed to list leads: %v", err)
+	}
+
+	names := make([]string, 0, len(bySource))
+	for name := range bySource {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	fmt.Printf("%d leads\n", total)
+	for _, name := range names {
+		c := bySource[name]
+		fmt.Printf("  %s: listed %d, found first %d, only source for %d\n", name, c.listed, c.first, c.only)
+	}
+}
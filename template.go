@@ -0,0 +1,257 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"text/template/parse"
+	"time"
+)
+
+// templateVariable is a merge variable available to outreach templates and exports
+type templateVariable struct {
+	Name        string
+	Description string
+	Value       func(b Business) string
+}
+
+// templateVariables is the catalog of merge variables. Templates reference them as {{.Name}}.
+// Every Business and enrichment field that is useful in outreach should be listed here.
+var templateVariables = []templateVariable{
+	{"Name", "Business name as listed on Google", func(b Business) string { return b.Name }},
+	{"Address", "Formatted address", func(b Business) string { return b.Address }},
+	{"Street", "Street address, e.g. 12 High Street", func(b Business) string { return b.Street }},
+	{"City", "Town or city", func(b Business) string { return b.City }},
+	{"Postcode", "Postcode", func(b Business) string { return b.Postcode }},
+	{"PostcodeDistrict", "Postcode district, e.g. TR11", func(b Business) string { return postcodeDistrict(b.Postcode) }},
+	{"Country", "Country", func(b Business) string { return b.Country }},
+	{"Latitude", "Latitude of the business, e.g. 50.153802", func(b Business) string { return formatCoordinate(b, b.Location.Lat) }},
+	{"Longitude", "Longitude of the business, e.g. -5.070956", func(b Business) string { return formatCoordinate(b, b.Location.Lng) }},
+	{"DistanceKm", "Distance in km from the centre of the search area, e.g. 3.2", func(b Business) string { return formatDistance(b.DistanceKm) }},
+	{"PlaceID", "Google Place ID", func(b Business) string { return b.PlaceID }},
+	{"Types", "Comma-separated Google place types", func(b Business) string { return strings.Join(b.Type, ", ") }},
+	{"WebsiteStatus", `"Has Website", "No Website", "Broken Website" or "Unknown Website"`, func(b Business) string { return b.WebsiteStatus }},
+	{"BusinessStatus", `"OPERATIONAL", "CLOSED_TEMPORARILY" or "CLOSED_PERMANENTLY", empty when the source doesn't say`, func(b Business) string { return b.BusinessStatus }},
+	{"Urgency", "High, Medium or Low", func(b Business) string { return b.Urgency }},
+	{"Contacted", "Outreach status", func(b Business) string { return b.Contacted }},
+	{"URL", "Business website, or a Google Maps search link when there is none", func(b Business) string { return b.URL }},
+	{"Email", "Best email address found on the website", func(b Business) string { return b.Email }},
+	{"Phone", "Phone number in E.164 format", func(b Business) string { return b.Phone }},
+	{"SuggestedDomain", "Available domain to pitch to businesses without a website", func(b Business) string { return b.SuggestedDomain }},
+	{"DomainRegistered", "Date the website's domain was registered, e.g. 2009-06-14", func(b Business) string { return formatDate(b.DomainRegistered) }},
+	{"DomainAge", "How long ago the website's domain was registered, e.g. 12 years", func(b Business) string { return domainAge(b.DomainRegistered, time.Now()) }},
+	{"Registrar", "Registrar the website's domain is registered with", func(b Business) string { return b.Registrar }},
+	{"SSL", `"true" if the website is served over HTTPS`, func(b Business) string { return strconv.FormatBool(b.HTTPS) }},
+	{"OpeningHours", "Weekly opening hours, one day per line", func(b Business) string { return b.OpeningHours }},
+	{"HoursListed", `"true" if Google lists opening hours`, func(b Business) string { return strconv.FormatBool(b.HoursListed) }},
+	{"LikelyUnclaimed", `"true" if the Google profile shows enough signs that nobody manages it`, func(b Business) string { return strconv.FormatBool(b.LikelyUnclaimed) }},
+	{"UnclaimedSignals", "Signs nobody manages the Google profile, e.g. no website, no hours, no owner photos", func(b Business) string { return strings.Join(b.UnclaimedSignals, ", ") }},
+	{"Rating", "Google rating from 1.0 to 5.0, empty when unrated", func(b Business) string { return formatRating(b) }},
+	{"ReviewCount", "Number of Google reviews", func(b Business) string { return strconv.Itoa(b.ReviewCount) }},
+	{"EmailConfidence", "How sure the email is from 0 to 100: 100 when found on the site, less when guessed", func(b Business) string { return strconv.Itoa(b.EmailConfidence) }},
+	{"LeadScore", "Lead score from 0 to 100", func(b Business) string { return strconv.Itoa(b.LeadScore) }},
+	{"ScoreExplanation", "Points behind the lead score, e.g. +50 no website, +15 120 reviews; set when scored this run", func(b Business) string { return explainScore(b.ScoreBreakdown) }},
+	{"Trend", "How the rating, reviews and website changed recently, e.g. rating dropped 0.4 in 3 months", func(b Business) string { return b.Trend }},
+	{"DuplicateOf", "Lead this one looks like under another PlaceID, e.g. LEAD-12; empty unless flagged", func(b Business) string { return b.DuplicateOf }},
+	{"ReviewThemes", "One-line summary of what reviewers praise and complain about", func(b Business) string { return b.ReviewThemes }},
+	{"Facebook", "Facebook page URL", func(b Business) string { return b.Facebook }},
+	{"Instagram", "Instagram profile URL", func(b Business) string { return b.Instagram }},
+	{"LinkedIn", "LinkedIn company or profile URL", func(b Business) string { return b.LinkedIn }},
+	{"X", "X (Twitter) profile URL", func(b Business) string { return b.X }},
+	{"SearchArea", "Name of the search area the business was found in", func(b Business) string { return b.SearchArea }},
+	{"Campaign", "Campaign whose run found the business", func(b Business) string { return b.Campaign }},
+	{"SearchedType", "Place type searched when the business was found, empty for keyword queries", func(b Business) string { return b.SearchedType }},
+	{"SearchKeyword", "Keyword or text query searched when the business was found", func(b Business) string { return b.SearchKeyword }},
+	{"SearchCell", "Grid cell searched when the business was found, e.g. 50.15257,-5.06627 r2000m", func(b Business) string { return b.SearchCell }},
+	{"RunID", "Search run that wrote the business, e.g. 20261014-144740-3f9a", func(b Business) string { return b.RunID }},
+	{"Sources", "Comma-separated sources that listed the business, e.g. google, yelp", func(b Business) string { return strings.Join(b.Sources, ", ") }},
+	{"FirstSource", "Source that found the business first", func(b Business) string { return b.FirstSource }},
+	{"Provenance", "Sources of the fields other sources filled in, e.g. OpeningHours: osm, Phone: yelp", func(b Business) string { return formatProvenance(b.Provenance) }},
+	{"CompanyNumber", "Companies House registration number of UK companies", func(b Business) string { return b.CompanyNumber }},
+	{"CompanyStatus", "Companies House status, e.g. active or dissolved", func(b Business) string { return b.CompanyStatus }},
+	{"IncorporationDate", "Date the company was incorporated, e.g. 2015-03-02", func(b Business) string { return formatDate(b.IncorporationDate) }},
+	{"RegisteredAddress", "Registered office address", func(b Business) string { return b.RegisteredAddress }},
+	{"LeadNumber", "Human-friendly lead number, e.g. LEAD-123", func(b Business) string { return b.LeadNumber }},
+	{"AssignedTo", "Rep the lead is assigned to", func(b Business) string { return b.AssignedTo }},
+	{"CadenceStep", "Number of outreach cadence steps done", func(b Business) string { return strconv.Itoa(b.CadenceStep) }},
+	{"LastActionDate", "Date the last cadence step was done", func(b Business) string { return formatDate(b.LastActionDate) }},
+	{"NextAction", `Next cadence step, "Done" once all are, or "Stopped" for leads who opted out`, func(b Business) string { return b.NextAction }},
+	{"NextActionDate", "Date the next cadence step is due", func(b Business) string { return formatDate(b.NextActionDate) }},
+	{"LastContacted", "Date the lead was last contacted", func(b Business) string { return formatDate(b.LastContacted) }},
+	{"NextFollowUp", "Date the next follow-up is due, empty once the cadence is done or stopped", func(b Business) string { return formatDate(b.NextFollowUp) }},
+	{"NotionURL", "Link to the lead's Notion page", func(b Business) string { return b.PageURL }},
+}
+
+// formatRating renders a rating with one decimal place, or "" for unrated businesses
+func formatRating(b Business) string {
+	if b.ReviewCount == 0 {
+		return ""
+	}
+	return strconv.FormatFloat(b.Rating, 'f', 1, 64)
+}
+
+// formatCoordinate renders a latitude or longitude to six decimal places, or "" when the source
+// didn't place the business
+func formatCoordinate(b Business, coordinate float64) string {
+	if !b.located() {
+		return ""
+	}
+	return strconv.FormatFloat(coordinate, 'f', 6, 64)
+}
+
+// formatDistance renders a distance in km to one decimal place, or "" when it isn't known
+func formatDistance(km float64) string {
+	if km == 0 {
+		return ""
+	}
+	return strconv.FormatFloat(km, 'f', 1, 64)
+}
+
+// formatDate renders a date as YYYY-MM-DD, or "" for zero dates
+func formatDate(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.DateOnly)
+}
+
+// templateData returns the merge variables for a business, keyed by variable name
+func templateData(b Business) map[string]string {
+	data := make(map[string]string, len(templateVariables))
+	for _, v := range templateVariables {
+		data[v.Name] = v.Value(b)
+	}
+	return data
+}
+
+// parseOutreachTemplate parses a template that fails on unknown merge variables at execution time
+func parseOutreachTemplate(name, text string) (*template.Template, error) {
+	return template.New(name).Option("missingkey=error").Parse(text)
+}
+
+// renderTemplate executes an outreach template against a business
+func renderTemplate(tmpl *template.Template, b Business) (string, error) {
+	var sb strings.Builder
+	if err := tmpl.Execute(&sb, templateData(b)); err != nil {
+		return "", err
+	}
+	return sb.String(), nil
+}
+
+// runTemplate handles the template subcommands: vars lists the catalog, lint validates template files
+func runTemplate(args []string) {
+	if len(args) == 0 {
+		log.Fatal("usage: template vars | template lint FILE...")
+	}
+
+	switch args[0] {
+	case "vars":
+		for _, v := range templateVariables {
+			fmt.Printf("{{.%s}}\t%s\n", v.Name, v.Description)
+		}
+	case "lint":
+		fs := flag.NewFlagSet("template lint", flag.ExitOnError)
+		fs.Parse(args[1:])
+		if fs.NArg() == 0 {
+			log.Fatal("template lint: no template files given")
+		}
+		failed := false
+		for _, path := range fs.Args() {
+			problems, err := lintTemplateFile(path)
+			if err != nil {
+				fmt.Printf("%s: %v\n", path, err)
+				failed = true
+				continue
+			}
+			for _, problem := range problems {
+				fmt.Printf("%s: %s\n", path, problem)
+				failed = true
+			}
+			if len(problems) == 0 {
+				fmt.Printf("%s: ok\n", path)
+			}
+		}
+		if failed {
+			os.Exit(1)
+		}
+	default:
+		log.Fatalf("template: unknown subcommand %q", args[0])
+	}
+}
+
+// lintTemplateFile parses a template file and reports references to unknown merge variables
+func lintTemplateFile(path string) ([]string, error) {
+	text, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	tmpl, err := parseOutreachTemplate(path, string(text))
+	if err != nil {
+		return nil, err
+	}
+
+	known := make(map[string]bool, len(templateVariables))
+	for _, v := range templateVariables {
+		known[v.Name] = true
+	}
+
+	unknown := make(map[string]bool)
+	for _, t := range tmpl.Templates() {
+		if t.Tree != nil {
+			collectUnknownFields(t.Tree.Root, known, unknown)
+		}
+	}
+
+	var problems []string
+	for name := range unknown {
+		problems = append(problems, fmt.Sprintf("unknown merge variable {{.%s}}", name))
+	}
+	sort.Strings(problems)
+	return problems, nil
+}
+
+// collectUnknownFields walks a template parse tree and records top-level field references not in known.
+// Fields inside range and with blocks refer to a different dot and are not checked.
+func collectUnknownFields(node parse.Node, known, unknown map[string]bool) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, child := range n.Nodes {
+			collectUnknownFields(child, known, unknown)
+		}
+	case *parse.ActionNode:
+		collectUnknownFields(n.Pipe, known, unknown)
+	case *parse.PipeNode:
+		if n == nil {
+			return
+		}
+		for _, cmd := range n.Cmds {
+			collectUnknownFields(cmd, known, unknown)
+		}
+	case *parse.CommandNode:
+		for _, arg := range n.Args {
+			collectUnknownFields(arg, known, unknown)
+		}
+	case *parse.FieldNode:
+		if len(n.Ident) > 0 && !known[n.Ident[0]] {
+			unknown[n.Ident[0]] = true
+		}
+	case *parse.IfNode:
+		collectUnknownFields(n.Pipe, known, unknown)
+		collectUnknownFields(n.List, known, unknown)
+		collectUnknownFields(n.ElseList, known, unknown)
+	case *parse.RangeNode:
+		collectUnknownFields(n.Pipe, known, unknown)
+		collectUnknownFields(n.ElseList, known, unknown)
+	case *parse.WithNode:
+		collectUnknownFields(n.Pipe, known, unknown)
+		collectUnknownFields(n.ElseList, known, unknown)
+	}
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+	"googlemaps.github.io/maps"
+)
+
+const (
+	// placesRequestsPerSecond is the request rate throttling starts at and recovers to
+	placesRequestsPerSecond = 10
+	// minPlacesRequestsPerSecond is the slowest throttling slows requests to
+	minPlacesRequestsPerSecond = 0.2
+	// quotaBackoff is the first pause after a quota error; each further error in a row doubles it
+	quotaBackoff = 5 * time.Second
+	// maxQuotaBackoff caps a single pause
+	maxQuotaBackoff = 5 * time.Minute
+	// quotaRecovery is how many successful requests in a row raise the rate again
+	quotaRecovery = 50
+)
+
+// errQuotaExceeded marks responses refusing a request because a quota is used up
+var errQuotaExceeded = errors.New("quota exceeded")
+
+// isQuotaError reports whether a Places request failed on a quota: OVER_QUERY_LIMIT from the
+// legacy API, which the Maps client only reports in its error text, or RESOURCE_EXHAUSTED from the new one
+func isQuotaError(err error) bool {
+	if err == nil {
+		return false
+	}
+	return errors.Is(err, errQuotaExceeded) ||
+		strings.Contains(err.Error(), "OVER_QUERY_LIMIT") || strings.Contains(err.Error(), "RESOURCE_EXHAUSTED")
+}
+
+// QuotaThrottle paces Places requests and, when a quota is hit, pauses every request, retries the
+// one that failed and slows down, speeding up again as requests succeed. Requests are only given
+// up on once they have been paused for longer than maxPause.
+type QuotaThrottle struct {
+	limiter  *rate.Limiter
+	maxPause time.Duration
+
+	mu          sync.Mutex
+	pausedUntil time.Time
+	backoff     time.Duration
+	successes   int
+}
+
+// NewQuotaThrottle initializes a QuotaThrottle
+func NewQuotaThrottle(maxPause time.Duration) *QuotaThrottle {
+	return &QuotaThrottle{
+		limiter:  rate.NewLimiter(placesRequestsPerSecond, 1),
+		maxPause: maxPause,
+		backoff:  quotaBackoff,
+	}
+}
+
+// do runs a request, retrying it through quota pauses
+func (qt *QuotaThrottle) do(ctx context.Context, request func() error) error {
+	paused := time.Duration(0)
+	for {
+		if err := qt.wait(ctx); err != nil {
+			return err
+		}
+		err := request()
+		if !isQuotaError(err) {
+			qt.succeeded()
+			return err
+		}
+		pause := qt.exceeded()
+		if paused += pause; paused > qt.maxPause {
+			return err
+		}
+		slog.Warn("Places quota exceeded, pausing", "operation", "throttle", "pause", pause.String(),
+			"rate", float64(qt.limiter.Limit()), "err", err)
+	}
+}
+
+// wait blocks until any pause is over and the rate allows another request
+func (qt *QuotaThrottle) wait(ctx context.Context) error {
+	qt.mu.Lock()
+	until := qt.pausedUntil
+	qt.mu.Unlock()
+	if delay := time.Until(until); delay > 0 {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+	return qt.limiter.Wait(ctx)
+}
+
+// exceeded starts a pause, or extends the current one, and halves the request rate
+func (qt *QuotaThrottle) exceeded() time.Duration {
+	qt.mu.Lock()
+	defer qt.mu.Unlock()
+	pause := qt.backoff
+	qt.backoff = min(2*qt.backoff, maxQuotaBackoff)
+	qt.successes = 0
+	if until := time.Now().Add(pause); until.After(qt.pausedUntil) {
+		qt.pausedUntil = until
+	}
+	qt.limiter.SetLimit(max(qt.limiter.Limit()/2, minPlacesRequestsPerSecond))
+	return pause
+}
+
+// succeeded resets the backoff and, after enough successes in a row, raises the rate by a quarter
+func (qt *QuotaThrottle) succeeded() {
+	qt.mu.Lock()
+	defer qt.mu.Unlock()
+	qt.backoff = quotaBackoff
+	if qt.limiter.Limit() >= placesRequestsPerSecond {
+		return
+	}
+	if qt.successes++; qt.successes >= quotaRecovery {
+		qt.successes = 0
+		qt.limiter.SetLimit(min(qt.limiter.Limit()*1.25, placesRequestsPerSecond))
+	}
+}
+
+// throttledPlaces sends every request through a quota throttle
+type throttledPlaces struct {
+	PlacesProvider
+	throttle *QuotaThrottle
+}
+
+func (tp throttledPlaces) NearbySearch(ctx context.Context, r *maps.NearbySearchRequest) (res maps.PlacesSearchResponse, err error) {
+	err = tp.throttle.do(ctx, func() error {
+		res, err = tp.PlacesProvider.NearbySearch(ctx, r)
+		return err
+	})
+	return res, err
+}
+
+func (tp throttledPlaces) TextSearch(ctx context.Context, r *maps.TextSearchRequest) (res maps.PlacesSearchResponse, err error) {
+	err = tp.throttle.do(ctx, func() error {
+		res, err = tp.PlacesProvider.TextSearch(ctx, r)
+		return err
+	})
+	return res, err
+}
+
+func (tp throttledPlaces) PlaceDetails(ctx context.Context, r *maps.PlaceDetailsRequest) (res maps.PlaceDetailsResult, err error) {
+	err = tp.throttle.do(ctx, func() error {
+		res, err = tp.PlacesProvider.PlaceDetails(ctx, r)
+		return err
+	})
+	return res, err
+}
+
+func (tp throttledPlaces) FindPlaceFromText(ctx context.Context, r *maps.FindPlaceFromTextRequest) (res maps.FindPlaceFromTextResponse, err error) {
+	err = tp.throttle.do(ctx, func() error {
+		res, err = tp.PlacesProvider.FindPlaceFromText(ctx, r)
+		return err
+	})
+	return res, err
+}
+
+func (tp throttledPlaces) PhotoURL(ctx context.Context, reference string) (photoURL string, err error) {
+	err = tp.throttle.do(ctx, func() error {
+		photoURL, err = tp.PlacesProvider.PhotoURL(ctx, reference)
+		return err
+	})
+	return photoURL, err
+}
@@ -0,0 +1,10 @@
+package main
+
+import "time"
+
+// defaultRequestTimeout bounds a single API request, so a hung connection can't stall a run
+const defaultRequestTimeout = 30 * time.Second
+
+// requestTimeout is how long a single Notion, Google or other API request may take, set by
+// --request-timeout
+var requestTimeout = defaultRequestTimeout
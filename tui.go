@@ -0,0 +1,322 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/term"
+)
+
+const (
+	// tuiRefresh is how often the terminal UI redraws
+	tuiRefresh = 250 * time.Millisecond
+	// tuiHistory is how many messages and errors the terminal UI keeps
+	tuiHistory = 500
+)
+
+// TUI is the interactive terminal UI of --tui. It takes over the terminal for the length of a
+// run, showing each stage's progress, the run's messages and the warnings and errors logged, and
+// reads keys to pause and resume the run, skip the stage being searched and browse the errors.
+type TUI struct {
+	cancel   context.CancelFunc
+	stdout   *os.File
+	logger   *slog.Logger
+	rawState *term.State
+	started  time.Time
+
+	mu         sync.Mutex
+	messages   []string
+	errors     []string
+	showErrors bool
+	cursor     int
+	finished   bool
+	quitting   bool
+	quit       chan struct{}
+	stop       chan struct{}
+}
+
+// StartTUI takes over the terminal; cancel stops the run when the user quits before it finishes
+func StartTUI(cancel context.CancelFunc) (*TUI, error) {
+	stdin, stdout := int(os.Stdin.Fd()), int(os.Stdout.Fd())
+	if !term.IsTerminal(stdin) || !term.IsTerminal(stdout) {
+		return nil, fmt.Errorf("the terminal UI needs a terminal")
+	}
+	if !enableANSI(os.Stdout) {
+		return nil, fmt.Errorf("the terminal doesn't support ANSI escape sequences")
+	}
+	state, err := term.MakeRaw(stdin)
+	if err != nil {
+		return nil, err
+	}
+	t := &TUI{
+		cancel:   cancel,
+		stdout:   os.Stdout,
+		logger:   slog.Default(),
+		rawState: state,
+		started:  time.Now(),
+		quit:     make(chan struct{}),
+		stop:     make(chan struct{}),
+	}
+
+	// Everything printed during the run goes to the messages pane rather than over the UI
+	r, w, err := os.Pipe()
+	if err != nil {
+		term.Restore(stdin, state)
+		return nil, err
+	}
+	os.Stdout = w
+	progress.SetOutput(w, false)
+	go t.collect(r, false)
+	lr, lw := io.Pipe()
+	slog.SetDefault(slog.New(slog.NewTextHandler(lw, &slog.HandlerOptions{Level: logLevel})))
+	go t.collect(lr, true)
+
+	fmt.Fprint(t.stdout, "\033[?1049h\033[?25l")
+	go t.readKeys()
+	go t.refresh()
+	return t, nil
+}
+
+// collect adds the lines written to r to the messages, and to the errors when they are logged
+// warnings or errors
+func (t *TUI) collect(r io.Reader, logged bool) {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		t.mu.Lock()
+		t.messages = appendCapped(t.messages, line)
+		if logged && (strings.Contains(line, "level=WARN") || strings.Contains(line, "level=ERROR")) {
+			t.errors = appendCapped(t.errors, line)
+		}
+		t.mu.Unlock()
+	}
+}
+
+func appendCapped(lines []string, line string) []string {
+	lines = append(lines, line)
+	if len(lines) > tuiHistory {
+		lines = lines[len(lines)-tuiHistory:]
+	}
+	return lines
+}
+
+// readKeys handles p (pause/resume), s (skip stage), e (errors), j/k and the arrows (move through
+// the errors) and q or Ctrl+C (stop the run, or leave once it finished)
+func (t *TUI) readKeys() {
+	buf := make([]byte, 16)
+	for {
+		n, err := os.Stdin.Read(buf)
+		if err != nil {
+			return
+		}
+		// Progress is only used outside the lock: its messages reach collect, which takes it
+		key := string(buf[:n])
+		switch key {
+		case "p":
+			if progress.Paused() {
+				progress.Resume()
+			} else {
+				progress.Pause()
+			}
+		case "s":
+			progress.SkipStage()
+		}
+		stopRun := false
+		t.mu.Lock()
+		switch key {
+		case "e":
+			t.showErrors = !t.showErrors
+		case "j", "\033[B":
+			// With no errors yet the cursor stays on the first one to come
+			t.cursor = max(min(t.cursor+1, len(t.errors)-1), 0)
+		case "k", "\033[A":
+			t.cursor = max(t.cursor-1, 0)
+		case "q", "\x03":
+			if t.finished {
+				t.closeQuitLocked()
+			} else if !t.quitting {
+				t.quitting, stopRun = true, true
+			}
+		}
+		t.mu.Unlock()
+		if stopRun {
+			progress.Resume()
+			t.cancel()
+		}
+		t.draw()
+	}
+}
+
+func (t *TUI) closeQuitLocked() {
+	select {
+	case <-t.quit:
+	default:
+		close(t.quit)
+	}
+}
+
+func (t *TUI) refresh() {
+	ticker := time.NewTicker(tuiRefresh)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-t.stop:
+			return
+		case <-ticker.C:
+			t.draw()
+		}
+	}
+}
+
+// draw redraws the whole screen
+func (t *TUI) draw() {
+	width, height, err := term.GetSize(int(t.stdout.Fd()))
+	if err != nil {
+		width, height = 100, 30
+	}
+	totals, stages, paused := progress.Snapshot(), progress.Stages(), progress.Paused()
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	state := "running"
+	switch {
+	case t.finished:
+		state = "finished, q to leave"
+	case t.quitting:
+		state = "stopping"
+	case paused:
+		state = "PAUSED"
+	}
+	lines := []string{
+		fmt.Sprintf("Business finder: %s, %s", state, time.Since(t.started).Round(time.Second)),
+		fmt.Sprintf("%d found, %d inserted, %d skipped, %d failed, %d merged | $%.2f",
+			totals.Found, totals.Inserted, totals.Skipped, totals.Failed, totals.Merged, totals.Spend),
+		"",
+		fmt.Sprintf("%-30s %9s %6s %8s %7s %6s  %s", "Stage", "Steps", "Found", "Inserted", "Skipped", "Failed", "State"),
+	}
+
+	// The stages table gets up to half the screen, showing the latest stages
+	if room := max(height/2-len(lines), 1); len(stages) > room {
+		stages = stages[len(stages)-room:]
+	}
+	for i, s := range stages {
+		stageState := "done"
+		switch {
+		case s.Skip:
+			stageState = "skipped"
+		case i == len(stages)-1 && !t.finished:
+			stageState = "searching"
+		}
+		lines = append(lines, fmt.Sprintf("%-30s %4d/%-4d %6d %8d %7d %6d  %s",
+			truncate(s.Name, 30), s.Step, s.Steps, s.Found, s.Inserted, s.Skipped, s.Failed, stageState))
+	}
+	lines = append(lines, "")
+
+	footer := "p pause/resume · s skip stage · e errors · q quit"
+	room := max(height-len(lines)-2, 1)
+	if t.showErrors {
+		lines = append(lines, fmt.Sprintf("Errors and warnings (%d), j/k to move:", len(t.errors)))
+		lines = append(lines, t.errorLinesLocked(width, room-1)...)
+	} else {
+		lines = append(lines, fmt.Sprintf("Messages (%d errors and warnings, e to show):", len(t.errors)))
+		messages := t.messages
+		if len(messages) > room-1 {
+			messages = messages[len(messages)-(room-1):]
+		}
+		lines = append(lines, messages...)
+	}
+	for len(lines) < height-1 {
+		lines = append(lines, "")
+	}
+	lines = append(lines[:height-1], footer)
+
+	var sb strings.Builder
+	sb.WriteString("\033[H")
+	for i, line := range lines {
+		sb.WriteString(truncate(line, width))
+		sb.WriteString("\033[K")
+		if i < len(lines)-1 {
+			sb.WriteString("\r\n")
+		}
+	}
+	fmt.Fprint(t.stdout, sb.String())
+}
+
+// errorLinesLocked lists the errors around the cursor, with the selected one wrapped in full below
+func (t *TUI) errorLinesLocked(width, room int) []string {
+	if len(t.errors) == 0 {
+		return []string{"  none"}
+	}
+	t.cursor = max(min(t.cursor, len(t.errors)-1), 0)
+	selected := wrap(t.errors[t.cursor], width-2)
+	listed := max(room-len(selected)-1, 1)
+	first := max(min(t.cursor-listed/2, len(t.errors)-listed), 0)
+	var lines []string
+	for i := first; i < len(t.errors) && i < first+listed; i++ {
+		marker := "  "
+		if i == t.cursor {
+			marker = "> "
+		}
+		lines = append(lines, marker+t.errors[i])
+	}
+	lines = append(lines, "")
+	for _, line := range selected {
+		lines = append(lines, "  "+line)
+	}
+	return lines
+}
+
+func truncate(s string, width int) string {
+	if width <= 0 {
+		return ""
+	}
+	if r := []rune(s); len(r) > width {
+		return string(r[:width-1]) + "…"
+	}
+	return s
+}
+
+func wrap(s string, width int) []string {
+	width = max(width, 10)
+	var lines []string
+	for r := []rune(s); len(r) > 0; {
+		n := min(len(r), width)
+		lines = append(lines, string(r[:n]))
+		r = r[n:]
+	}
+	return lines
+}
+
+// Finish marks the run as finished
+func (t *TUI) Finish() {
+	t.mu.Lock()
+	t.finished = true
+	if t.quitting {
+		t.closeQuitLocked()
+	}
+	t.mu.Unlock()
+	t.draw()
+}
+
+// Wait blocks until the user leaves the UI
+func (t *TUI) Wait() {
+	<-t.quit
+}
+
+// Stop gives the terminal back
+func (t *TUI) Stop() {
+	close(t.stop)
+	fmt.Fprint(t.stdout, "\033[?25h\033[?1049l")
+	term.Restore(int(os.Stdin.Fd()), t.rawState)
+	slog.SetDefault(t.logger)
+	os.Stdout = t.stdout
+	// StartTUI checked that stdout is a terminal with ANSI support
+	progress.SetOutput(t.stdout, true)
+}
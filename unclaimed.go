@@ -0,0 +1,54 @@
+package main
+
+import (
+	"slices"
+	"strings"
+
+	"googlemaps.github.io/maps"
+)
+
+// unclaimedSignalsNeeded is how many signs of an unmanaged profile flag a listing as likely
+// unclaimed
+const unclaimedSignalsNeeded = 3
+
+// unclaimedSignals lists the signs in a place's details that nobody manages its Google profile:
+// no website, hours or phone, and no photos uploaded by the business itself, which Google
+// attributes to the business's name. Only the fields requested are judged, as a field left out
+// of the request looks empty; the Places APIs don't say whether a profile is claimed outright.
+func unclaimedSignals(name string, details maps.PlaceDetailsResult, socials SocialProfiles, fields []maps.PlaceDetailsFieldMask) []string {
+	requested := func(field maps.PlaceDetailsFieldMask) bool {
+		return len(fields) == 0 || slices.Contains(fields, field)
+	}
+	var signals []string
+	// A social profile given as the website was still put there by someone
+	if requested(maps.PlaceDetailsFieldMaskWebsite) && details.Website == "" && socials.IsEmpty() {
+		signals = append(signals, "no website")
+	}
+	if requested(maps.PlaceDetailsFieldMaskOpeningHours) && (details.OpeningHours == nil || len(details.OpeningHours.WeekdayText) == 0) {
+		signals = append(signals, "no hours")
+	}
+	if requested(maps.PlaceDetailsFieldMaskInternationalPhoneNumber) && details.InternationalPhoneNumber == "" && details.FormattedPhoneNumber == "" {
+		signals = append(signals, "no phone")
+	}
+	if requested(maps.PlaceDetailsFieldMaskPhotos) && !slices.ContainsFunc(details.Photos, func(photo maps.Photo) bool {
+		return ownerPhoto(name, photo)
+	}) {
+		signals = append(signals, "no owner photos")
+	}
+	return signals
+}
+
+// ownerPhoto reports whether a photo was uploaded by the business, going by its attribution
+func ownerPhoto(name string, photo maps.Photo) bool {
+	business := normalizeName(name)
+	if business == "" {
+		return false
+	}
+	for _, attribution := range photo.HTMLAttributions {
+		author := normalizeName(htmlTagPattern.ReplaceAllString(attribution, ""))
+		if author != "" && strings.Contains(author, business) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultUsagePath is where the usage ledger is kept unless --usage-file says otherwise
+	defaultUsagePath = ".business-finder-usage.json"
+	// defaultProfile is the ledger profile of runs without a campaign
+	defaultProfile = "default"
+	// usageWarning is the share of a monthly budget at which a warning is logged
+	usageWarning = 0.8
+)
+
+// usageEntry is one profile's Google usage in one month
+type usageEntry struct {
+	Spend    float64        `json:"spend"`
+	Requests map[string]int `json:"requests"`
+}
+
+// UsageLedger records the estimated Google spend of every profile (campaign) per calendar month,
+// across runs, so monthly budgets can keep one campaign from using up the shared billing account
+type UsageLedger struct {
+	path string
+
+	mu sync.Mutex
+	// months maps e.g. "2026-10" to the usage of each profile that month
+	months  map[string]map[string]*usageEntry
+	changed bool
+}
+
+// LoadUsageLedger reads the ledger at path; a missing file is an empty ledger
+func LoadUsageLedger(path string) (*UsageLedger, error) {
+	ul := &UsageLedger{path: path, months: make(map[string]map[string]*usageEntry)}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return ul, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &ul.months); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	return ul, nil
+}
+
+// usageMonth is the ledger key of the month t falls in
+func usageMonth(t time.Time) string {
+	return t.UTC().Format("2006-01")
+}
+
+// Spent returns what a profile has spent in a month
+func (ul *UsageLedger) Spent(month, profile string) float64 {
+	ul.mu.Lock()
+	defer ul.mu.Unlock()
+	if entry := ul.months[month][profile]; entry != nil {
+		return entry.Spend
+	}
+	return 0
+}
+
+// Add records a request of a kind made for a profile
+func (ul *UsageLedger) Add(month, profile, kind string, price float64) {
+	ul.mu.Lock()
+	defer ul.mu.Unlock()
+	profiles, ok := ul.months[month]
+	if !ok {
+		profiles = make(map[string]*usageEntry)
+		ul.months[month] = profiles
+	}
+	entry, ok := profiles[profile]
+	if !ok {
+		entry = &usageEntry{Requests: make(map[string]int)}
+		profiles[profile] = entry
+	}
+	entry.Spend += price
+	entry.Requests[kind]++
+	ul.changed = true
+}
+
+// Save writes the ledger if usage was added since it was loaded
+func (ul *UsageLedger) Save() error {
+	ul.mu.Lock()
+	defer ul.mu.Unlock()
+	if !ul.changed {
+		return nil
+	}
+	data, err := json.MarshalIndent(ul.months, "", "  ")
+	if err != nil {
+		return err
+	}
+	err = replaceFile(ul.path, func(f *os.File) error {
+		_, err := f.Write(data)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	ul.changed = false
+	return nil
+}
+
+// Report lists each profile's spend in a month against its monthly budget
+func (ul *UsageLedger) Report(month string, budget func(profile string) float64) string {
+	ul.mu.Lock()
+	defer ul.mu.Unlock()
+	profiles := make([]string, 0, len(ul.months[month]))
+	for profile := range ul.months[month] {
+		profiles = append(profiles, profile)
+	}
+	if len(profiles) == 0 {
+		return fmt.Sprintf("No Google usage recorded in %s\n", month)
+	}
+	sort.Strings(profiles)
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Google usage in %s:\n", month)
+	for _, profile := range profiles {
+		entry := ul.months[month][profile]
+		requests := 0
+		for _, n := range entry.Requests {
+			requests += n
+		}
+		fmt.Fprintf(&sb, "  %s: $%.2f, %d requests", profile, entry.Spend, requests)
+		if limit := budget(profile); limit > 0 {
+			fmt.Fprintf(&sb, ", %.0f%% of the $%.2f monthly budget", 100*entry.Spend/limit, limit)
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+// runUsage prints the usage ledger for a month
+func runUsage(cfg *Config, ledger *UsageLedger, args []string) {
+	fs := flag.NewFlagSet("usage", flag.ExitOnError)
+	month := fs.String("month", usageMonth(time.Now()), "month to report, e.g. 2026-10")
+	fs.Parse(args)
+
+	if _, err := time.Parse("2006-01", *month); err != nil {
+		log.Fatalf("usage: invalid --month %q, expected e.g. 2026-10", *month)
+	}
+	fmt.Print(ledger.Report(*month, cfg.ProfileBudget))
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sort"
+	"strings"
+)
+
+// verifyMatchThreshold is the minimum name similarity for a baseline entry to count as found
+const verifyMatchThreshold = 0.6
+
+// baselineBusiness is one entry of an authoritative business list
+type baselineBusiness struct {
+	Name     string
+	Address  string
+	Postcode string
+}
+
+// runVerify cross-checks the businesses in Notion against a baseline CSV and reports recall and misses
+func runVerify(notionClient *NotionClient, args []string) {
+	fs := flag.NewFlagSet("verify", flag.ExitOnError)
+	baselinePath := fs.String("baseline", "", "CSV of known businesses with a name column and optional address/postcode columns")
+	missesPath := fs.String("misses", "", "write baseline entries that were not discovered to this CSV")
+	fs.Parse(args)
+
+	if *baselinePath == "" {
+		log.Fatal("verify: --baseline is required")
+	}
+	baseline, err := readBaseline(*baselinePath)
+	if err != nil {
+		log.Fatalf("Failed to read baseline: %v", err)
+	}
+
+	// Keep only what matching needs; whole pages add up on county-sized databases
+	var discovered []Business
+	err = notionClient.EachBusiness(context.Background(), nil, func(b Business) error {
+		discovered = append(discovered, Business{Name: b.Name, Address: b.Address})
+		return nil
+	})
+	if err != nil {
+		log.Fatalf("Failed to list businesses: %v", err)
+	}
+
+	var misses []baselineBusiness
+	for _, known := range baseline {
+		if !baselineFound(known, discovered) {
+			misses = append(misses, known)
+		}
+	}
+
+	if stableOrder {
+		sort.SliceStable(misses, func(i, j int) bool {
+			if misses[i].Name != misses[j].Name {
+				return misses[i].Name < misses[j].Name
+			}
+			return misses[i].Address < misses[j].Address
+		})
+	}
+
+	found := len(baseline) - len(misses)
+	recall := 0.0
+	if len(baseline) > 0 {
+		recall = float64(found) / float64(len(baseline)) * 100
+	}
+	fmt.Printf("Baseline: %d businesses, discovered: %d, matched: %d\n", len(baseline), len(discovered), found)
+	fmt.Printf("Recall: %.1f%%\n", recall)
+	if len(misses) > 0 {
+		fmt.Printf("Missed %d businesses:\n", len(misses))
+		for _, miss := range misses {
+			fmt.Printf("  %s\t%s\n", miss.Name, miss.Address)
+		}
+	}
+
+	if *missesPath != "" {
+		if err := writeBaseline(*missesPath, misses); err != nil {
+			log.Fatalf("Failed to write misses: %v", err)
+		}
+		fmt.Printf("Misses written to %s\n", *missesPath)
+	}
+}
+
+// baselineFound reports whether a baseline entry matches any discovered business.
+// When both sides have a postcode they must agree, which keeps common names like "The Bakery" apart.
+func baselineFound(known baselineBusiness, discovered []Business) bool {
+	for _, b := range discovered {
+		if known.Postcode != "" {
+			if postcode := extractPostcode(b.Address); postcode != "" && postcode != known.Postcode {
+				continue
+			}
+		}
+		if normalizeName(known.Name) == normalizeName(b.Name) || tokenSetSimilarity(known.Name, b.Name) >= verifyMatchThreshold {
+			return true
+		}
+	}
+	return false
+}
+
+// readBaseline parses a baseline CSV, locating the name, address and postcode columns from its header
+func readBaseline(path string) ([]baselineBusiness, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	r := csv.NewReader(f)
+	r.FieldsPerRecord = -1
+	header, err := r.Read()
+	if err != nil {
+		return nil, err
+	}
+	nameCol, addressCol, postcodeCol := -1, -1, -1
+	for i, column := range header {
+		switch strings.ToLower(strings.TrimSpace(column)) {
+		case "name", "business", "business name", "company", "company name":
+			nameCol = i
+		case "address", "full address":
+			addressCol = i
+		case "postcode", "post code", "zip", "postal code":
+			postcodeCol = i
+		}
+	}
+	if nameCol < 0 {
+		return nil, fmt.Errorf("%s: no name column in header", path)
+	}
+
+	var baseline []baselineBusiness
+	for {
+		record, err := r.Read()
+		if err == io.EOF {
+			return baseline, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		entry := baselineBusiness{Name: column(record, nameCol), Address: column(record, addressCol)}
+		if entry.Name == "" {
+			continue
+		}
+		entry.Postcode = extractPostcode(column(record, postcodeCol))
+		if entry.Postcode == "" {
+			entry.Postcode = extractPostcode(entry.Address)
+		}
+		baseline = append(baseline, entry)
+	}
+}
+
+// writeBaseline writes baseline entries back out as CSV
+func writeBaseline(path string, entries []baselineBusiness) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	w := csv.NewWriter(f)
+	w.Write([]string{"name", "address", "postcode"})
+	for _, entry := range entries {
+		w.Write([]string{entry.Name, entry.Address, entry.Postcode})
+	}
+	w.Flush()
+	return w.Error()
+}
+
+// column returns a trimmed CSV field, or "" when the column is absent
+func column(record []string, i int) string {
+	if i < 0 || i >= len(record) {
+		return ""
+	}
+	return strings.TrimSpace(record[i])
+}
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/mail"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// maxPageBytes caps how much of a single page is read when crawling a website.
+// Contact details are almost always near the top or in the footer of a page well under this size.
+const maxPageBytes = 1 << 20
+
+var (
+	hrefPattern  = regexp.MustCompile(`(?i)href\s*=\s*["']([^"']+)["']`)
+	emailPattern = regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`)
+)
+
+// emailBlockedSuffixes are file extensions that look like email domains in asset names such as logo@2x.png
+var emailBlockedSuffixes = []string{".png", ".jpg", ".jpeg", ".gif", ".svg", ".webp", ".css", ".js"}
+
+// emailPreferredPrefixes are mailbox names that usually reach the business owner
+var emailPreferredPrefixes = []string{"info", "hello", "contact", "enquiries", "office", "admin", "sales"}
+
+// WebsiteCrawler fetches business websites to extract contact details
+type WebsiteCrawler struct {
+	client *http.Client
+}
+
+// NewWebsiteCrawler initializes a new WebsiteCrawler
+func NewWebsiteCrawler() *WebsiteCrawler {
+	return &WebsiteCrawler{
+		client: &http.Client{Timeout: 15 * time.Second},
+	}
+}
+
+// fetchPage downloads a page and returns its body as a string, along with the URL it was served from after redirects
+func (wc *WebsiteCrawler) fetchPage(ctx context.Context, pageURL string) (string, *url.URL, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, pageURL, nil)
+	if err != nil {
+		return "", nil, err
+	}
+	req.Header.Set("User-Agent", "business-finder/1.0")
+
+	resp, err := wc.client.Do(req)
+	if err != nil {
+		return "", nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		return "", nil, fmt.Errorf("fetching %s: status %d", pageURL, resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxPageBytes))
+	if err != nil {
+		return "", nil, err
+	}
+	return string(body), resp.Request.URL, nil
+}
+
+// SiteInfo holds the contact details found on a business website
+type SiteInfo struct {
+	Email   string
+	Socials SocialProfiles
+	// HTTPS reports whether the homepage was ultimately served over TLS
+	HTTPS bool
+}
+
+// Crawl fetches the homepage and contact page of a website and extracts contact details
+func (wc *WebsiteCrawler) Crawl(ctx context.Context, website string) (*SiteInfo, error) {
+	base, err := url.Parse(website)
+	if err != nil {
+		return nil, err
+	}
+
+	homepage, finalURL, err := wc.fetchPage(ctx, website)
+	if err != nil {
+		return nil, err
+	}
+
+	candidates := extractEmails(homepage)
+	socials := extractSocialProfiles(homepage)
+	if contactURL := findContactPage(base, homepage); contactURL != "" {
+		contactPage, _, err := wc.fetchPage(ctx, contactURL)
+		if err == nil {
+			candidates = append(candidates, extractEmails(contactPage)...)
+			socials.Merge(extractSocialProfiles(contactPage))
+		}
+	}
+
+	return &SiteInfo{
+		Email:   bestEmail(candidates, base.Hostname()),
+		Socials: socials,
+		HTTPS:   finalURL.Scheme == "https",
+	}, nil
+}
+
+// emailCandidate is an address found on a page, along with whether it came from a mailto link
+type emailCandidate struct {
+	Address string
+	Mailto  bool
+}
+
+// extractEmails collects mailto links and email-looking strings from a page
+func extractEmails(page string) []emailCandidate {
+	var candidates []emailCandidate
+	for _, match := range hrefPattern.FindAllStringSubmatch(page, -1) {
+		href := match[1]
+		if !strings.HasPrefix(strings.ToLower(href), "mailto:") {
+			continue
+		}
+		address := href[len("mailto:"):]
+		if i := strings.Index(address, "?"); i >= 0 {
+			address = address[:i]
+		}
+		if decoded, err := url.PathUnescape(address); err == nil {
+			address = decoded
+		}
+		if email, ok := validateEmail(address); ok {
+			candidates = append(candidates, emailCandidate{Address: email, Mailto: true})
+		}
+	}
+	for _, match := range emailPattern.FindAllString(page, -1) {
+		if email, ok := validateEmail(match); ok {
+			candidates = append(candidates, emailCandidate{Address: email})
+		}
+	}
+	return candidates
+}
+
+// validateEmail normalizes an address and rejects strings that only look like emails
+func validateEmail(address string) (string, bool) {
+	address = strings.ToLower(strings.TrimSpace(address))
+	parsed, err := mail.ParseAddress(address)
+	if err != nil || parsed.Address != address {
+		return "", false
+	}
+	for _, suffix := range emailBlockedSuffixes {
+		if strings.HasSuffix(address, suffix) {
+			return "", false
+		}
+	}
+	domain := address[strings.LastIndex(address, "@")+1:]
+	if !strings.Contains(domain, ".") || strings.Contains(domain, "example.") || strings.HasSuffix(domain, "sentry.io") {
+		return "", false
+	}
+	return address, true
+}
+
+// findContactPage returns the absolute URL of the first link that looks like a contact page
+func findContactPage(base *url.URL, page string) string {
+	for _, match := range hrefPattern.FindAllStringSubmatch(page, -1) {
+		href := match[1]
+		if !strings.Contains(strings.ToLower(href), "contact") || strings.HasPrefix(strings.ToLower(href), "mailto:") {
+			continue
+		}
+		ref, err := url.Parse(href)
+		if err != nil {
+			continue
+		}
+		resolved := base.ResolveReference(ref)
+		if resolved.Hostname() != base.Hostname() {
+			continue
+		}
+		return resolved.String()
+	}
+	return ""
+}
+
+// bestEmail ranks candidates, preferring the site's own domain, mailto links, and generic business mailboxes
+func bestEmail(candidates []emailCandidate, host string) string {
+	host = strings.TrimPrefix(strings.ToLower(host), "www.")
+
+	best := ""
+	bestScore := -1
+	for _, c := range candidates {
+		score := 0
+		local, domain, _ := strings.Cut(c.Address, "@")
+		if host != "" && (domain == host || strings.HasSuffix(host, "."+domain) || strings.HasSuffix(domain, "."+host)) {
+			score += 4
+		}
+		if c.Mailto {
+			score += 2
+		}
+		for _, prefix := range emailPreferredPrefixes {
+			if local == prefix {
+				score++
+				break
+			}
+		}
+		if score > bestScore {
+			best = c.Address
+			bestScore = score
+		}
+	}
+	return best
+}
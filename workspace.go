@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+
+	"github.com/jomei/notionapi"
+)
+
+// outreachSteps are the to-dos of the outreach notes section every lead page starts with
+var outreachSteps = []string{"Researched the business", "First contact", "Followed up", "Got a reply"}
+
+// setupSteps say how to add the views the API can't create, on the setup page next to a new database
+var setupSteps = []string{
+	`Open the Businesses database, add a Board view and group it by "Contacted", to move leads through outreach.`,
+	`Add a Table view named "No Website" filtered to WebsiteStatus is "No Website", the hottest leads.`,
+	`Every lead page starts with an "Outreach notes" section; record calls and emails there as the to-dos are ticked.`,
+}
+
+// paragraph is a block of plain text
+func paragraph(content string) notionapi.ParagraphBlock {
+	return notionapi.ParagraphBlock{
+		BasicBlock: notionapi.BasicBlock{Object: notionapi.ObjectTypeBlock, Type: notionapi.BlockTypeParagraph},
+		Paragraph:  notionapi.Paragraph{RichText: richText(content)},
+	}
+}
+
+// outreachBlocks are the outreach notes section of a lead page: the steps to tick and room for notes
+func outreachBlocks() []notionapi.Block {
+	blocks := []notionapi.Block{notionapi.Heading3Block{
+		BasicBlock: notionapi.BasicBlock{Object: notionapi.ObjectTypeBlock, Type: notionapi.BlockTypeHeading3},
+		Heading3:   notionapi.Heading{RichText: richText("Outreach notes")},
+	}}
+	for _, step := range outreachSteps {
+		blocks = append(blocks, notionapi.ToDoBlock{
+			BasicBlock: notionapi.BasicBlock{Object: notionapi.ObjectTypeBlock, Type: notionapi.BlockTypeToDo},
+			ToDo:       notionapi.ToDo{RichText: richText(step)},
+		})
+	}
+	return append(blocks, paragraph("Notes:"))
+}
+
+// CreateSetupPage adds a page next to a newly created database linking to it and saying how to
+// set up its board and No Website views, which the Notion API has no way of creating
+func (nc *NotionClient) CreateSetupPage(ctx context.Context) error {
+	children := []notionapi.Block{
+		notionapi.LinkToPageBlock{
+			BasicBlock: notionapi.BasicBlock{Object: notionapi.ObjectTypeBlock, Type: notionapi.BlockTypeLinkToPage},
+			LinkToPage: notionapi.LinkToPage{Type: "database_id", DatabaseID: nc.databaseID},
+		},
+	}
+	for _, step := range setupSteps {
+		children = append(children, notionapi.NumberedListItemBlock{
+			BasicBlock:       notionapi.BasicBlock{Object: notionapi.ObjectTypeBlock, Type: notionapi.BlockTypeNumberedListItem},
+			NumberedListItem: notionapi.ListItem{RichText: richText(step)},
+		})
+	}
+	_, err := nc.client.Page.Create(ctx, &notionapi.PageCreateRequest{
+		Parent: notionapi.Parent{Type: notionapi.ParentTypePageID, PageID: nc.pageID},
+		Properties: notionapi.Properties{
+			"title": notionapi.TitleProperty{Title: richText("Setting up the Businesses database")},
+		},
+		Children: children,
+	})
+	return err
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"googlemaps.github.io/maps"
+)
+
+const (
+	// yelpSearchURL is the Yelp Fusion business search endpoint
+	yelpSearchURL = "https://api.yelp.com/v3/businesses/search"
+	// yelpPageSize is the most businesses Yelp returns per request
+	yelpPageSize = 50
+	// yelpMaxResults is how deep Yelp lets a search page, offset plus limit
+	yelpMaxResults = 240
+	// yelpMaxRadius is the largest search radius Yelp accepts, in metres
+	yelpMaxRadius = 40000
+)
+
+// YelpSource finds businesses with the Yelp Fusion API. Yelp doesn't list business websites, so
+// its leads are recorded as "Unknown Website" until another source or a recheck says otherwise.
+type YelpSource struct {
+	apiKey string
+	client *http.Client
+}
+
+// NewYelpSource initializes a new YelpSource
+func NewYelpSource(apiKey string) *YelpSource {
+	return &YelpSource{
+		apiKey: apiKey,
+		client: &http.Client{Timeout: requestTimeout},
+	}
+}
+
+func (ys *YelpSource) Name() string { return "yelp" }
+
+// yelpBusiness is a business as returned by Yelp's search
+type yelpBusiness struct {
+	ID           string  `json:"id"`
+	Name         string  `json:"name"`
+	URL          string  `json:"url"`
+	Phone        string  `json:"phone"`
+	DisplayPhone string  `json:"display_phone"`
+	Rating       float64 `json:"rating"`
+	ReviewCount  int     `json:"review_count"`
+	IsClosed     bool    `json:"is_closed"`
+	Categories   []struct {
+		Alias string `json:"alias"`
+	} `json:"categories"`
+	Coordinates struct {
+		Latitude  float64 `json:"latitude"`
+		Longitude float64 `json:"longitude"`
+	} `json:"coordinates"`
+	Location struct {
+		DisplayAddress []string `json:"display_address"`
+	} `json:"location"`
+}
+
+// Search runs a term search for each of the area's types and queries in every search cell
+func (ys *YelpSource) Search(ctx context.Context, area *SearchArea, fn func(*Business)) error {
+	progress.Verbosef("Searching %s on Yelp\n", area.Name)
+	seen := make(map[string]struct{})
+	for _, st := range area.searchTerms() {
+		typeArea := area.forType(st)
+		cells := typeArea.Cells()
+		progress.Stage(fmt.Sprintf("yelp %q", st.term()), len(cells))
+		for _, cell := range cells {
+			if !progress.Proceed(ctx) {
+				break
+			}
+			if err := ys.searchCell(ctx, typeArea, cell, st, seen, fn); err != nil {
+				return err
+			}
+			progress.Step()
+		}
+	}
+	return nil
+}
+
+// searchCell pages through the results of one term in one cell
+func (ys *YelpSource) searchCell(ctx context.Context, area *SearchArea, cell SearchCell, st SearchType, seen map[string]struct{}, fn func(*Business)) error {
+	term := st.term()
+	for offset := 0; offset+yelpPageSize <= yelpMaxResults; offset += yelpPageSize {
+		query := url.Values{}
+		query.Set("term", term)
+		query.Set("latitude", strconv.FormatFloat(cell.Center.Lat, 'f', 6, 64))
+		query.Set("longitude", strconv.FormatFloat(cell.Center.Lng, 'f', 6, 64))
+		query.Set("radius", strconv.Itoa(int(min(cell.Radius, yelpMaxRadius))))
+		query.Set("limit", strconv.Itoa(yelpPageSize))
+		query.Set("offset", strconv.Itoa(offset))
+
+		var res struct {
+			Businesses []yelpBusiness `json:"businesses"`
+			Total      int            `json:"total"`
+		}
+		if err := ys.get(ctx, query, &res); err != nil {
+			return fmt.Errorf("searching %q: %w", term, err)
+		}
+		for _, yb := range res.Businesses {
+			if _, ok := seen[yb.ID]; ok {
+				continue
+			}
+			seen[yb.ID] = struct{}{}
+			business := yb.business(area)
+			if !area.InBoundary(business.Location) {
+				continue
+			}
+			business.tagSearch(st, cell)
+			fn(business)
+		}
+		if offset+yelpPageSize >= res.Total {
+			return nil
+		}
+	}
+	return nil
+}
+
+func (ys *YelpSource) get(ctx context.Context, query url.Values, out any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, yelpSearchURL+"?"+query.Encode(), nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+ys.apiKey)
+	resp, err := ys.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("yelp: unexpected status %d", resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// business converts a Yelp listing to a Business. Its PlaceID is prefixed so it can't collide
+// with Google's.
+func (yb yelpBusiness) business(area *SearchArea) *Business {
+	var types []string
+	for _, c := range yb.Categories {
+		types = append(types, c.Alias)
+	}
+	if len(types) == 0 {
+		types = []string{"Other"}
+	}
+	// The listing URL carries tracking parameters that change between requests
+	listing, _, _ := strings.Cut(yb.URL, "?")
+	business := &Business{
+		Name:          yb.Name,
+		Address:       strings.Join(yb.Location.DisplayAddress, ", "),
+		PlaceID:       "yelp:" + yb.ID,
+		Type:          types,
+		WebsiteStatus: "Unknown Website",
+		URL:           listing,
+		Phone:         normalizePhone(yb.Phone, yb.DisplayPhone),
+		ListedPhone:   cmp.Or(yb.Phone, yb.DisplayPhone),
+		Rating:        yb.Rating,
+		ReviewCount:   yb.ReviewCount,
+		SearchArea:    area.Name,
+		Location:      maps.LatLng{Lat: yb.Coordinates.Latitude, Lng: yb.Coordinates.Longitude},
+	}
+	// Yelp only marks businesses closed for good
+	if yb.IsClosed {
+		business.BusinessStatus = statusClosedPermanently
+	}
+	return business
+}